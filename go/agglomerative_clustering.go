@@ -1,6 +1,7 @@
 package cuml4go
 
 import (
+	"context"
 	"errors"
 
 	"github.com/getumen/cuml-bindings/go/rawcuml4go"
@@ -11,7 +12,8 @@ var (
 )
 
 type AgglomerativeClustering struct {
-	deviceResource *rawcuml4go.DeviceResource
+	resources      *Resources
+	ownsResources  bool
 	pairwiseConn   bool
 	metric         Metric
 	initNumCluster int
@@ -23,15 +25,17 @@ func NewAgglomerativeClustering(
 	metric Metric,
 	initNumCluster int,
 	numNeighbor int,
+	opts ...Option,
 ) (*AgglomerativeClustering, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	resources, owned, err := newConfig(opts).deviceResources()
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &AgglomerativeClustering{
-		deviceResource: deviceResource,
+		resources:      resources,
+		ownsResources:  owned,
 		pairwiseConn:   pairwiseConn,
 		metric:         metric,
 		initNumCluster: initNumCluster,
@@ -50,19 +54,42 @@ func (c *AgglomerativeClustering) Fit(
 	numRow int,
 	numCol int,
 ) ([]int32, []int32, int32, error) {
+	return c.FitContext(context.Background(), x, numRow, numCol)
+}
 
-	labels, children, numCluster, err := rawcuml4go.AgglomerativeClustering(
-		c.deviceResource,
-		x,
-		numRow,
-		numCol,
-		c.pairwiseConn,
-		int(c.metric),
-		c.initNumCluster,
-		c.numNeighbor,
-		nil,
-		nil,
+// FitContext is the context-aware variant of Fit.
+// the native call cannot be interrupted, so it returns ctx.Err() as soon as ctx is done
+// while the call runs to completion in the background;
+// its device resource handle is not reused until then, and it reads a copy of x,
+// which the caller may reuse as soon as FitContext returns.
+func (c *AgglomerativeClustering) FitContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	numCol int,
+) ([]int32, []int32, int32, error) {
+	var (
+		labels     []int32
+		children   []int32
+		numCluster int32
 	)
+	x = ownedInput(ctx, x)
+	err := callContext(ctx, c.resources, func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		labels, children, numCluster, err = rawcuml4go.AgglomerativeClustering(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			c.pairwiseConn,
+			int(c.metric),
+			c.initNumCluster,
+			c.numNeighbor,
+			nil,
+			nil,
+		)
+		return err
+	})
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -70,6 +97,7 @@ func (c *AgglomerativeClustering) Fit(
 	return labels, children, numCluster, nil
 }
 
+// Close frees the device resources unless they are shared by WithResources.
 func (c *AgglomerativeClustering) Close() error {
-	return c.deviceResource.Close()
+	return closeResources(c.resources, c.ownsResources)
 }
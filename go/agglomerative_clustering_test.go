@@ -8,6 +8,7 @@ import (
 )
 
 func TestAgglomerativeClustering(t *testing.T) {
+	skipWithoutNativeLibrary(t)
 	features := csvToFloat32Array(t, "../testdata/feature.csv")
 	featureCol := 30
 	featureRow := 114
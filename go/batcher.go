@@ -0,0 +1,371 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidBatcherConfig is returned when a batcher config is invalid.
+	ErrInvalidBatcherConfig = errors.New("invalid batcher config")
+	// ErrBatcherClosed is returned when a batcher is used after Close.
+	ErrBatcherClosed = errors.New("batcher is closed")
+)
+
+// Predictor is a model which predicts rows of features in a single call.
+// the result has 2 values per row if outputClassProbability is true, and 1 otherwise.
+// it is implemented by FILModel.
+type Predictor interface {
+	PredictContext(
+		ctx context.Context,
+		x []float32,
+		numRow int,
+		outputClassProbability bool,
+	) ([]float32, error)
+	NumFeatures() int
+}
+
+// BatcherConfig is the configuration of Batcher.
+type BatcherConfig struct {
+	// MaxBatchSize is the maximum number of rows passed to a single Predict.
+	// a request larger than MaxBatchSize is predicted alone.
+	MaxBatchSize int
+	// MaxWait is the maximum time the first request of a batch waits for other requests.
+	MaxWait time.Duration
+	// Concurrency is the number of batches predicted at once. it defaults to 1.
+	// it should not exceed the size of the Resources of the predictor.
+	Concurrency int
+	// QueueSize is the number of requests which can wait for a batch. it defaults to MaxBatchSize.
+	QueueSize int
+	// OutputClassProbability is passed to every Predict.
+	OutputClassProbability bool
+}
+
+// Validate checks the configuration.
+func (c BatcherConfig) Validate() error {
+	if c.MaxBatchSize <= 0 || c.MaxWait < 0 || c.Concurrency < 0 || c.QueueSize < 0 {
+		return ErrInvalidBatcherConfig
+	}
+	return nil
+}
+
+// queueWaitBounds are the upper bounds of the queue wait histogram, in seconds.
+var queueWaitBounds = []float64{
+	0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1,
+}
+
+// Histogram is a cumulative histogram of observed values.
+type Histogram struct {
+	// Bounds are the inclusive upper bounds of the buckets in increasing order.
+	Bounds []float64
+	// Counts are the number of observations in every bucket;
+	// the last count is for the values greater than every bound.
+	Counts []uint64
+	// Sum is the sum of the observed values.
+	Sum float64
+	// Count is the number of observations.
+	Count uint64
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) observe(v float64) {
+	i := 0
+	for i < len(h.Bounds) && v > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += v
+	h.Count++
+}
+
+func (h Histogram) clone() Histogram {
+	h.Bounds = append([]float64(nil), h.Bounds...)
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// BatcherStats are the statistics of the batches predicted by Batcher.
+type BatcherStats struct {
+	// BatchSize is the histogram of the number of rows of the batches.
+	BatchSize Histogram
+	// QueueWait is the histogram of the time in seconds between
+	// the arrival of a request and the start of the Predict of its batch.
+	QueueWait Histogram
+}
+
+type batchRequest struct {
+	ctx     context.Context
+	x       []float32
+	numRow  int
+	arrival time.Time
+	done    chan batchResult
+}
+
+type batchResult struct {
+	preds []float32
+	err   error
+}
+
+// Batcher coalesces concurrent requests into batches to reduce the number of native calls.
+// a batch is predicted when it reaches MaxBatchSize rows or its first request waited MaxWait.
+// Batcher is safe for concurrent use.
+type Batcher struct {
+	predictor Predictor
+	config    BatcherConfig
+
+	requests chan *batchRequest
+	done     chan struct{}
+	workers  sync.WaitGroup
+
+	// mu guards closed; Predict holds the read lock while it enqueues.
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+
+	statsMu sync.Mutex
+	stats   BatcherStats
+}
+
+// NewBatcher starts a batcher of predictor.
+func NewBatcher(predictor Predictor, config BatcherConfig) (*Batcher, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = 1
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = config.MaxBatchSize
+	}
+
+	var batchSizeBounds []float64
+	for size := 1; size < config.MaxBatchSize; size *= 2 {
+		batchSizeBounds = append(batchSizeBounds, float64(size))
+	}
+	batchSizeBounds = append(batchSizeBounds, float64(config.MaxBatchSize))
+
+	b := &Batcher{
+		predictor: predictor,
+		config:    config,
+		requests:  make(chan *batchRequest, config.QueueSize),
+		done:      make(chan struct{}),
+		stats: BatcherStats{
+			BatchSize: newHistogram(batchSizeBounds),
+			QueueWait: newHistogram(queueWaitBounds),
+		},
+	}
+
+	b.workers.Add(config.Concurrency)
+	for i := 0; i < config.Concurrency; i++ {
+		go b.work()
+	}
+
+	return b, nil
+}
+
+// Predict returns the prediction result of numRow rows of x
+// with the same layout as the predictor's PredictContext.
+// it returns ctx.Err() as soon as ctx is done; a canceled request is dropped from its batch
+// unless the batch is already being predicted.
+func (b *Batcher) Predict(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	if numRow <= 0 || len(x) != numRow*b.predictor.NumFeatures() {
+		return nil, ErrDimensionMismatch
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return b.submit(ctx, x, numRow)
+}
+
+// PredictInto is the same as Predict but writes the result into dst,
+// whose length must be the length of the result.
+// dst is not written once PredictInto returns, even if ctx is done.
+func (b *Batcher) PredictInto(ctx context.Context, dst []float32, x []float32, numRow int) error {
+	if numRow <= 0 || len(x) != numRow*b.predictor.NumFeatures() {
+		return ErrDimensionMismatch
+	}
+	if len(dst) != numRow*filOutputWidth(b.config.OutputClassProbability) {
+		return ErrInvalidOutputLength
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	preds, err := b.submit(ctx, x, numRow)
+	if err != nil {
+		return err
+	}
+	copy(dst, preds)
+	return nil
+}
+
+// submit enqueues a request and waits for its slice of the batch result.
+func (b *Batcher) submit(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	req := &batchRequest{
+		ctx:     ctx,
+		x:       x,
+		numRow:  numRow,
+		arrival: time.Now(),
+		done:    make(chan batchResult, 1),
+	}
+
+	if err := b.enqueue(req); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-req.done:
+		return res.preds, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Batcher) enqueue(req *batchRequest) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBatcherClosed
+	}
+
+	select {
+	case b.requests <- req:
+		return nil
+	case <-req.ctx.Done():
+		return req.ctx.Err()
+	case <-b.done:
+		return ErrBatcherClosed
+	}
+}
+
+// Stats returns a snapshot of the statistics.
+func (b *Batcher) Stats() BatcherStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	return BatcherStats{
+		BatchSize: b.stats.BatchSize.clone(),
+		QueueWait: b.stats.QueueWait.clone(),
+	}
+}
+
+// Close stops accepting requests and waits until the queued requests are predicted.
+// it does not close the predictor.
+func (b *Batcher) Close() error {
+	b.closeOnce.Do(func() {
+		// wake up the requests blocked on a full queue before waiting for them
+		close(b.done)
+
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+
+		// no request is enqueued after closed is set
+		close(b.requests)
+		b.workers.Wait()
+	})
+	return nil
+}
+
+// work collects and predicts batches until the requests are closed.
+func (b *Batcher) work() {
+	defer b.workers.Done()
+
+	var next *batchRequest
+	for {
+		first := next
+		next = nil
+		if first == nil {
+			var ok bool
+			first, ok = <-b.requests
+			if !ok {
+				return
+			}
+		}
+
+		batch := []*batchRequest{first}
+		numRow := first.numRow
+
+		timer := time.NewTimer(b.config.MaxWait)
+	collect:
+		for numRow < b.config.MaxBatchSize {
+			select {
+			case req, ok := <-b.requests:
+				if !ok {
+					break collect
+				}
+				if numRow+req.numRow > b.config.MaxBatchSize {
+					next = req
+					break collect
+				}
+				batch = append(batch, req)
+				numRow += req.numRow
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.predict(batch)
+	}
+}
+
+// predict runs a single Predict for the live requests of batch and scatters the result.
+func (b *Batcher) predict(batch []*batchRequest) {
+	live := batch[:0]
+	numRow := 0
+	for _, req := range batch {
+		if err := req.ctx.Err(); err != nil {
+			req.done <- batchResult{err: err}
+			continue
+		}
+		live = append(live, req)
+		numRow += req.numRow
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	start := time.Now()
+	b.statsMu.Lock()
+	b.stats.BatchSize.observe(float64(numRow))
+	for _, req := range live {
+		b.stats.QueueWait.observe(start.Sub(req.arrival).Seconds())
+	}
+	b.statsMu.Unlock()
+
+	x := live[0].x
+	if len(live) > 1 {
+		buf := getFloat32Buffer(0)
+		defer putFloat32Buffer(buf)
+		for _, req := range live {
+			*buf = append(*buf, req.x...)
+		}
+		x = *buf
+	}
+
+	preds, err := b.predictor.PredictContext(context.Background(), x, numRow, b.config.OutputClassProbability)
+	if err != nil {
+		for _, req := range live {
+			req.done <- batchResult{err: err}
+		}
+		return
+	}
+
+	outputWidth := len(preds) / numRow
+	offset := 0
+	for _, req := range live {
+		n := req.numRow * outputWidth
+		req.done <- batchResult{preds: preds[offset : offset+n : offset+n]}
+		offset += n
+	}
+}
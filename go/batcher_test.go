@@ -0,0 +1,156 @@
+package cuml4go_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+// sumPredictor predicts the sum of the features of every row
+// and records the number of rows of every call.
+type sumPredictor struct {
+	numFeatures int
+	started     chan struct{}
+	block       chan struct{}
+
+	mu      sync.Mutex
+	batches []int
+}
+
+func (p *sumPredictor) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	if p.block != nil {
+		p.started <- struct{}{}
+		<-p.block
+	}
+
+	p.mu.Lock()
+	p.batches = append(p.batches, numRow)
+	p.mu.Unlock()
+
+	preds := make([]float32, numRow)
+	for r := range preds {
+		for _, v := range x[r*p.numFeatures : (r+1)*p.numFeatures] {
+			preds[r] += v
+		}
+	}
+	return preds, nil
+}
+
+func (p *sumPredictor) NumFeatures() int {
+	return p.numFeatures
+}
+
+func TestBatcherConfigValidate(t *testing.T) {
+	_, err := cuml4go.NewBatcher(&sumPredictor{numFeatures: 1}, cuml4go.BatcherConfig{})
+	require.ErrorIs(t, err, cuml4go.ErrInvalidBatcherConfig)
+
+	_, err = cuml4go.NewBatcher(&sumPredictor{numFeatures: 1}, cuml4go.BatcherConfig{
+		MaxBatchSize: 8,
+		MaxWait:      -time.Millisecond,
+	})
+	require.ErrorIs(t, err, cuml4go.ErrInvalidBatcherConfig)
+}
+
+func TestBatcher(t *testing.T) {
+	predictor := &sumPredictor{numFeatures: 2}
+	target, err := cuml4go.NewBatcher(predictor, cuml4go.BatcherConfig{
+		MaxBatchSize: 16,
+		MaxWait:      10 * time.Millisecond,
+		Concurrency:  2,
+		QueueSize:    64,
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	results := make([][]float32, 64)
+	errs := make([]error, 64)
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			results[g], errs[g] = target.Predict(context.Background(), []float32{float32(g), 1}, 1)
+		}(g)
+	}
+	wg.Wait()
+	for g, preds := range results {
+		require.NoError(t, errs[g])
+		require.Equal(t, []float32{float32(g) + 1}, preds)
+	}
+
+	// a request larger than MaxBatchSize is predicted alone
+	large := make([]float32, 2*20)
+	preds, err := target.Predict(context.Background(), large, 20)
+	require.NoError(t, err)
+	require.Len(t, preds, 20)
+
+	_, err = target.Predict(context.Background(), []float32{1}, 1)
+	require.ErrorIs(t, err, cuml4go.ErrDimensionMismatch)
+
+	require.NoError(t, target.Close())
+	require.NoError(t, target.Close())
+
+	_, err = target.Predict(context.Background(), []float32{1, 1}, 1)
+	require.ErrorIs(t, err, cuml4go.ErrBatcherClosed)
+
+	predictor.mu.Lock()
+	batches := predictor.batches
+	predictor.mu.Unlock()
+
+	require.Less(t, len(batches), 64)
+	total := 0
+	for _, n := range batches {
+		total += n
+	}
+	require.Equal(t, 64+20, total)
+
+	stats := target.Stats()
+	require.Equal(t, uint64(len(batches)), stats.BatchSize.Count)
+	require.Equal(t, float64(total), stats.BatchSize.Sum)
+	require.Equal(t, uint64(65), stats.QueueWait.Count)
+	require.Len(t, stats.BatchSize.Counts, len(stats.BatchSize.Bounds)+1)
+}
+
+func TestBatcherCanceled(t *testing.T) {
+	predictor := &sumPredictor{
+		numFeatures: 1,
+		started:     make(chan struct{}, 2),
+		block:       make(chan struct{}),
+	}
+	target, err := cuml4go.NewBatcher(predictor, cuml4go.BatcherConfig{
+		MaxBatchSize: 1,
+		MaxWait:      time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// the first request occupies the worker
+	first := make(chan error, 1)
+	go func() {
+		_, err := target.Predict(context.Background(), []float32{1}, 1)
+		first <- err
+	}()
+	<-predictor.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = target.Predict(ctx, []float32{2}, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(predictor.block)
+	require.NoError(t, <-first)
+	require.NoError(t, target.Close())
+
+	// the canceled request is dropped from its batch
+	predictor.mu.Lock()
+	defer predictor.mu.Unlock()
+	require.Equal(t, []int{1}, predictor.batches)
+}
@@ -0,0 +1,39 @@
+package cuml4go
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrInvalidOutputLength is returned when the output buffer passed to a PredictInto method
+// does not have the length of the prediction result.
+var ErrInvalidOutputLength = errors.New("invalid output length")
+
+// maxPooledBufferLen is the capacity above which scratch buffers are released to the GC
+// instead of being pooled, so that a single large request does not pin memory.
+const maxPooledBufferLen = 1 << 22
+
+// float32Buffers pools the scratch buffers of the convenience methods.
+var float32Buffers sync.Pool
+
+// getFloat32Buffer returns a scratch buffer of length n.
+// its content is undefined. it must be returned by putFloat32Buffer.
+func getFloat32Buffer(n int) *[]float32 {
+	if buf, ok := float32Buffers.Get().(*[]float32); ok {
+		if cap(*buf) >= n {
+			*buf = (*buf)[:n]
+			return buf
+		}
+		putFloat32Buffer(buf)
+	}
+	buf := make([]float32, n)
+	return &buf
+}
+
+// putFloat32Buffer returns a buffer obtained from getFloat32Buffer.
+func putFloat32Buffer(buf *[]float32) {
+	if cap(*buf) > maxPooledBufferLen {
+		return
+	}
+	float32Buffers.Put(buf)
+}
@@ -0,0 +1,60 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+// ErrOutOfMemory is returned when a native call fails to allocate device memory.
+// the call may succeed with a smaller input; see WithMaxRowsPerCall and WithOutOfMemoryRetry.
+var ErrOutOfMemory = rawcuml4go.ErrOutOfMemory
+
+// chunking limits the input of a single native call.
+type chunking struct {
+	maxRows          int
+	maxBytes         int
+	retryOutOfMemory bool
+}
+
+// chunkRows returns the number of rows passed to a native call at once for rows of rowBytes bytes.
+// defaultRows is used unless a smaller limit is configured.
+func (c chunking) chunkRows(rowBytes int, defaultRows int) int {
+	rows := defaultRows
+	if c.maxRows > 0 {
+		rows = min(rows, c.maxRows)
+	}
+	if c.maxBytes > 0 && rowBytes > 0 {
+		rows = min(rows, c.maxBytes/rowBytes)
+	}
+	return max(rows, 1)
+}
+
+// forEachChunk is forEachChunk with the configured limits.
+// a chunk which fails with ErrOutOfMemory is retried with half the rows if retry is enabled,
+// and the following chunks keep the smaller size.
+func (c chunking) forEachChunk(
+	ctx context.Context,
+	numRow int,
+	rowBytes int,
+	defaultRows int,
+	fn func(start int, end int) error,
+) error {
+	chunkRows := c.chunkRows(rowBytes, defaultRows)
+	for start := 0; start < numRow; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+chunkRows, numRow)
+		if err := fn(start, end); err != nil {
+			if c.retryOutOfMemory && errors.Is(err, ErrOutOfMemory) && end-start > 1 {
+				chunkRows = (end - start) / 2
+				continue
+			}
+			return err
+		}
+		start = end
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package cuml4go_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestFILModelMaxRowsPerCall(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	forest := newFakeForest(2)
+	target, err := cuml4go.NewFILModelFromForest(forest, resources, cuml4go.WithMaxRowsPerCall(4))
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := make([]float32, 2*10)
+	for i := range x {
+		x[i] = float32(i)
+	}
+
+	actual, err := target.Predict(x, 10, false)
+	require.NoError(t, err)
+	require.Equal(t, []float32{1, 5, 9, 13, 17, 21, 25, 29, 33, 37}, actual)
+	require.Equal(t, []int{4, 4, 2}, forest.calls)
+}
+
+func TestFILModelPredictInOneCall(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	forest := newFakeForest(1)
+	target, err := cuml4go.NewFILModelFromForest(forest, resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	// Predict is not split without a limit, while PredictContext is split to check the context
+	_, err = target.Predict(make([]float32, 5000), 5000, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{5000}, forest.calls)
+
+	forest.calls = nil
+	_, err = target.PredictContext(context.Background(), make([]float32, 5000), 5000, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{4096, 904}, forest.calls)
+}
+
+func TestFILModelMaxBytesPerCall(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	// a row of 2 features is 8 bytes
+	forest := newFakeForest(2)
+	target, err := cuml4go.NewFILModelFromForest(forest, resources, cuml4go.WithMaxBytesPerCall(20))
+	require.NoError(t, err)
+	defer target.Close()
+
+	_, err = target.Predict(make([]float32, 2*5), 5, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 2, 1}, forest.calls)
+}
+
+func TestFILModelOutOfMemoryRetry(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	forest := newFakeForest(1)
+	forest.oomRows = 3
+	target, err := cuml4go.NewFILModelFromForest(forest, resources)
+	require.NoError(t, err)
+
+	_, err = target.Predict(make([]float32, 10), 10, false)
+	require.ErrorIs(t, err, cuml4go.ErrOutOfMemory)
+	require.NoError(t, target.Close())
+
+	forest = newFakeForest(1)
+	forest.oomRows = 3
+	target, err = cuml4go.NewFILModelFromForest(forest, resources, cuml4go.WithOutOfMemoryRetry())
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	actual, err := target.Predict(x, 10, false)
+	require.NoError(t, err)
+	require.Equal(t, x, actual)
+	// 10 and 5 rows fail, then chunks of 2 rows succeed
+	require.Equal(t, []int{10, 5, 2, 2, 2, 2, 2}, forest.calls)
+}
@@ -0,0 +1,123 @@
+// Command cuml4go-serve serves forest models with the KServe v2 REST protocol,
+// which is also spoken by Triton Inference Server.
+//
+// every subdirectory of the model repository with a config.json is a model named after the directory:
+//
+//	models/
+//	  breast-cancer/
+//	    config.json
+//	    xgboost.json
+//
+// config.json looks like
+//
+//	{
+//	  "model_type": "xgboost_json",
+//	  "model_file": "xgboost.json",
+//	  "classification": true,
+//	  "threshold": 0.5,
+//	  "output_class_probability": true,
+//	  "max_batch_size": 256,
+//	  "max_queue_delay_microseconds": 100,
+//	  "instance_count": 2
+//	}
+//
+// model_type is xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx.
+// max_batch_size enables batching of concurrent requests, and instance_count is the number
+// of batches predicted at once. with -backend auto, models are evaluated on the CPU
+// if no GPU is available, e.g. when the command is built with CGO_ENABLED=0 or the nocuml build tag.
+//
+// a model has a single FP32 input input__0 of shape [N, features]
+// and a single FP32 output output__0 of shape [N], or [N, 2] with output_class_probability.
+//
+// Usage:
+//
+//	cuml4go-serve -model-repository models -addr :8000
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+const version = "0.1.0"
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	repository := flag.String("model-repository", "", "directory of the models")
+	addr := flag.String("addr", ":8000", "address to listen on")
+	backendName := flag.String("backend", "auto", "where models are evaluated: auto, gpu or cpu")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "time to wait for in-flight requests on shutdown")
+	flag.Parse()
+
+	if *repository == "" {
+		return errors.New("-model-repository is required")
+	}
+	backend, err := parseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+
+	models, err := loadRepository(*repository, backend)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeModels(models); err != nil {
+			log.Print(err)
+		}
+	}()
+	for _, name := range sortedNames(models) {
+		log.Printf("loaded model %s", name)
+	}
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(models, version).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", *addr)
+		errc <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// parseBackend returns the backend of name, and logs when auto falls back to the CPU.
+func parseBackend(name string) (cuml4go.Backend, error) {
+	backend, err := cuml4go.ParseBackend(name)
+	if err != nil {
+		return 0, err
+	}
+	if name == "auto" && backend == cuml4go.CPUBackend {
+		log.Print("no GPU is available; models are evaluated on the CPU")
+	}
+	return backend, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"go.uber.org/multierr"
+)
+
+// configFileName is the name of the configuration file in the directory of a model.
+const configFileName = "config.json"
+
+var errInvalidConfig = errors.New("invalid model config")
+
+// modelConfig is the configuration of a model, read from config.json in its directory.
+type modelConfig struct {
+	// ModelType is xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx.
+	ModelType string `json:"model_type"`
+	// ModelFile is the path of the model file relative to the directory.
+	ModelFile string `json:"model_file"`
+	// Classification and Threshold are passed to the model.
+	Classification bool    `json:"classification"`
+	Threshold      float32 `json:"threshold"`
+	// OutputClassProbability makes the model output [1-p, p] for every row.
+	OutputClassProbability bool `json:"output_class_probability"`
+	// MaxBatchSize enables batching of concurrent requests up to MaxBatchSize rows.
+	// 0 disables batching.
+	MaxBatchSize int `json:"max_batch_size"`
+	// MaxQueueDelayMicroseconds is how long the first request of a batch waits for others.
+	MaxQueueDelayMicroseconds int `json:"max_queue_delay_microseconds"`
+	// InstanceCount is the number of predictions running at once. it defaults to 1.
+	InstanceCount int `json:"instance_count"`
+}
+
+func (c *modelConfig) validate() error {
+	if c.ModelFile == "" {
+		return fmt.Errorf("%w: model_file is empty", errInvalidConfig)
+	}
+	if c.MaxBatchSize < 0 || c.MaxQueueDelayMicroseconds < 0 || c.InstanceCount < 0 {
+		return fmt.Errorf("%w: negative batching parameter", errInvalidConfig)
+	}
+	return nil
+}
+
+// model is a loaded model and the batcher in front of it.
+type model struct {
+	name      string
+	config    modelConfig
+	fil       *cuml4go.FILModel
+	resources *cuml4go.Resources
+	batcher   *cuml4go.Batcher
+}
+
+// loadRepository loads every directory of dir which has a config.json, named after the directory.
+func loadRepository(dir string, backend cuml4go.Backend) (map[string]*model, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make(map[string]*model)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), configFileName)); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		m, err := loadModel(filepath.Join(dir, entry.Name()), entry.Name(), backend)
+		if err != nil {
+			return nil, multierr.Append(fmt.Errorf("model %s: %w", entry.Name(), err), closeModels(models))
+		}
+		models[m.name] = m
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no model in %s", dir)
+	}
+	return models, nil
+}
+
+func loadModel(dir string, name string, backend cuml4go.Backend) (*model, error) {
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		return nil, err
+	}
+	var config modelConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidConfig, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	modelType, err := cuml4go.ParseFILModelType(config.ModelType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: model_type: %v", errInvalidConfig, err)
+	}
+
+	m := &model{
+		name:   name,
+		config: config,
+	}
+
+	opts := []cuml4go.Option{cuml4go.WithBackend(backend)}
+	if backend == cuml4go.GPUBackend && config.InstanceCount > 1 {
+		if m.resources, err = cuml4go.NewResources(config.InstanceCount); err != nil {
+			return nil, err
+		}
+		opts = append(opts, cuml4go.WithResources(m.resources))
+	}
+
+	m.fil, err = cuml4go.NewFILModel(
+		modelType,
+		filepath.Join(dir, config.ModelFile),
+		cuml4go.AlgoAuto,
+		config.Classification,
+		config.Threshold,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		opts...,
+	)
+	if err != nil {
+		return nil, multierr.Append(err, m.close())
+	}
+
+	if config.MaxBatchSize > 0 {
+		m.batcher, err = cuml4go.NewBatcher(m.fil, cuml4go.BatcherConfig{
+			MaxBatchSize:           config.MaxBatchSize,
+			MaxWait:                time.Duration(config.MaxQueueDelayMicroseconds) * time.Microsecond,
+			Concurrency:            max(config.InstanceCount, 1),
+			OutputClassProbability: config.OutputClassProbability,
+		})
+		if err != nil {
+			return nil, multierr.Append(err, m.close())
+		}
+	}
+	return m, nil
+}
+
+// outputWidth returns the number of outputs of a row.
+func (m *model) outputWidth() int {
+	if m.config.OutputClassProbability {
+		return 2
+	}
+	return 1
+}
+
+func (m *model) predict(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	if m.batcher != nil {
+		return m.batcher.Predict(ctx, x, numRow)
+	}
+	return m.fil.PredictContext(ctx, x, numRow, m.config.OutputClassProbability)
+}
+
+// close stops the batcher and frees the model and its resources.
+func (m *model) close() error {
+	var err error
+	if m.batcher != nil {
+		err = multierr.Append(err, m.batcher.Close())
+	}
+	if m.fil != nil {
+		err = multierr.Append(err, m.fil.Close())
+	}
+	if m.resources != nil {
+		err = multierr.Append(err, m.resources.Close())
+	}
+	return err
+}
+
+func closeModels(models map[string]*model) error {
+	var err error
+	for _, m := range models {
+		err = multierr.Append(err, m.close())
+	}
+	return err
+}
+
+func sortedNames(models map[string]*model) []string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
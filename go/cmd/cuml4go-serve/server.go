@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+const (
+	serverName = "cuml4go-serve"
+	// inputName and outputName are the tensor names of every model, as in the Triton FIL backend.
+	inputName  = "input__0"
+	outputName = "output__0"
+	platform   = "fil"
+	// maxRequestBytes is the maximum size of an inference request body.
+	maxRequestBytes = 64 << 20
+)
+
+var errBadRequest = errors.New("bad request")
+
+// tensorMetadata describes an input or an output of a model. -1 is a variable dimension.
+type tensorMetadata struct {
+	Name     string `json:"name"`
+	Datatype string `json:"datatype"`
+	Shape    []int  `json:"shape"`
+}
+
+type serverMetadata struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Extensions []string `json:"extensions"`
+}
+
+type modelMetadata struct {
+	Name     string           `json:"name"`
+	Platform string           `json:"platform"`
+	Inputs   []tensorMetadata `json:"inputs"`
+	Outputs  []tensorMetadata `json:"outputs"`
+}
+
+type inferInput struct {
+	Name     string          `json:"name"`
+	Shape    []int           `json:"shape"`
+	Datatype string          `json:"datatype"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type inferRequestOutput struct {
+	Name string `json:"name"`
+}
+
+type inferRequest struct {
+	ID      string               `json:"id,omitempty"`
+	Inputs  []inferInput         `json:"inputs"`
+	Outputs []inferRequestOutput `json:"outputs,omitempty"`
+}
+
+type inferOutput struct {
+	Name     string    `json:"name"`
+	Shape    []int     `json:"shape"`
+	Datatype string    `json:"datatype"`
+	Data     []float32 `json:"data"`
+}
+
+type inferResponse struct {
+	ModelName string        `json:"model_name"`
+	ID        string        `json:"id,omitempty"`
+	Outputs   []inferOutput `json:"outputs"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// server serves the models with the KServe v2 REST protocol.
+type server struct {
+	models  map[string]*model
+	version string
+}
+
+func newServer(models map[string]*model, version string) *server {
+	return &server{
+		models:  models,
+		version: version,
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2", s.serverMetadata)
+	mux.HandleFunc("GET /v2/health/live", s.ok)
+	mux.HandleFunc("GET /v2/health/ready", s.ok)
+	mux.HandleFunc("GET /v2/models/{name}", s.modelMetadata)
+	mux.HandleFunc("GET /v2/models/{name}/ready", s.modelReady)
+	mux.HandleFunc("POST /v2/models/{name}/infer", s.infer)
+	return mux
+}
+
+// ok reports that the server is live and ready; every model is loaded before it starts listening.
+func (s *server) ok(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *server) serverMetadata(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, serverMetadata{
+		Name:       serverName,
+		Version:    s.version,
+		Extensions: []string{},
+	})
+}
+
+func (s *server) model(w http.ResponseWriter, r *http.Request) (*model, bool) {
+	name := r.PathValue("name")
+	m, ok := s.models[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown model %q", name))
+	}
+	return m, ok
+}
+
+func (s *server) modelReady(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.model(w, r); ok {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *server) modelMetadata(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.model(w, r)
+	if !ok {
+		return
+	}
+
+	outputShape := []int{-1}
+	if m.outputWidth() > 1 {
+		outputShape = append(outputShape, m.outputWidth())
+	}
+	writeJSON(w, http.StatusOK, modelMetadata{
+		Name:     m.name,
+		Platform: platform,
+		Inputs:   []tensorMetadata{{Name: inputName, Datatype: "FP32", Shape: []int{-1, m.fil.NumFeatures()}}},
+		Outputs:  []tensorMetadata{{Name: outputName, Datatype: "FP32", Shape: outputShape}},
+	})
+}
+
+func (s *server) infer(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.model(w, r)
+	if !ok {
+		return
+	}
+
+	var req inferRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errBadRequest, err))
+		return
+	}
+	x, numRow, err := parseInput(&req, m.fil.NumFeatures())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, output := range req.Outputs {
+		if output.Name != outputName {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: unknown output %q", errBadRequest, output.Name))
+			return
+		}
+	}
+
+	preds, err := m.predict(r.Context(), x, numRow)
+	if err != nil {
+		writeError(w, predictStatus(err), err)
+		return
+	}
+
+	shape := []int{numRow}
+	if m.outputWidth() > 1 {
+		shape = append(shape, m.outputWidth())
+	}
+	writeJSON(w, http.StatusOK, inferResponse{
+		ModelName: m.name,
+		ID:        req.ID,
+		Outputs: []inferOutput{{
+			Name:     outputName,
+			Shape:    shape,
+			Datatype: "FP32",
+			Data:     preds,
+		}},
+	})
+}
+
+// parseInput returns the rows of the single input of req.
+// the data may be flat or nested in row-major order.
+func parseInput(req *inferRequest, numFeatures int) ([]float32, int, error) {
+	if len(req.Inputs) != 1 {
+		return nil, 0, fmt.Errorf("%w: expected 1 input, got %d", errBadRequest, len(req.Inputs))
+	}
+	input := &req.Inputs[0]
+	if input.Name != inputName {
+		return nil, 0, fmt.Errorf("%w: unknown input %q", errBadRequest, input.Name)
+	}
+	if !numericDatatypes[input.Datatype] {
+		return nil, 0, fmt.Errorf("%w: unsupported datatype %q", errBadRequest, input.Datatype)
+	}
+	if len(input.Shape) != 2 || input.Shape[0] <= 0 || input.Shape[1] != numFeatures {
+		return nil, 0, fmt.Errorf("%w: shape %v, expected [N %d]", errBadRequest, input.Shape, numFeatures)
+	}
+	numRow := input.Shape[0]
+	// every value takes at least 2 bytes of the body, which bounds the shape before allocating it
+	if numRow > maxRequestBytes/2/max(numFeatures, 1) {
+		return nil, 0, fmt.Errorf("%w: shape %v exceeds the request size", errBadRequest, input.Shape)
+	}
+
+	x := make([]float32, 0, numRow*numFeatures)
+	if err := json.Unmarshal(input.Data, &x); err != nil {
+		var nested any
+		if err := json.Unmarshal(input.Data, &nested); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", errBadRequest, err)
+		}
+		x = x[:0]
+		if x, err = flatten(x, nested); err != nil {
+			return nil, 0, err
+		}
+	}
+	if len(x) != numRow*numFeatures {
+		return nil, 0, fmt.Errorf("%w: %d values for shape %v", errBadRequest, len(x), input.Shape)
+	}
+	return x, numRow, nil
+}
+
+var numericDatatypes = map[string]bool{
+	"FP16": true, "FP32": true, "FP64": true,
+	"INT8": true, "INT16": true, "INT32": true, "INT64": true,
+	"UINT8": true, "UINT16": true, "UINT32": true, "UINT64": true,
+}
+
+func flatten(dst []float32, value any) ([]float32, error) {
+	switch value := value.(type) {
+	case float64:
+		return append(dst, float32(value)), nil
+	case []any:
+		var err error
+		for _, v := range value {
+			if dst, err = flatten(dst, v); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	default:
+		return nil, fmt.Errorf("%w: data must be numbers", errBadRequest)
+	}
+}
+
+func predictStatus(err error) int {
+	switch {
+	case errors.Is(err, cuml4go.ErrDimensionMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, cuml4go.ErrBatcherClosed), errors.Is(err, cuml4go.ErrFILModelClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
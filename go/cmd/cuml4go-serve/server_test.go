@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+const (
+	numRow     = 114
+	numFeature = 30
+)
+
+func readCSV(t *testing.T, path string) []float32 {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+
+	var values []float32
+	for _, record := range records {
+		for _, field := range record {
+			v, err := strconv.ParseFloat(field, 32)
+			require.NoError(t, err)
+			values = append(values, float32(v))
+		}
+	}
+	return values
+}
+
+// newRepository writes a model repository with the xgboost test model under name.
+func newRepository(t *testing.T, name string, config modelConfig) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+
+	data, err := os.ReadFile("../../../testdata/xgboost.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name, "xgboost.json"), data, 0o600))
+
+	data, err = json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name, configFileName), data, 0o600))
+	return dir
+}
+
+func newTestServer(t *testing.T, config modelConfig) *httptest.Server {
+	t.Helper()
+	models, err := loadRepository(newRepository(t, "breast-cancer", config), cuml4go.CPUBackend)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, closeModels(models)) })
+
+	ts := httptest.NewServer(newServer(models, version).handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postInfer(t *testing.T, url string, req any) (int, []byte) {
+	t.Helper()
+	status, body, err := infer(url, req)
+	require.NoError(t, err)
+	return status, body
+}
+
+// infer posts req to url without asserting, so that it can be called from other goroutines.
+func infer(url string, req any) (int, []byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, buf.Bytes(), nil
+}
+
+func TestServerInfer(t *testing.T) {
+	ts := newTestServer(t, modelConfig{
+		ModelType:                 "xgboost_json",
+		ModelFile:                 "xgboost.json",
+		Classification:            true,
+		Threshold:                 0.5,
+		OutputClassProbability:    true,
+		MaxBatchSize:              64,
+		MaxQueueDelayMicroseconds: 100,
+	})
+	url := ts.URL + "/v2/models/breast-cancer/infer"
+
+	features := readCSV(t, "../../../testdata/feature.csv")
+	expectedScores := readCSV(t, "../../../testdata/score-xgboost.csv")
+
+	status, body := postInfer(t, url, map[string]any{
+		"id": "42",
+		"inputs": []map[string]any{{
+			"name":     inputName,
+			"shape":    []int{numRow, numFeature},
+			"datatype": "FP32",
+			"data":     features,
+		}},
+		"outputs": []map[string]any{{"name": outputName}},
+	})
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	var resp inferResponse
+	require.NoError(t, json.Unmarshal(body, &resp))
+	require.Equal(t, "breast-cancer", resp.ModelName)
+	require.Equal(t, "42", resp.ID)
+	require.Len(t, resp.Outputs, 1)
+	require.Equal(t, outputName, resp.Outputs[0].Name)
+	require.Equal(t, []int{numRow, 2}, resp.Outputs[0].Shape)
+	for i, score := range expectedScores {
+		require.InDelta(t, score, resp.Outputs[0].Data[2*i+1], 1e-5)
+	}
+
+	// concurrent single-row requests with nested data are batched
+	var wg sync.WaitGroup
+	statuses := make([]int, numRow)
+	bodies := make([][]byte, numRow)
+	errs := make([]error, numRow)
+	for i := 0; i < numRow; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			statuses[i], bodies[i], errs[i] = infer(url, map[string]any{
+				"inputs": []map[string]any{{
+					"name":     inputName,
+					"shape":    []int{1, numFeature},
+					"datatype": "FP64",
+					"data":     [][]float32{features[i*numFeature : (i+1)*numFeature]},
+				}},
+			})
+		}(i)
+	}
+	wg.Wait()
+	for i, body := range bodies {
+		require.NoError(t, errs[i])
+		require.Equal(t, http.StatusOK, statuses[i], string(body))
+
+		var resp inferResponse
+		require.NoError(t, json.Unmarshal(body, &resp))
+		require.InDelta(t, expectedScores[i], resp.Outputs[0].Data[1], 1e-5)
+	}
+}
+
+func TestServerInferInvalid(t *testing.T) {
+	ts := newTestServer(t, modelConfig{
+		ModelType:      "xgboost_json",
+		ModelFile:      "xgboost.json",
+		Classification: true,
+		Threshold:      0.5,
+	})
+	url := ts.URL + "/v2/models/breast-cancer/infer"
+
+	input := func(shape []int, data any) map[string]any {
+		return map[string]any{
+			"inputs": []map[string]any{{
+				"name":     inputName,
+				"shape":    shape,
+				"datatype": "FP32",
+				"data":     data,
+			}},
+		}
+	}
+
+	status, body := postInfer(t, url, input([]int{1, numFeature}, make([]float32, numFeature)))
+	require.Equal(t, http.StatusOK, status, string(body))
+	var resp inferResponse
+	require.NoError(t, json.Unmarshal(body, &resp))
+	require.Equal(t, []int{1}, resp.Outputs[0].Shape)
+
+	for name, req := range map[string]any{
+		"feature count": input([]int{1, 3}, []float32{1, 2, 3}),
+		"data length":   input([]int{2, numFeature}, make([]float32, numFeature)),
+		"huge shape":    input([]int{1e10, numFeature}, make([]float32, numFeature)),
+		"overflow":      input([]int{math.MaxInt / 2, numFeature}, make([]float32, numFeature)),
+		"data type":     input([]int{1, numFeature}, "text"),
+		"output name": map[string]any{
+			"inputs":  input([]int{1, numFeature}, make([]float32, numFeature))["inputs"],
+			"outputs": []map[string]any{{"name": "unknown"}},
+		},
+		"no input": map[string]any{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, body := postInfer(t, url, req)
+			require.Equal(t, http.StatusBadRequest, status, string(body))
+			require.Contains(t, string(body), "error")
+		})
+	}
+
+	status, _ = postInfer(t, ts.URL+"/v2/models/unknown/infer", input([]int{1, numFeature}, make([]float32, numFeature)))
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServerMetadata(t *testing.T) {
+	ts := newTestServer(t, modelConfig{
+		ModelType:              "xgboost_json",
+		ModelFile:              "xgboost.json",
+		OutputClassProbability: true,
+	})
+
+	for path, expected := range map[string]int{
+		"/v2/health/live":                  http.StatusOK,
+		"/v2/health/ready":                 http.StatusOK,
+		"/v2/models/breast-cancer/ready":   http.StatusOK,
+		"/v2/models/unknown/ready":         http.StatusNotFound,
+		"/v2/models/unknown":               http.StatusNotFound,
+		"/v2/models/breast-cancer/unknown": http.StatusNotFound,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, expected, resp.StatusCode, path)
+	}
+
+	resp, err := http.Get(ts.URL + "/v2")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var server serverMetadata
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&server))
+	require.Equal(t, serverName, server.Name)
+
+	resp, err = http.Get(ts.URL + "/v2/models/breast-cancer")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var metadata modelMetadata
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+	require.Equal(t, modelMetadata{
+		Name:     "breast-cancer",
+		Platform: platform,
+		Inputs:   []tensorMetadata{{Name: inputName, Datatype: "FP32", Shape: []int{-1, numFeature}}},
+		Outputs:  []tensorMetadata{{Name: outputName, Datatype: "FP32", Shape: []int{-1, 2}}},
+	}, metadata)
+}
+
+func TestLoadRepositoryInvalid(t *testing.T) {
+	_, err := loadRepository(newRepository(t, "model", modelConfig{ModelType: "catboost", ModelFile: "xgboost.json"}), cuml4go.CPUBackend)
+	require.ErrorIs(t, err, errInvalidConfig)
+
+	_, err = loadRepository(newRepository(t, "model", modelConfig{ModelType: "lightgbm", ModelFile: "xgboost.json"}), cuml4go.CPUBackend)
+	require.ErrorIs(t, err, cuml4go.ErrFILModelLoad)
+
+	_, err = loadRepository(t.TempDir(), cuml4go.CPUBackend)
+	require.Error(t, err)
+}
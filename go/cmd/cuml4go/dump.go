@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// runDump prints the trees of a forest model as text or as a Graphviz digraph. it runs on the CPU.
+func runDump(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("dump", stderr)
+	modelPath := flags.String("model", "", "model file")
+	modelTypeName := flags.String("model-type", "xgboost_json", "model format: xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx")
+	format := flags.String("format", "text", "output format: text, like xgboost's dump_model, or dot")
+	tree := flags.Int("tree", 0, "index of the tree of the dot format")
+	stats := flags.Bool("stats", false, "print the gain and cover of the nodes in the text format")
+	output := flags.String("output", "", "output file; the dump is printed if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("model", *modelPath); err != nil {
+		return err
+	}
+	modelType, err := cuml4go.ParseFILModelType(*modelTypeName)
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "dot" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	var f *forest.Forest
+	err = timed(stderr, "load "+*modelPath, func() error {
+		var err error
+		f, err = cuml4go.LoadForest(modelType, *modelPath)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		if *format == "dot" {
+			return f.WriteDOT(w, *tree, nil)
+		}
+		return f.WriteText(w, nil, *stats)
+	}
+	if *output == "" {
+		return write(stdout)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+// linearModel is the file format of a fitted linear or ridge model.
+type linearModel struct {
+	// Model is linear or ridge.
+	Model     string    `json:"model"`
+	Coef      []float32 `json:"coef"`
+	Intercept float32   `json:"intercept"`
+}
+
+func runFit(algorithm string, args []string, stdout io.Writer, stderr io.Writer) error {
+	switch algorithm {
+	case "kmeans":
+		return fitKmeans(args, stdout, stderr)
+	case "dbscan":
+		return fitDBScan(args, stdout, stderr)
+	case "agglomerative":
+		return fitAgglomerative(args, stdout, stderr)
+	case "linear", "ridge":
+		return fitLinear(algorithm, args, stdout, stderr)
+	default:
+		return errUsage
+	}
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// requireFlags returns an error naming the first empty flag of name and value pairs.
+func requireFlags(pairs ...string) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			return fmt.Errorf("-%s is required", pairs[i])
+		}
+	}
+	return nil
+}
+
+func fitKmeans(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("fit kmeans", stderr)
+	input := flags.String("input", "", "features")
+	k := flags.Int("k", 8, "number of clusters")
+	maxIter := flags.Int("max-iter", 300, "maximum number of iterations")
+	tol := flags.Float64("tol", 1e-4, "relative tolerance of convergence")
+	seed := flags.Int("seed", 0, "random seed")
+	metricName := flags.String("metric", "sqeuclid", "distance: l2, sqeuclid, l1, cosine or chebyshev")
+	labelsPath := flags.String("labels", "", "output file of the labels")
+	centroidsPath := flags.String("centroids", "", "output file of the centroids")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("input", *input); err != nil {
+		return err
+	}
+	metric, err := parseMetric(*metricName)
+	if err != nil {
+		return err
+	}
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+
+	model, err := cuml4go.NewKmeans(*k, *maxIter, *tol, cuml4go.KMeansPlusPlus, metric, *seed, cuml4go.Off)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	var (
+		labels    []int32
+		centroids []float32
+		inertia   float32
+		nIter     int32
+	)
+	err = timed(stderr, "fit kmeans", func() error {
+		var err error
+		labels, centroids, inertia, nIter, err = model.Fit(x.data, x.numRow, x.numCol, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "inertia: %g\niterations: %d\n", inertia, nIter)
+
+	if *labelsPath != "" {
+		if err := writeLabels(*labelsPath, labels); err != nil {
+			return err
+		}
+	}
+	if *centroidsPath != "" {
+		if err := writeMatrix(*centroidsPath, centroids, *k, x.numCol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fitDBScan(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("fit dbscan", stderr)
+	input := flags.String("input", "", "features")
+	minPts := flags.Int("min-pts", 5, "minimum number of neighbors of a core point")
+	eps := flags.Float64("eps", 0.5, "maximum distance of neighbors")
+	metricName := flags.String("metric", "l2", "distance: l2, sqeuclid, l1, cosine or chebyshev")
+	maxBytesPerBatch := flags.Int("max-bytes-per-batch", 0, "memory limit of a batch of the distance matrix; 0 is automatic")
+	labelsPath := flags.String("labels", "", "output file of the labels; noise is -1")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("input", *input); err != nil {
+		return err
+	}
+	metric, err := parseMetric(*metricName)
+	if err != nil {
+		return err
+	}
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+
+	model, err := cuml4go.NewDBScan(*minPts, *eps, metric, *maxBytesPerBatch, cuml4go.Off)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	var labels []int32
+	err = timed(stderr, "fit dbscan", func() error {
+		var err error
+		labels, err = model.Fit(x.data, x.numRow, x.numCol)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	clusters := make(map[int32]bool)
+	noise := 0
+	for _, l := range labels {
+		if l < 0 {
+			noise++
+		} else {
+			clusters[l] = true
+		}
+	}
+	fmt.Fprintf(stdout, "clusters: %d\nnoise: %d\n", len(clusters), noise)
+
+	if *labelsPath != "" {
+		return writeLabels(*labelsPath, labels)
+	}
+	return nil
+}
+
+func fitAgglomerative(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("fit agglomerative", stderr)
+	input := flags.String("input", "", "features")
+	numCluster := flags.Int("n-clusters", 2, "number of clusters")
+	numNeighbor := flags.Int("n-neighbors", 15, "number of neighbors of the knn graph")
+	pairwise := flags.Bool("pairwise", true, "connect with the pairwise distances instead of a knn graph")
+	metricName := flags.String("metric", "l2", "distance: l2, sqeuclid, l1, cosine or chebyshev")
+	labelsPath := flags.String("labels", "", "output file of the labels")
+	childrenPath := flags.String("children", "", "output file of the children of the dendrogram nodes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("input", *input); err != nil {
+		return err
+	}
+	metric, err := parseMetric(*metricName)
+	if err != nil {
+		return err
+	}
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+
+	model, err := cuml4go.NewAgglomerativeClustering(*pairwise, metric, *numCluster, *numNeighbor)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	var (
+		labels   []int32
+		children []int32
+		clusters int32
+	)
+	err = timed(stderr, "fit agglomerative", func() error {
+		var err error
+		labels, children, clusters, err = model.Fit(x.data, x.numRow, x.numCol)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "clusters: %d\n", clusters)
+
+	if *labelsPath != "" {
+		if err := writeLabels(*labelsPath, labels); err != nil {
+			return err
+		}
+	}
+	if *childrenPath != "" {
+		return writeLabels(*childrenPath, children)
+	}
+	return nil
+}
+
+func fitLinear(algorithm string, args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("fit "+algorithm, stderr)
+	input := flags.String("input", "", "features")
+	targetPath := flags.String("target", "", "target values, a single column")
+	fitIntercept := flags.Bool("fit-intercept", true, "fit the intercept")
+	normalize := flags.Bool("normalize", false, "normalize the features")
+	solverName := flags.String("solver", "eig", "solver: svd, eig or qr")
+	alpha := flags.Float64("alpha", 1, "regularization strength of ridge")
+	modelPath := flags.String("model", "", "output JSON file of the model")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("input", *input, "target", *targetPath); err != nil {
+		return err
+	}
+	solver, err := parseSolver(*solverName)
+	if err != nil {
+		return err
+	}
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+	y, err := readInput(stderr, *targetPath)
+	if err != nil {
+		return err
+	}
+	if y.numCol != 1 || y.numRow != x.numRow {
+		return errors.New("target must be a single column with a value for every row of the input")
+	}
+
+	result := linearModel{Model: algorithm}
+	switch algorithm {
+	case "linear":
+		model, err := cuml4go.NewLinearRegression(*fitIntercept, *normalize, solver)
+		if err != nil {
+			return err
+		}
+		defer model.Close()
+		if err := timed(stderr, "fit linear", func() error {
+			return model.Fit(x.data, x.numRow, x.numCol, y.data)
+		}); err != nil {
+			return err
+		}
+		result.Coef, result.Intercept = model.GetParams(), model.GetIntercept()
+	default:
+		model, err := cuml4go.NewRidgeRegression(float32(*alpha), *fitIntercept, *normalize, solver)
+		if err != nil {
+			return err
+		}
+		defer model.Close()
+		if err := timed(stderr, "fit ridge", func() error {
+			return model.Fit(x.data, x.numRow, x.numCol, y.data)
+		}); err != nil {
+			return err
+		}
+		result.Coef, result.Intercept = model.GetParams(), model.GetIntercept()
+	}
+	fmt.Fprintf(stdout, "coef: %v\nintercept: %g\n", result.Coef, result.Intercept)
+
+	if *modelPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*modelPath, append(data, '\n'), 0o644)
+}
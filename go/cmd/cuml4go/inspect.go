@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+var postTransformNames = map[forest.PostTransform]string{
+	forest.Identity:    "identity",
+	forest.Sigmoid:     "sigmoid",
+	forest.Softmax:     "softmax",
+	forest.Exponential: "exponential",
+	forest.MaxIndex:    "max_index",
+	forest.Hinge:       "hinge",
+}
+
+var importanceTypes = map[string]forest.ImportanceType{
+	"weight":      forest.ImportanceWeight,
+	"gain":        forest.ImportanceGain,
+	"cover":       forest.ImportanceCover,
+	"total_gain":  forest.ImportanceTotalGain,
+	"total_cover": forest.ImportanceTotalCover,
+}
+
+// runInspect prints a summary of a forest model. it runs on the CPU.
+func runInspect(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("inspect", stderr)
+	modelPath := flags.String("model", "", "model file")
+	modelTypeName := flags.String("model-type", "xgboost_json", "model format: xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx")
+	importanceName := flags.String("importance", "", "print the feature importance: weight, gain, cover, total_gain or total_cover")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("model", *modelPath); err != nil {
+		return err
+	}
+	modelType, err := cuml4go.ParseFILModelType(*modelTypeName)
+	if err != nil {
+		return err
+	}
+	importanceType, ok := importanceTypes[*importanceName]
+	if *importanceName != "" && !ok {
+		return fmt.Errorf("unknown importance type %q", *importanceName)
+	}
+
+	var f *forest.Forest
+	err = timed(stderr, "load "+*modelPath, func() error {
+		var err error
+		f, err = cuml4go.LoadForest(modelType, *modelPath)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	summary := f.Summary()
+	fmt.Fprintf(stdout, "format: %s\n", *modelTypeName)
+	fmt.Fprintf(stdout, "objective: %s\n", f.Objective)
+	fmt.Fprintf(stdout, "post transform: %s\n", postTransformNames[f.PostTransform])
+	fmt.Fprintf(stdout, "features: %d\n", f.NumFeature)
+	fmt.Fprintf(stdout, "outputs: %d\n", f.NumGroup)
+	fmt.Fprintf(stdout, "base score: %v\n", f.BaseScore)
+	fmt.Fprintf(stdout, "trees: %d\n", summary.NumTrees)
+	fmt.Fprintf(stdout, "nodes: %d\n", summary.NumNodes)
+	fmt.Fprintf(stdout, "leaves: %d\n", summary.NumLeaves)
+	fmt.Fprintf(stdout, "max depth: %d\n", summary.MaxDepth)
+	fmt.Fprintf(stdout, "used features: %d\n", summary.NumUsedFeatures)
+	if len(f.FeatureNames) > 0 {
+		fmt.Fprintf(stdout, "feature names: %v\n", f.FeatureNames)
+	}
+	if *importanceName == "" {
+		return nil
+	}
+
+	importance, err := f.FeatureImportance(importanceType)
+	if err != nil {
+		return err
+	}
+	features := f.UsedFeatures()
+	sort.SliceStable(features, func(i, j int) bool {
+		return importance[features[i]] > importance[features[j]]
+	})
+	fmt.Fprintf(stdout, "importance (%s):\n", *importanceName)
+	for _, i := range features {
+		name := strconv.Itoa(i)
+		if i < len(f.FeatureNames) {
+			name = f.FeatureNames[i]
+		}
+		fmt.Fprintf(stdout, "  %s: %g\n", name, importance[i])
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Command cuml4go fits, predicts with and inspects models from the command line.
+//
+// Usage:
+//
+//	cuml4go fit kmeans|dbscan|agglomerative|linear|ridge [flags]
+//	cuml4go predict fil|linear [flags]
+//	cuml4go inspect [flags]
+//	cuml4go dump [flags]
+//
+// inputs are CSV files without a header, or .npy files of a 1-d or 2-d float or integer array.
+// outputs are written as .npy if their name ends with .npy, and as CSV otherwise.
+// the time of every step is printed to the standard error.
+//
+// fitting runs on the GPU. inspect and dump run on the CPU. predict runs on the CPU with -backend cpu,
+// or with -backend auto (the default) if no GPU is available.
+// built with CGO_ENABLED=0 or the nocuml build tag, the command does not need the native libraries,
+// and every command but fit runs on the CPU.
+//
+// Examples:
+//
+//	cuml4go fit kmeans -input testdata/feature.csv -k 3 -labels labels.csv -centroids centroids.npy
+//	cuml4go fit linear -input x.npy -target y.npy -model linear.json
+//	cuml4go predict fil -model testdata/xgboost.json -model-type xgboost_json -input testdata/feature.csv -output scores.csv -probability
+//	cuml4go inspect -model testdata/xgboost.json -model-type xgboost_json
+//	cuml4go dump -model testdata/xgboost.json -format dot -tree 0 | dot -Tsvg > tree0.svg
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+var errUsage = errors.New(`usage:
+  cuml4go fit kmeans|dbscan|agglomerative|linear|ridge [flags]
+  cuml4go predict fil|linear [flags]
+  cuml4go inspect [flags]
+  cuml4go dump [flags]
+run a command with -h for its flags`)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command of args. results go to stdout, and timings and flag errors to stderr.
+func run(args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "fit":
+		if len(args) < 2 {
+			return errUsage
+		}
+		return runFit(args[1], args[2:], stdout, stderr)
+	case "predict":
+		if len(args) < 2 {
+			return errUsage
+		}
+		return runPredict(args[1], args[2:], stdout, stderr)
+	case "inspect":
+		return runInspect(args[1:], stdout, stderr)
+	case "dump":
+		return runDump(args[1:], stdout, stderr)
+	default:
+		return errUsage
+	}
+}
+
+// timed runs fn and prints its duration to stderr.
+func timed(stderr io.Writer, step string, fn func() error) error {
+	start := time.Now()
+	if err := fn(); err != nil {
+		return fmt.Errorf("%s: %w", step, err)
+	}
+	fmt.Fprintf(stderr, "%s: %v\n", step, time.Since(start))
+	return nil
+}
+
+// readInput reads a matrix and prints its size and the time it took.
+func readInput(stderr io.Writer, path string) (*matrix, error) {
+	var m *matrix
+	err := timed(stderr, "read "+path, func() error {
+		var err error
+		m, err = readMatrix(path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(stderr, "%s: %d rows, %d columns\n", path, m.numRow, m.numCol)
+	return m, nil
+}
+
+var metrics = map[string]cuml4go.Metric{
+	"l2":        cuml4go.L2SqrtExpanded,
+	"sqeuclid":  cuml4go.L2Expanded,
+	"l1":        cuml4go.L1,
+	"cosine":    cuml4go.CosineExpanded,
+	"chebyshev": cuml4go.Linf,
+}
+
+func parseMetric(name string) (cuml4go.Metric, error) {
+	metric, ok := metrics[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown metric %q: expected l2, sqeuclid, l1, cosine or chebyshev", name)
+	}
+	return metric, nil
+}
+
+var solvers = map[string]cuml4go.GlmSolverAlgo{
+	"svd": cuml4go.Svd,
+	"eig": cuml4go.Eig,
+	"qr":  cuml4go.Qr,
+}
+
+func parseSolver(name string) (cuml4go.GlmSolverAlgo, error) {
+	solver, ok := solvers[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown solver %q: expected svd, eig or qr", name)
+	}
+	return solver, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/onnx"
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+func TestMatrixRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	values := []float32{1, 2.5, -3, 4, 5, 6}
+
+	for _, name := range []string{"m.csv", "m.npy"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, writeMatrix(path, values, 2, 3))
+
+		m, err := readMatrix(path)
+		require.NoError(t, err)
+		require.Equal(t, &matrix{data: values, numRow: 2, numCol: 3}, m)
+	}
+
+	labels := []int32{0, -1, 2}
+	for _, name := range []string{"l.csv", "l.npy"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, writeLabels(path, labels))
+
+		m, err := readMatrix(path)
+		require.NoError(t, err)
+		require.Equal(t, &matrix{data: []float32{0, -1, 2}, numRow: 3, numCol: 1}, m)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "m.npy"))
+	require.NoError(t, err)
+	// the data is aligned to 64 bytes
+	require.Zero(t, (len(data)-4*len(values))%64)
+}
+
+func TestReadNPYFortranOrder(t *testing.T) {
+	var buf bytes.Buffer
+	header := "{'descr': '<i8', 'fortran_order': True, 'shape': (2, 3), }\n"
+	buf.WriteString("\x93NUMPY\x01\x00")
+	buf.Write([]byte{byte(len(header)), 0})
+	buf.WriteString(header)
+	for _, v := range []int64{1, 4, 2, 5, 3, 6} {
+		for i := 0; i < 8; i++ {
+			buf.WriteByte(byte(v >> (8 * i)))
+		}
+	}
+
+	m, err := readNPY(&buf)
+	require.NoError(t, err)
+	require.Equal(t, &matrix{data: []float32{1, 2, 3, 4, 5, 6}, numRow: 2, numCol: 3}, m)
+
+	_, err = readNPY(strings.NewReader("not npy"))
+	require.ErrorIs(t, err, errInvalidNPY)
+
+	// a huge or overflowing shape fails without allocating it
+	for _, shape := range []string{"(1000000000, 1000000000)", "(9223372036854775807, 2)"} {
+		var buf bytes.Buffer
+		header := "{'descr': '<f4', 'fortran_order': False, 'shape': " + shape + ", }\n"
+		buf.WriteString("\x93NUMPY\x01\x00")
+		buf.Write([]byte{byte(len(header)), 0})
+		buf.WriteString(header)
+		buf.Write(make([]byte, 16))
+		_, err = readNPY(&buf)
+		require.ErrorIs(t, err, errInvalidNPY, shape)
+	}
+}
+
+func TestPredictFILOnCPU(t *testing.T) {
+	dir := t.TempDir()
+	f, err := cuml4go.LoadForest(cuml4go.XGBoostJSON, "../../../testdata/xgboost.json")
+	require.NoError(t, err)
+	m, err := onnx.Forest(f)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "xgboost.onnx"), m.Marshal(), 0o644))
+
+	for _, tc := range []struct{ model, modelType string }{
+		{model: "../../../testdata/xgboost.json", modelType: "xgboost_json"},
+		{model: filepath.Join(dir, "xgboost.onnx"), modelType: "onnx"},
+	} {
+		t.Run(tc.modelType, func(t *testing.T) {
+			output := filepath.Join(dir, tc.modelType+".npy")
+			var stdout, stderr bytes.Buffer
+			err := run([]string{
+				"predict", "fil",
+				"-model", tc.model,
+				"-model-type", tc.modelType,
+				"-input", "../../../testdata/feature.csv",
+				"-output", output,
+				"-probability",
+				"-backend", "cpu",
+			}, &stdout, &stderr)
+			require.NoError(t, err, stderr.String())
+			require.Contains(t, stderr.String(), "predict: ")
+
+			scores, err := readMatrix(output)
+			require.NoError(t, err)
+			require.Equal(t, 2, scores.numCol)
+			expected, err := readMatrix("../../../testdata/score-xgboost.csv")
+			require.NoError(t, err)
+			require.Equal(t, expected.numRow, scores.numRow)
+			for i, score := range expected.data {
+				require.InDelta(t, score, scores.data[2*i+1], 1e-5)
+			}
+		})
+	}
+}
+
+func TestPredictFILMargin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{
+		"predict", "fil",
+		"-model", "../../../testdata/xgboost.json",
+		"-input", "../../../testdata/feature.csv",
+		"-margin",
+		"-backend", "cpu",
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+
+	expected, err := readMatrix("../../../testdata/score-xgboost.csv")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, expected.numRow)
+	for i, line := range lines {
+		margin, err := strconv.ParseFloat(line, 64)
+		require.NoError(t, err)
+		require.InDelta(t, expected.data[i], 1/(1+math.Exp(-margin)), 1e-5)
+	}
+}
+
+func TestPredictLinearOnCPU(t *testing.T) {
+	dir := t.TempDir()
+	model, err := json.Marshal(linearModel{Model: "linear", Coef: []float32{1, 2}, Intercept: 0.5})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.json"), model, 0o600))
+	require.NoError(t, writeMatrix(filepath.Join(dir, "x.csv"), []float32{1, 1, 2, 0}, 2, 2))
+
+	var stdout, stderr bytes.Buffer
+	err = run([]string{
+		"predict", "linear",
+		"-model", filepath.Join(dir, "model.json"),
+		"-input", filepath.Join(dir, "x.csv"),
+		"-backend", "cpu",
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	require.Equal(t, "3.5\n2.5\n", stdout.String())
+}
+
+func TestInspect(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{
+		"inspect",
+		"-model", "../../../testdata/xgboost.json",
+		"-model-type", "xgboost_json",
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	require.Contains(t, stdout.String(), "objective: binary:logistic\n")
+	require.Contains(t, stdout.String(), "post transform: sigmoid\n")
+	require.Contains(t, stdout.String(), "features: 30\n")
+	require.Contains(t, stdout.String(), "trees: 100\n")
+
+	stdout.Reset()
+	err = run([]string{
+		"inspect",
+		"-model", "../../../testdata/xgboost.json",
+		"-importance", "weight",
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	_, importance, ok := strings.Cut(stdout.String(), "importance (weight):\n")
+	require.True(t, ok)
+	require.Regexp(t, `^  [a-z ]+: [0-9]+\n`, importance)
+
+	require.Error(t, run([]string{"inspect", "-model", "m", "-importance", "size"}, &stdout, &stderr))
+}
+
+func TestDump(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"dump", "-model", "../../../testdata/xgboost.json", "-stats"}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	require.True(t, strings.HasPrefix(stdout.String(), "booster[0]:\n0:[worst concave points<0.1424] yes=1,no=2,missing=2,gain="))
+	require.Contains(t, stdout.String(), "booster[99]:\n")
+	require.Contains(t, stdout.String(), ",gain=")
+
+	output := filepath.Join(t.TempDir(), "tree.dot")
+	err = run([]string{"dump", "-model", "../../../testdata/xgboost.json", "-format", "dot", "-tree", "3", "-output", output}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "digraph tree3 {\n"))
+
+	require.Error(t, run([]string{"dump", "-model", "../../../testdata/xgboost.json", "-format", "svg"}, &stdout, &stderr))
+}
+
+func TestFitKmeans(t *testing.T) {
+	if !rawcuml4go.HasNativeLibrary {
+		t.Skip("built without the native libraries")
+	}
+	labels := filepath.Join(t.TempDir(), "labels.csv")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{
+		"fit", "kmeans",
+		"-input", "../../../testdata/feature.csv",
+		"-k", "3",
+		"-max-iter", "10",
+		"-seed", "42",
+		"-labels", labels,
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+	require.Contains(t, stdout.String(), "inertia: ")
+
+	m, err := readMatrix(labels)
+	require.NoError(t, err)
+	require.Equal(t, 114, m.numRow)
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{nil, {"fit"}, {"fit", "svm"}, {"predict", "svm"}, {"train"}} {
+		require.ErrorIs(t, run(args, &stdout, &stderr), errUsage)
+	}
+	require.EqualError(t, run([]string{"inspect"}, &stdout, &stderr), "-model is required")
+	require.Error(t, run([]string{"predict", "fil", "-model", "m", "-input", "x", "-model-type", "pmml"}, &stdout, &stderr))
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var errInvalidNPY = errors.New("invalid npy file")
+
+// matrix is a dense row-major matrix.
+type matrix struct {
+	data   []float32
+	numRow int
+	numCol int
+}
+
+// readMatrix reads a .npy file, or a CSV file without a header.
+// a 1-d array is a single column.
+func readMatrix(path string) (*matrix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".npy") {
+		return readNPY(bufio.NewReader(f))
+	}
+	return readCSV(f)
+}
+
+func readCSV(r io.Reader) (*matrix, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	m := &matrix{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if m.numRow == 0 {
+			m.numCol = len(record)
+		}
+		for _, field := range record {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", m.numRow+1, err)
+			}
+			m.data = append(m.data, float32(v))
+		}
+		m.numRow++
+	}
+	return m, nil
+}
+
+var (
+	npyMagic       = []byte("\x93NUMPY")
+	npyDescr       = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran     = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape       = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+	npyElementSize = map[string]int{"<f4": 4, "<f8": 8, "<i4": 4, "<i8": 8}
+)
+
+// readFull reads n bytes of r. unlike io.ReadFull, it allocates as much as r holds,
+// so that a header claiming a huge length fails with io.ErrUnexpectedEOF instead of allocating it.
+func readFull(r io.Reader, n int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// readNPY reads a 1-d or 2-d little-endian float or integer array.
+func readNPY(r io.Reader) (*matrix, error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil || !bytes.HasPrefix(prefix, npyMagic) {
+		return nil, errInvalidNPY
+	}
+	var headerLen int
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, errInvalidNPY
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, errInvalidNPY
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("%w: version %d", errInvalidNPY, major)
+	}
+	header, err := readFull(r, headerLen)
+	if err != nil {
+		return nil, errInvalidNPY
+	}
+
+	descr := npyDescr.FindSubmatch(header)
+	fortran := npyFortran.FindSubmatch(header)
+	shape := npyShape.FindSubmatch(header)
+	if descr == nil || fortran == nil || shape == nil {
+		return nil, fmt.Errorf("%w: header %q", errInvalidNPY, header)
+	}
+	elementSize, ok := npyElementSize[string(descr[1])]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported dtype %s", errInvalidNPY, descr[1])
+	}
+
+	var dims []int
+	for _, field := range strings.Split(string(shape[1]), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		dim, err := strconv.Atoi(field)
+		if err != nil || dim < 0 {
+			return nil, fmt.Errorf("%w: shape %s", errInvalidNPY, shape[1])
+		}
+		dims = append(dims, dim)
+	}
+	m := &matrix{}
+	switch len(dims) {
+	case 1:
+		m.numRow, m.numCol = dims[0], 1
+	case 2:
+		m.numRow, m.numCol = dims[0], dims[1]
+	default:
+		return nil, fmt.Errorf("%w: %d dimensions", errInvalidNPY, len(dims))
+	}
+
+	if m.numCol != 0 && m.numRow > math.MaxInt/elementSize/m.numCol {
+		return nil, fmt.Errorf("%w: shape %s overflows", errInvalidNPY, shape[1])
+	}
+	raw, err := readFull(r, m.numRow*m.numCol*elementSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidNPY, err)
+	}
+	values := make([]float32, m.numRow*m.numCol)
+	for i := range values {
+		b := raw[i*elementSize : (i+1)*elementSize]
+		switch string(descr[1]) {
+		case "<f4":
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case "<f8":
+			values[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case "<i4":
+			values[i] = float32(int32(binary.LittleEndian.Uint32(b)))
+		case "<i8":
+			values[i] = float32(int64(binary.LittleEndian.Uint64(b)))
+		}
+	}
+
+	if string(fortran[1]) == "True" {
+		m.data = make([]float32, len(values))
+		for c := 0; c < m.numCol; c++ {
+			for r := 0; r < m.numRow; r++ {
+				m.data[r*m.numCol+c] = values[c*m.numRow+r]
+			}
+		}
+	} else {
+		m.data = values
+	}
+	return m, nil
+}
+
+// writeMatrix writes a matrix of floats as .npy or CSV, depending on the extension of path.
+func writeMatrix(path string, values []float32, numRow int, numCol int) error {
+	return writeFile(path, func(w io.Writer, npy bool) error {
+		if npy {
+			data := make([]byte, 4*len(values))
+			for i, v := range values {
+				binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+			}
+			return writeNPY(w, "<f4", numRow, numCol, data)
+		}
+		return writeCSV(w, numCol, len(values), func(i int) string {
+			return strconv.FormatFloat(float64(values[i]), 'g', -1, 32)
+		})
+	})
+}
+
+// writeLabels writes a column of integers as .npy or CSV, depending on the extension of path.
+func writeLabels(path string, labels []int32) error {
+	return writeFile(path, func(w io.Writer, npy bool) error {
+		if npy {
+			data := make([]byte, 4*len(labels))
+			for i, l := range labels {
+				binary.LittleEndian.PutUint32(data[4*i:], uint32(l))
+			}
+			return writeNPY(w, "<i4", len(labels), 1, data)
+		}
+		return writeCSV(w, 1, len(labels), func(i int) string {
+			return strconv.Itoa(int(labels[i]))
+		})
+	})
+}
+
+func writeFile(path string, write func(w io.Writer, npy bool) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w, strings.EqualFold(filepath.Ext(path), ".npy")); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCSV(w io.Writer, numCol int, n int, format func(i int) string) error {
+	for i := 0; i < n; i++ {
+		sep := ","
+		if (i+1)%numCol == 0 {
+			sep = "\n"
+		}
+		if _, err := io.WriteString(w, format(i)+sep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNPY writes a version 1.0 .npy file. a single column is written as a 1-d array.
+func writeNPY(w io.Writer, descr string, numRow int, numCol int, data []byte) error {
+	shape := fmt.Sprintf("(%d, %d)", numRow, numCol)
+	if numCol == 1 {
+		shape = fmt.Sprintf("(%d,)", numRow)
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shape)
+	// the data starts at a multiple of 64 bytes, and the header ends with a newline
+	total := len(npyMagic) + 4 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	var prefix bytes.Buffer
+	prefix.Write(npyMagic)
+	prefix.Write([]byte{1, 0})
+	_ = binary.Write(&prefix, binary.LittleEndian, uint16(len(header)))
+	prefix.WriteString(header)
+	if _, err := w.Write(prefix.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func runPredict(model string, args []string, stdout io.Writer, stderr io.Writer) error {
+	switch model {
+	case "fil":
+		return predictFIL(args, stdout, stderr)
+	case "linear":
+		return predictLinear(args, stdout, stderr)
+	default:
+		return errUsage
+	}
+}
+
+func predictFIL(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("predict fil", stderr)
+	modelPath := flags.String("model", "", "model file")
+	modelTypeName := flags.String("model-type", "xgboost_json", "model format: xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx")
+	input := flags.String("input", "", "features")
+	output := flags.String("output", "", "output file of the predictions; they are printed if empty")
+	probability := flags.Bool("probability", false, "output the probabilities [1-p, p] of the classes")
+	margin := flags.Bool("margin", false, "output the raw margins before the sigmoid or softmax")
+	classification := flags.Bool("classification", true, "output the class of a binary classifier")
+	threshold := flags.Float64("threshold", 0.5, "threshold of the class")
+	backendName := flags.String("backend", "auto", "where the model is evaluated: auto, gpu or cpu")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("model", *modelPath, "input", *input); err != nil {
+		return err
+	}
+	modelType, err := cuml4go.ParseFILModelType(*modelTypeName)
+	if err != nil {
+		return err
+	}
+	backend, err := cuml4go.ParseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+
+	var model *cuml4go.FILModel
+	err = timed(stderr, "load "+*modelPath, func() error {
+		var err error
+		model, err = cuml4go.NewFILModel(
+			modelType,
+			*modelPath,
+			cuml4go.AlgoAuto,
+			*classification,
+			float32(*threshold),
+			cuml4go.Auto,
+			0,
+			1,
+			0,
+			cuml4go.WithBackend(backend),
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+	if x.numCol != model.NumFeatures() {
+		return fmt.Errorf("input has %d columns, but the model has %d features", x.numCol, model.NumFeatures())
+	}
+
+	var preds []float32
+	err = timed(stderr, "predict", func() error {
+		var err error
+		if *margin {
+			preds, err = model.PredictOutputContext(context.Background(), x.data, x.numRow, cuml4go.OutputMargin)
+		} else {
+			preds, err = model.PredictContext(context.Background(), x.data, x.numRow, *probability)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	numCol := 1
+	switch {
+	case *margin:
+		numCol = len(model.BaseScore())
+	case *probability:
+		numCol = 2
+	}
+	return writePredictions(stdout, *output, preds, x.numRow, numCol)
+}
+
+func predictLinear(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := newFlagSet("predict linear", stderr)
+	modelPath := flags.String("model", "", "JSON model file written by fit linear or fit ridge")
+	input := flags.String("input", "", "features")
+	output := flags.String("output", "", "output file of the predictions; they are printed if empty")
+	backendName := flags.String("backend", "auto", "where the model is evaluated: auto, gpu or cpu")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireFlags("model", *modelPath, "input", *input); err != nil {
+		return err
+	}
+	backend, err := cuml4go.ParseBackend(*backendName)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*modelPath)
+	if err != nil {
+		return err
+	}
+	var model linearModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return fmt.Errorf("%s: %w", *modelPath, err)
+	}
+
+	x, err := readInput(stderr, *input)
+	if err != nil {
+		return err
+	}
+	if x.numCol != len(model.Coef) {
+		return fmt.Errorf("input has %d columns, but the model has %d coefficients", x.numCol, len(model.Coef))
+	}
+
+	var preds []float32
+	err = timed(stderr, "predict", func() error {
+		if backend == cuml4go.CPUBackend {
+			preds = make([]float32, x.numRow)
+			for r := range preds {
+				sum := model.Intercept
+				for c, coef := range model.Coef {
+					sum += coef * x.data[r*x.numCol+c]
+				}
+				preds[r] = sum
+			}
+			return nil
+		}
+
+		// the solver does not matter for prediction
+		gpuModel, err := cuml4go.NewLinearRegression(true, false, cuml4go.Eig)
+		if err != nil {
+			return err
+		}
+		defer gpuModel.Close()
+		gpuModel.SetParams(model.Coef)
+		gpuModel.SetIntercept(model.Intercept)
+		preds, err = gpuModel.Predict(x.data, x.numRow, x.numCol, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return writePredictions(stdout, *output, preds, x.numRow, 1)
+}
+
+// writePredictions writes the predictions to path, or prints them as CSV if path is empty.
+func writePredictions(stdout io.Writer, path string, preds []float32, numRow int, numCol int) error {
+	if path != "" {
+		return writeMatrix(path, preds, numRow, numCol)
+	}
+	return writeCSV(stdout, numCol, len(preds), func(i int) string {
+		return fmt.Sprint(preds[i])
+	})
+}
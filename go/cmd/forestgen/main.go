@@ -0,0 +1,82 @@
+// Command forestgen compiles an XGBoost or LightGBM model into a Go package without cgo.
+//
+// Usage:
+//
+//	forestgen -model model.json [-model-type xgboost_json] [-package name] [-output model_gen.go] [-quantize]
+//
+// the package defaults to $GOPACKAGE, so that a model can be compiled by go generate:
+//
+//	//go:generate go run github.com/getumen/cuml-bindings/go/cmd/forestgen -model model.json -output model_gen.go
+//
+// the generated package exports Predict(features []float32) []float32,
+// which returns the same outputs as the CPU backend of cuml4go.FILModel.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/codegen"
+)
+
+var formats = map[string]forest.Format{
+	"xgboost":        forest.XGBoostBinary,
+	"xgboost_json":   forest.XGBoostJSON,
+	"xgboost_ubjson": forest.XGBoostUBJSON,
+	"lightgbm":       forest.LightGBM,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run generates the code of the model of args into -output, or stdout if it is empty.
+func run(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("forestgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	modelPath := flags.String("model", "", "model file")
+	formatName := flags.String("model-type", "xgboost_json", "model format: xgboost, xgboost_json, xgboost_ubjson or lightgbm")
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"), "name of the generated package; defaults to $GOPACKAGE")
+	output := flags.String("output", "", "output file; the code is printed if empty")
+	quantize := flags.Bool("quantize", false, "compare the indices of the thresholds instead of the feature values")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *modelPath == "" {
+		return errors.New("-model is required")
+	}
+	if *pkg == "" {
+		return errors.New("-package is required outside go generate")
+	}
+	format, ok := formats[*formatName]
+	if !ok {
+		return fmt.Errorf("unknown model type %q: expected xgboost, xgboost_json, xgboost_ubjson or lightgbm", *formatName)
+	}
+
+	data, err := os.ReadFile(*modelPath)
+	if err != nil {
+		return err
+	}
+	f, err := forest.Parse(format, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *modelPath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := codegen.Generate(&buf, f, codegen.Config{Package: *pkg, Quantize: *quantize}); err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "model_gen.go")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{
+		"-model", "../../../testdata/xgboost.json",
+		"-package", "model",
+		"-output", output,
+		"-quantize",
+	}, &stdout, &stderr)
+	require.NoError(t, err, stderr.String())
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "// Code generated by "))
+	require.Contains(t, string(data), "\npackage model\n")
+	require.Contains(t, string(data), "\nfunc tree99(x []float32, q *[NumFeature]int32) float64 {\n")
+}
+
+func TestRunUsage(t *testing.T) {
+	t.Setenv("GOPACKAGE", "")
+	var stdout, stderr bytes.Buffer
+	require.EqualError(t, run(nil, &stdout, &stderr), "-model is required")
+	require.EqualError(t, run([]string{"-model", "m"}, &stdout, &stderr), "-package is required outside go generate")
+	require.Error(t, run([]string{"-model", "m", "-package", "p", "-model-type", "onnx"}, &stdout, &stderr))
+}
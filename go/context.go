@@ -0,0 +1,69 @@
+package cuml4go
+
+import (
+	"context"
+	"slices"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+// contextChunkRows is the number of rows passed to a native call at once
+// by the context-aware methods, which check cancellation between chunks.
+const contextChunkRows = 4096
+
+// forEachChunk calls fn for consecutive row ranges of at most chunkRows rows
+// and stops with ctx.Err() as soon as ctx is done.
+func forEachChunk(
+	ctx context.Context,
+	numRow int,
+	chunkRows int,
+	fn func(start int, end int) error,
+) error {
+	for start := 0; start < numRow; start += chunkRows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(start, min(start+chunkRows, numRow)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callContext runs the monolithic native call fn with a handle borrowed from resources.
+// it returns ctx.Err() as soon as ctx is done. in that case fn keeps running
+// in the background and the handle is returned only once it returns,
+// so that the handle is neither reused nor freed while the GPU is busy.
+// fn must only read memory it owns, see ownedInput.
+func callContext(
+	ctx context.Context,
+	resources *Resources,
+	fn func(deviceResource *rawcuml4go.DeviceResource) error,
+) error {
+	handle, err := resources.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer resources.release(handle)
+		done <- fn(handle)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ownedInput returns a copy of x if ctx can be done, since callContext may return
+// while the native call still reads x. x is returned as is for a context which is never done.
+func ownedInput[T any](ctx context.Context, x []T) []T {
+	if ctx.Done() == nil {
+		return x
+	}
+	return slices.Clone(x)
+}
@@ -0,0 +1,68 @@
+package cuml4go_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestFitContextCanceled(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	kmeans, err := cuml4go.NewKmeans(3, 10, 0.0, cuml4go.KMeansPlusPlus, cuml4go.L2Expanded, 42, cuml4go.Info)
+	require.NoError(t, err)
+	defer kmeans.Close()
+
+	_, _, _, _, err = kmeans.FitContext(ctx, features, featureRow, featureCol, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	dbscan, err := cuml4go.NewDBScan(5, 3.0, cuml4go.L2SqrtUnexpanded, 0, cuml4go.Info)
+	require.NoError(t, err)
+	defer dbscan.Close()
+
+	_, err = dbscan.FitContext(ctx, features, featureRow, featureCol)
+	require.ErrorIs(t, err, context.Canceled)
+
+	agglomerative, err := cuml4go.NewAgglomerativeClustering(false, cuml4go.L2SqrtExpanded, 5, 15)
+	require.NoError(t, err)
+	defer agglomerative.Close()
+
+	_, _, _, err = agglomerative.FitContext(ctx, features, featureRow, featureCol)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCSRContextCanceled(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	x := cuml4go.NewCSRMatrixFromDense([]float32{
+		1, 0, 2,
+		0, 3, 0,
+	}, 2, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cuml4go.PairwiseDistanceCSRContext(ctx, x, x, cuml4go.L2Expanded, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	target, err := cuml4go.NewLinearRegression(true, false, cuml4go.Svd)
+	require.NoError(t, err)
+	defer target.Close()
+
+	target.SetParams([]float32{1, 1, 1})
+
+	_, err = target.PredictCSRContext(ctx, x, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	actual, err := target.PredictCSRContext(context.Background(), x, nil)
+	require.NoError(t, err)
+	require.Equal(t, []float32{3, 3}, actual)
+}
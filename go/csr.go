@@ -0,0 +1,160 @@
+package cuml4go
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrInvalidCSRMatrix is returned when a CSR matrix is malformed.
+	ErrInvalidCSRMatrix = errors.New("invalid csr matrix")
+)
+
+// csrChunkRows is the number of rows densified at once
+// when a CSR matrix is passed to a GPU path that lacks sparse kernels.
+const csrChunkRows = 1024
+
+// CSRMatrix is a sparse matrix in compressed sparse row format.
+// the column indices and values of row r are stored in
+// Indices[IndPtr[r]:IndPtr[r+1]] and Values[IndPtr[r]:IndPtr[r+1]].
+type CSRMatrix struct {
+	IndPtr  []int32
+	Indices []int32
+	Values  []float32
+	NumCol  int
+}
+
+// NewCSRMatrix returns a validated CSR matrix.
+// the slices are not copied.
+func NewCSRMatrix(
+	indPtr []int32,
+	indices []int32,
+	values []float32,
+	numCol int,
+) (*CSRMatrix, error) {
+	m := &CSRMatrix{
+		IndPtr:  indPtr,
+		Indices: indices,
+		Values:  values,
+		NumCol:  numCol,
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// NewCSRMatrixFromDense converts a dense row-major matrix into CSR format.
+// entries equal to zero are dropped.
+func NewCSRMatrixFromDense(
+	x []float32,
+	numRow int,
+	numCol int,
+) *CSRMatrix {
+	indPtr := make([]int32, numRow+1)
+	indices := make([]int32, 0)
+	values := make([]float32, 0)
+
+	for r := 0; r < numRow; r++ {
+		for c, value := range x[r*numCol : (r+1)*numCol] {
+			if value != 0 {
+				indices = append(indices, int32(c))
+				values = append(values, value)
+			}
+		}
+		indPtr[r+1] = int32(len(indices))
+	}
+
+	return &CSRMatrix{
+		IndPtr:  indPtr,
+		Indices: indices,
+		Values:  values,
+		NumCol:  numCol,
+	}
+}
+
+// NumRow returns the number of rows.
+func (m *CSRMatrix) NumRow() int {
+	if len(m.IndPtr) == 0 {
+		return 0
+	}
+	return len(m.IndPtr) - 1
+}
+
+// NumNonZero returns the number of stored entries.
+func (m *CSRMatrix) NumNonZero() int {
+	return len(m.Values)
+}
+
+// Validate checks the structure of the matrix.
+func (m *CSRMatrix) Validate() error {
+	if m.NumCol < 0 || len(m.IndPtr) == 0 {
+		return ErrInvalidCSRMatrix
+	}
+	if len(m.Indices) != len(m.Values) {
+		return ErrInvalidCSRMatrix
+	}
+	if m.IndPtr[0] != 0 || int(m.IndPtr[len(m.IndPtr)-1]) != len(m.Values) {
+		return ErrInvalidCSRMatrix
+	}
+	for r := 0; r < m.NumRow(); r++ {
+		if m.IndPtr[r] > m.IndPtr[r+1] {
+			return ErrInvalidCSRMatrix
+		}
+	}
+	for _, c := range m.Indices {
+		if c < 0 || int(c) >= m.NumCol {
+			return ErrInvalidCSRMatrix
+		}
+	}
+	return nil
+}
+
+// Densify writes rows [rowStart, rowEnd) into dst as a dense row-major matrix
+// of numCol columns. absent entries are set to fill; use NaN to mark them as missing.
+// columns at or beyond numCol are dropped.
+// dst is allocated if its capacity is too small.
+func (m *CSRMatrix) Densify(
+	rowStart int,
+	rowEnd int,
+	numCol int,
+	fill float32,
+	dst []float32,
+) []float32 {
+	size := (rowEnd - rowStart) * numCol
+	if cap(dst) < size {
+		dst = make([]float32, size)
+	}
+	dst = dst[:size]
+
+	for i := range dst {
+		dst[i] = fill
+	}
+
+	for r := rowStart; r < rowEnd; r++ {
+		row := dst[(r-rowStart)*numCol : (r-rowStart+1)*numCol]
+		for i := m.IndPtr[r]; i < m.IndPtr[r+1]; i++ {
+			if c := int(m.Indices[i]); c < numCol {
+				row[c] = m.Values[i]
+			}
+		}
+	}
+
+	return dst
+}
+
+// rowDot returns the dot product of row r and the dense vector v.
+func (m *CSRMatrix) rowDot(r int, v []float32) float32 {
+	var sum float32
+	for i := m.IndPtr[r]; i < m.IndPtr[r+1]; i++ {
+		if c := int(m.Indices[i]); c < len(v) {
+			sum += m.Values[i] * v[c]
+		}
+	}
+	return sum
+}
+
+// missingValue is the value FIL treats as a missing feature.
+var missingValue = float32(math.NaN())
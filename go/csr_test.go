@@ -0,0 +1,151 @@
+package cuml4go_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestCSRMatrix(t *testing.T) {
+	dense := []float32{
+		1, 0, 2,
+		0, 0, 0,
+		0, 3, 0,
+	}
+
+	m := cuml4go.NewCSRMatrixFromDense(dense, 3, 3)
+	require.NoError(t, m.Validate())
+	require.Equal(t, 3, m.NumRow())
+	require.Equal(t, 3, m.NumNonZero())
+	require.Equal(t, []int32{0, 2, 2, 3}, m.IndPtr)
+
+	require.Equal(t, dense, m.Densify(0, 3, 3, 0, nil))
+
+	actual := m.Densify(1, 3, 2, -1, nil)
+	require.Equal(t, []float32{-1, -1, -1, 3}, actual)
+
+	_, err := cuml4go.NewCSRMatrix([]int32{0, 1}, []int32{3}, []float32{1}, 3)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidCSRMatrix)
+
+	_, err = cuml4go.NewCSRMatrix([]int32{0, 2}, []int32{0}, []float32{1}, 3)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidCSRMatrix)
+
+	_, err = cuml4go.NewCSRMatrix([]int32{0, 1}, []int32{0}, []float32{1}, 3)
+	require.NoError(t, err)
+}
+
+func TestPairwiseDistanceCSR(t *testing.T) {
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	numCol := 30
+	numRowX := 7
+	numRowY := 5
+
+	// sparsify the features so that both rows share only some of the columns
+	for i := range features {
+		if i%3 == 0 {
+			features[i] = 0
+		}
+	}
+	denseX := features[:numRowX*numCol]
+	denseY := features[numRowX*numCol : (numRowX+numRowY)*numCol]
+
+	x := cuml4go.NewCSRMatrixFromDense(denseX, numRowX, numCol)
+	y := cuml4go.NewCSRMatrixFromDense(denseY, numRowY, numCol)
+
+	metrics := []cuml4go.Metric{
+		cuml4go.L2Expanded,
+		cuml4go.L2SqrtExpanded,
+		cuml4go.L2Unexpanded,
+		cuml4go.L2SqrtUnexpanded,
+		cuml4go.L1,
+		cuml4go.Linf,
+		cuml4go.CosineExpanded,
+		cuml4go.InnerProduct,
+	}
+
+	for _, metric := range metrics {
+		actual, err := cuml4go.PairwiseDistanceCSR(x, y, metric, nil)
+		require.NoError(t, err)
+		require.Len(t, actual, numRowX*numRowY)
+
+		for i := 0; i < numRowX; i++ {
+			for j := 0; j < numRowY; j++ {
+				expected := denseDistance(
+					metric,
+					denseX[i*numCol:(i+1)*numCol],
+					denseY[j*numCol:(j+1)*numCol],
+				)
+				require.InDelta(t, expected, actual[i*numRowY+j], 1e-2*math.Max(1, math.Abs(expected)), "metric %d", metric)
+			}
+		}
+	}
+
+	_, err := cuml4go.PairwiseDistanceCSR(x, y, cuml4go.Haversine, nil)
+	require.ErrorIs(t, err, cuml4go.ErrUnsupportedMetric)
+
+	invalid := &cuml4go.CSRMatrix{IndPtr: []int32{0, 2}, Indices: []int32{0, int32(numCol)}, Values: []float32{1, 2}, NumCol: numCol}
+	_, err = cuml4go.PairwiseDistanceCSR(invalid, y, cuml4go.L2Expanded, nil)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidCSRMatrix)
+	_, err = cuml4go.PairwiseDistanceCSR(x, invalid, cuml4go.L2Expanded, nil)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidCSRMatrix)
+}
+
+func denseDistance(metric cuml4go.Metric, a, b []float32) float64 {
+	var dot, normA, normB, l1, linf, l2 float64
+	for i := range a {
+		diff := float64(a[i]) - float64(b[i])
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+		l1 += math.Abs(diff)
+		linf = math.Max(linf, math.Abs(diff))
+		l2 += diff * diff
+	}
+	switch metric {
+	case cuml4go.L2Expanded, cuml4go.L2Unexpanded:
+		return l2
+	case cuml4go.L2SqrtExpanded, cuml4go.L2SqrtUnexpanded:
+		return math.Sqrt(l2)
+	case cuml4go.L1:
+		return l1
+	case cuml4go.Linf:
+		return linf
+	case cuml4go.CosineExpanded:
+		return 1 - dot/math.Sqrt(normA*normB)
+	default:
+		return dot
+	}
+}
+
+func TestLinearRegressionPredictCSR(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	target, err := cuml4go.NewLinearRegression(
+		true,
+		false,
+		cuml4go.Svd,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	target.SetParams([]float32{0.5, -1, 2})
+	target.SetIntercept(0.25)
+
+	x := cuml4go.NewCSRMatrixFromDense([]float32{
+		1, 0, 2,
+		0, 0, 0,
+		0, 3, 0,
+	}, 3, 3)
+
+	actual, err := target.PredictCSR(x, nil)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float32{4.75, 0.25, -2.75}, actual, 1e-6)
+
+	_, err = target.PredictCSR(&cuml4go.CSRMatrix{
+		IndPtr: []int32{0},
+		NumCol: 2,
+	}, nil)
+	require.ErrorIs(t, err, cuml4go.ErrDimensionMismatch)
+}
@@ -1,6 +1,7 @@
 package cuml4go
 
 import (
+	"context"
 	"errors"
 
 	"github.com/getumen/cuml-bindings/go/rawcuml4go"
@@ -11,7 +12,8 @@ var (
 )
 
 type DBScan struct {
-	deviceResource   *rawcuml4go.DeviceResource
+	resources        *Resources
+	ownsResources    bool
 	minPts           int
 	eps              float64
 	metric           Metric
@@ -25,15 +27,17 @@ func NewDBScan(
 	metric Metric,
 	maxBytesPerBatch int,
 	verbosity LogLevel,
+	opts ...Option,
 ) (*DBScan, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	resources, owned, err := newConfig(opts).deviceResources()
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &DBScan{
-		deviceResource:   deviceResource,
+		resources:        resources,
+		ownsResources:    owned,
 		minPts:           minPts,
 		eps:              eps,
 		metric:           metric,
@@ -47,23 +51,68 @@ func (d *DBScan) Fit(
 	numRow int,
 	numCol int,
 ) ([]int32, error) {
+	return d.FitContext(context.Background(), x, numRow, numCol)
+}
 
-	labels, err := rawcuml4go.DBScan(
-		d.deviceResource,
-		x,
-		numRow,
-		numCol,
-		d.minPts,
-		d.eps,
-		int(d.metric),
-		d.maxBytesPerBatch,
-		int(d.verbosity),
-		nil,
-	)
+// FitContext is the context-aware variant of Fit.
+// the native call cannot be interrupted, so it returns ctx.Err() as soon as ctx is done
+// while the call runs to completion in the background;
+// its device resource handle is not reused until then, and it reads a copy of x,
+// which the caller may reuse as soon as FitContext returns.
+func (d *DBScan) FitContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	numCol int,
+) ([]int32, error) {
+	var labels []int32
+	x = ownedInput(ctx, x)
+	err := callContext(ctx, d.resources, func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		labels, err = rawcuml4go.DBScan(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			d.minPts,
+			d.eps,
+			int(d.metric),
+			d.maxBytesPerBatch,
+			int(d.verbosity),
+			nil,
+		)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return labels, nil
+}
+
+// Fit64 is the double precision variant of Fit.
+func (d *DBScan) Fit64(
+	x []float64,
+	numRow int,
+	numCol int,
+) ([]int32, error) {
+	var labels []int32
+	err := d.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		labels, err = rawcuml4go.DBScan64(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			d.minPts,
+			d.eps,
+			int(d.metric),
+			d.maxBytesPerBatch,
+			int(d.verbosity),
+			nil,
+		)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +120,7 @@ func (d *DBScan) Fit(
 	return labels, nil
 }
 
+// Close frees the device resources unless they are shared by WithResources.
 func (d *DBScan) Close() error {
-	return d.deviceResource.Close()
+	return closeResources(d.resources, d.ownsResources)
 }
@@ -8,7 +8,7 @@ import (
 )
 
 func TestDBScan(t *testing.T) {
-
+	skipWithoutNativeLibrary(t)
 	features := csvToFloat32Array(t, "../testdata/feature.csv")
 	featureCol := 30
 	featureRow := 114
@@ -33,3 +33,30 @@ func TestDBScan(t *testing.T) {
 
 	require.Equal(t, len(result), featureRow)
 }
+
+func TestDBScan64(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	features := csvToFloat64Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	target, err := cuml4go.NewDBScan(
+		5,
+		3.0,
+		cuml4go.L2SqrtUnexpanded,
+		0,
+		cuml4go.Info,
+	)
+
+	require.NoError(t, err)
+
+	result, err := target.Fit64(
+		features,
+		featureRow,
+		featureCol,
+	)
+
+	require.NoError(t, err)
+
+	require.Equal(t, len(result), featureRow)
+}
@@ -0,0 +1,151 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+var (
+	// ErrUnsupportedMetric is returned when the metric is not implemented for the input.
+	ErrUnsupportedMetric = errors.New("unsupported metric")
+	// ErrDimensionMismatch is returned when the number of columns of the inputs differ.
+	ErrDimensionMismatch = errors.New("dimension mismatch")
+)
+
+// PairwiseDistanceCSR computes the distance between every row of x and every row of y on CPU.
+// result is a float array of size x.NumRow() * y.NumRow(),
+// and the distance between row i of x and row j of y is stored in result[i * y.NumRow() + j].
+// supported metrics are L2Expanded, L2SqrtExpanded, L2Unexpanded, L2SqrtUnexpanded,
+// L1, Linf, CosineExpanded and InnerProduct.
+// result is allocated if it is nil.
+func PairwiseDistanceCSR(
+	x *CSRMatrix,
+	y *CSRMatrix,
+	metric Metric,
+	result []float32,
+) ([]float32, error) {
+	return PairwiseDistanceCSRContext(context.Background(), x, y, metric, result)
+}
+
+// PairwiseDistanceCSRContext is the context-aware variant of PairwiseDistanceCSR.
+// ctx is checked for every row of x.
+func PairwiseDistanceCSRContext(
+	ctx context.Context,
+	x *CSRMatrix,
+	y *CSRMatrix,
+	metric Metric,
+	result []float32,
+) ([]float32, error) {
+	if err := x.Validate(); err != nil {
+		return nil, err
+	}
+	if err := y.Validate(); err != nil {
+		return nil, err
+	}
+	if x.NumCol != y.NumCol {
+		return nil, ErrDimensionMismatch
+	}
+
+	switch metric {
+	case L2Expanded, L2SqrtExpanded, L2Unexpanded, L2SqrtUnexpanded,
+		L1, Linf, CosineExpanded, InnerProduct:
+	default:
+		return nil, ErrUnsupportedMetric
+	}
+
+	numRowX := x.NumRow()
+	numRowY := y.NumRow()
+
+	if result == nil {
+		result = make([]float32, numRowX*numRowY)
+	}
+
+	normX := x.rowNorms()
+	normY := y.rowNorms()
+
+	// dense copy of the current row of x and the mark of columns touched by the row of y
+	dense := make([]float32, x.NumCol)
+	visited := make([]int, x.NumCol)
+	for c := range visited {
+		visited[c] = -1
+	}
+
+	for i := 0; i < numRowX; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for k := x.IndPtr[i]; k < x.IndPtr[i+1]; k++ {
+			dense[x.Indices[k]] = x.Values[k]
+		}
+
+		for j := 0; j < numRowY; j++ {
+			var dist float64
+			switch metric {
+			case L2Expanded, L2SqrtExpanded, CosineExpanded, InnerProduct:
+				dot := float64(y.rowDot(j, dense))
+				switch metric {
+				case InnerProduct:
+					dist = dot
+				case CosineExpanded:
+					if normX[i] == 0 || normY[j] == 0 {
+						dist = 0
+					} else {
+						dist = 1 - dot/math.Sqrt(normX[i]*normY[j])
+					}
+				default:
+					dist = math.Max(normX[i]+normY[j]-2*dot, 0)
+					if metric == L2SqrtExpanded {
+						dist = math.Sqrt(dist)
+					}
+				}
+			default:
+				// entries stored in y, then entries stored only in x
+				stamp := i*numRowY + j
+				for k := y.IndPtr[j]; k < y.IndPtr[j+1]; k++ {
+					c := y.Indices[k]
+					visited[c] = stamp
+					dist = accumulateDistance(metric, dist, float64(dense[c])-float64(y.Values[k]))
+				}
+				for k := x.IndPtr[i]; k < x.IndPtr[i+1]; k++ {
+					if visited[x.Indices[k]] != stamp {
+						dist = accumulateDistance(metric, dist, float64(x.Values[k]))
+					}
+				}
+				if metric == L2SqrtUnexpanded {
+					dist = math.Sqrt(dist)
+				}
+			}
+			result[i*numRowY+j] = float32(dist)
+		}
+
+		for k := x.IndPtr[i]; k < x.IndPtr[i+1]; k++ {
+			dense[x.Indices[k]] = 0
+		}
+	}
+
+	return result, nil
+}
+
+func accumulateDistance(metric Metric, acc float64, diff float64) float64 {
+	switch metric {
+	case L1:
+		return acc + math.Abs(diff)
+	case Linf:
+		return math.Max(acc, math.Abs(diff))
+	default:
+		return acc + diff*diff
+	}
+}
+
+// rowNorms returns the squared L2 norm of every row.
+func (m *CSRMatrix) rowNorms() []float64 {
+	norms := make([]float64, m.NumRow())
+	for r := range norms {
+		for i := m.IndPtr[r]; i < m.IndPtr[r+1]; i++ {
+			norms[r] += float64(m.Values[i]) * float64(m.Values[i])
+		}
+	}
+	return norms
+}
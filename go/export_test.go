@@ -0,0 +1,29 @@
+package cuml4go
+
+import "github.com/getumen/cuml-bindings/go/forest"
+
+// FILForest exposes the native forest interface to tests.
+type FILForest = filForest
+
+// NewFakeResources creates resources whose handles are never passed to the native library.
+func NewFakeResources(size int) (*Resources, error) {
+	return newHostResources(size)
+}
+
+// NewFILModelFromForest wraps forest with resources shared by the caller.
+func NewFILModelFromForest(forest FILForest, resources *Resources, opts ...Option) (*FILModel, error) {
+	return newFILModel(forest, resources, false, newConfig(opts).chunking)
+}
+
+// FILTrees converts a forest into the trees built on the device.
+var FILTrees = filTrees
+
+// SetParseHost sets how a model wrapped by NewFILModelFromForest is parsed on the host on the first use.
+func (m *FILModel) SetParseHost(parse func() (*forest.Forest, error)) {
+	m.parseHost = parse
+}
+
+// SetParseFeatures sets how a model wrapped by NewFILModelFromForest parses its feature names on the first use.
+func (m *FILModel) SetParseFeatures(parse func() ([]string, []string, error)) {
+	m.parseFeatures = parse
+}
@@ -1,8 +1,14 @@
 package cuml4go
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
 
+	"github.com/getumen/cuml-bindings/go/forest"
 	"github.com/getumen/cuml-bindings/go/rawcuml4go"
 	"go.uber.org/multierr"
 )
@@ -14,6 +20,8 @@ var (
 	ErrFILModelFree = errors.New("fail to free model")
 	// ErrFILModelPredict is returned when fail to predict.
 	ErrFILModelPredict = errors.New("fail to predict")
+	// ErrFILModelClosed is returned when the model is used after Close.
+	ErrFILModelClosed = errors.New("model is closed")
 )
 
 // FILModelType is the type of the forest.
@@ -26,8 +34,30 @@ const (
 	XGBoostJSON
 	// LightGBM lighgbm model (binary model file)
 	LightGBM
+	// ONNX onnx model of a TreeEnsembleRegressor or TreeEnsembleClassifier, see onnx.Model.ToForest
+	ONNX
+	// XGBoostUBJSON xgboost model (ubjson model file), the default of xgboost 2
+	XGBoostUBJSON
 )
 
+// filModelTypes are the names of the model types accepted by ParseFILModelType.
+var filModelTypes = map[string]FILModelType{
+	"xgboost":        XGBoost,
+	"xgboost_json":   XGBoostJSON,
+	"xgboost_ubjson": XGBoostUBJSON,
+	"lightgbm":       LightGBM,
+	"onnx":           ONNX,
+}
+
+// ParseFILModelType returns the model type of name: xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx.
+func ParseFILModelType(name string) (FILModelType, error) {
+	modelType, ok := filModelTypes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown model type %q: expected xgboost, xgboost_json, xgboost_ubjson, lightgbm or onnx", name)
+	}
+	return modelType, nil
+}
+
 // FILInferenceAlgorithm is the inference algorithm.
 type FILInferenceAlgorithm int
 
@@ -65,16 +95,62 @@ const (
 	Sparse8
 )
 
+// filForest is the native forest used by FILModel.
+// it is implemented by *rawcuml4go.FILModel.
+type filForest interface {
+	PredictOn(
+		deviceResource *rawcuml4go.DeviceResource,
+		x []float32,
+		numRow int,
+		outputClassProbability bool,
+		preds []float32,
+	) ([]float32, error)
+	CloseOn(deviceResource *rawcuml4go.DeviceResource) error
+	NumFeatures() (int, error)
+}
+
 // FILModel is a Forest Inference Library model.
+//
+// FILModel is safe for concurrent use by multiple goroutines.
+// every prediction borrows a device resource handle from the model's Resources
+// exclusively for its duration, so concurrent predictions never share a CUDA stream.
+// by default the model owns a single handle and concurrent predictions are serialized;
+// pass WithResources with a larger pool to run them in parallel.
+// Close waits for in-flight predictions, and later calls return ErrFILModelClosed.
 type FILModel struct {
-	raw            *rawcuml4go.FILModel
-	deviceResource *rawcuml4go.DeviceResource
+	raw           filForest
+	resources     *Resources
+	ownsResources bool
+	chunking      chunking
+	numFeatures   int
+
+	// featureNames, featureTypes and featureIndex are nil if the model has no feature names.
+	// a model loaded on the device sets those stored in the model by parseFeatures on the first use of them.
+	featureNames  []string
+	featureTypes  []string
+	featureIndex  map[string]int
+	featuresOnce  sync.Once
+	parseFeatures func() (names []string, types []string, err error)
+
+	// forest is the model parsed on the host for what FIL does not offer, e.g. PredictContributions.
+	// a model loaded on the device is parsed by hostForest on the first use of such a method,
+	// and hostErr is the error of the parse.
+	forest    *forest.Forest
+	hostErr   error
+	hostOnce  sync.Once
+	parseHost func() (*forest.Forest, error)
+	// threshold is the threshold of the class of a binary classifier.
+	threshold float32
+
+	// mu guards closed; predictions hold the read lock.
+	mu     sync.RWMutex
+	closed bool
 }
 
 // NewFILModel
 // algo is the inference algorithm.
 // threshold may be used for thresholding if classification == true,
-// and is ignored otherwise. threshold is ignored if leaves store
+// and is ignored otherwise, except by PredictOutput with OutputClass. threshold is ignored if leaves store
 // vectorized class labels. in that case, a class with most votes
 // is returned regardless of the absolute vote count.
 // blocksPerSm if nonzero, works as a limit to improve cache hit rate for larger forests
@@ -84,6 +160,8 @@ type FILModel struct {
 // can only be a power of 2
 // nItems is how many input samples (items) any thread processes. If 0 is given,
 // choose most (up to 4) that fit into shared memory.
+// with WithBackend(CPUBackend), the model is parsed and evaluated in Go,
+// and algo, storageType, blocksPerSm, threadsPerTree and nItems are ignored.
 func NewFILModel(
 	modelType FILModelType,
 	filePath string,
@@ -94,74 +172,441 @@ func NewFILModel(
 	blocksPerSm int,
 	threadsPerTree int,
 	nItems int,
+	opts ...Option,
+) (*FILModel, error) {
+	cfg := newConfig(opts)
+	if cfg.backend == CPUBackend {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return newCPUFILModel(cfg, modelType, data, classification, threshold)
+	}
+
+	// the native library exposes neither the trees nor the feature names,
+	// so the file is read again on the host on the first use of them
+	read := func() ([]byte, error) {
+		return os.ReadFile(filePath)
+	}
+	if modelType == ONNX || cfg.iterationRange != nil {
+		data, err := read()
+		if err != nil {
+			return nil, err
+		}
+		return loadFILModelFromTrees(cfg, modelType, data, read, algo, classification, threshold, storageType, blocksPerSm, threadsPerTree, nItems)
+	}
+
+	return loadFILModel(cfg, modelType, read, threshold, func(deviceResource *rawcuml4go.DeviceResource) (*rawcuml4go.FILModel, error) {
+		return rawcuml4go.NewFILModel(
+			deviceResource,
+			int(modelType),
+			filePath,
+			int(algo),
+			classification,
+			threshold,
+			int(storageType),
+			blocksPerSm,
+			threadsPerTree,
+			nItems,
+		)
+	})
+}
+
+// NewFILModelFromBytes is the same as NewFILModel but loads the model from data,
+// e.g. a model fetched from a remote storage or embedded with go:embed.
+// data is kept to parse the model on the host on the first use of a method FIL does not offer,
+// e.g. PredictContributions, or of its feature names, so it must not be modified.
+func NewFILModelFromBytes(
+	modelType FILModelType,
+	data []byte,
+	algo FILInferenceAlgorithm,
+	classification bool,
+	threshold float32,
+	storageType FILStorageType,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+	opts ...Option,
 ) (*FILModel, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	cfg := newConfig(opts)
+	if cfg.backend == CPUBackend {
+		return newCPUFILModel(cfg, modelType, data, classification, threshold)
+	}
+	read := func() ([]byte, error) {
+		return data, nil
+	}
+	if modelType == ONNX || cfg.iterationRange != nil {
+		return loadFILModelFromTrees(cfg, modelType, data, read, algo, classification, threshold, storageType, blocksPerSm, threadsPerTree, nItems)
+	}
+
+	return loadFILModel(cfg, modelType, read, threshold, func(deviceResource *rawcuml4go.DeviceResource) (*rawcuml4go.FILModel, error) {
+		return rawcuml4go.NewFILModelFromBytes(
+			deviceResource,
+			int(modelType),
+			data,
+			int(algo),
+			classification,
+			threshold,
+			int(storageType),
+			blocksPerSm,
+			threadsPerTree,
+			nItems,
+		)
+	})
+}
 
+// NewFILModelFromReader is the same as NewFILModelFromBytes but reads the model from r until EOF.
+func NewFILModelFromReader(
+	modelType FILModelType,
+	r io.Reader,
+	algo FILInferenceAlgorithm,
+	classification bool,
+	threshold float32,
+	storageType FILStorageType,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+	opts ...Option,
+) (*FILModel, error) {
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	raw, err := rawcuml4go.NewFILModel(
-		deviceResource,
-		int(modelType),
-		filePath,
-		int(algo),
+	return NewFILModelFromBytes(
+		modelType,
+		data,
+		algo,
 		classification,
 		threshold,
-		int(storageType),
+		storageType,
 		blocksPerSm,
 		threadsPerTree,
 		nItems,
+		opts...,
 	)
+}
+
+// loadFILModel loads a model on the device with a borrowed handle.
+// threshold is the threshold of the class of OutputClass.
+// read returns the model to parse it on the host on the first use of its feature names
+// or of a method FIL does not offer, which fails if the Go parser does not support it
+// since the native library may still load it.
+func loadFILModel(
+	cfg *config,
+	modelType FILModelType,
+	read func() ([]byte, error),
+	threshold float32,
+	load func(deviceResource *rawcuml4go.DeviceResource) (*rawcuml4go.FILModel, error),
+) (*FILModel, error) {
+	resources, owned, err := cfg.deviceResources()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var raw *rawcuml4go.FILModel
+	err = resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		raw, err = load(deviceResource)
+		return err
+	})
+
+	if err != nil {
+		return nil, multierr.Append(err, closeResources(resources, owned))
+	}
 
+	m, err := newFILModel(raw, resources, owned, cfg.chunking)
 	if err != nil {
 		return nil, err
 	}
+	m.parseHost = func() (*forest.Forest, error) {
+		data, err := read()
+		if err != nil {
+			return nil, err
+		}
+		f, err := parseForest(modelType, data)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.slice(f)
+	}
+	m.threshold = threshold
+	if err := m.setFeatures(cfg.featureNames, nil); err != nil {
+		return nil, multierr.Append(err, m.Close())
+	}
+	m.parseFeatures = func() ([]string, []string, error) {
+		if modelType == ONNX {
+			// ONNX models store no feature names
+			return nil, nil, nil
+		}
+		data, err := read()
+		if err != nil {
+			return nil, nil, err
+		}
+		return forest.ParseFeatures(forest.Format(modelType), data)
+	}
+	return m, nil
+}
+
+// newFILModel wraps a loaded forest. the forest is freed if it fails.
+func newFILModel(raw filForest, resources *Resources, owned bool, chunking chunking) (*FILModel, error) {
+	m := &FILModel{
+		raw:           raw,
+		resources:     resources,
+		ownsResources: owned,
+		chunking:      chunking,
+	}
 
-	return &FILModel{
-		raw:            raw,
-		deviceResource: deviceResource,
-	}, nil
+	var err error
+	m.numFeatures, err = raw.NumFeatures()
+	if err != nil {
+		return nil, multierr.Append(err, m.Close())
+	}
+
+	return m, nil
+}
+
+// with runs fn with a borrowed handle unless the model is closed.
+func (m *FILModel) with(
+	ctx context.Context,
+	fn func(deviceResource *rawcuml4go.DeviceResource) error,
+) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return ErrFILModelClosed
+	}
+	return m.resources.with(ctx, fn)
+}
+
+// NumFeatures returns the number of features the model expects.
+func (m *FILModel) NumFeatures() int {
+	return m.numFeatures
 }
 
 // Predict returns the prediction result.
 // result is a float array of size num_row * num_class if output_class_probability is true,
 // or num_row otherwise.
 // given a row r and class c, the probability of r belonging to c is stored in result[r * num_class + c].
+// the input is split into chunks if it exceeds WithMaxRowsPerCall or WithMaxBytesPerCall.
 func (m *FILModel) Predict(
 	x []float32,
 	numRow int,
 	outputClassProbability bool) ([]float32, error) {
 
-	preds, err := m.raw.Predict(x, numRow, outputClassProbability, nil)
-	if err != nil {
+	preds := make([]float32, numRow*filOutputWidth(outputClassProbability))
+	if err := m.PredictInto(preds, x, numRow, outputClassProbability); err != nil {
 		return nil, err
 	}
 	return preds, nil
 }
 
+// PredictContext is the context-aware variant of Predict.
+// the input is passed to the native call in chunks of rows and ctx is checked between chunks.
+func (m *FILModel) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	preds := make([]float32, numRow*filOutputWidth(outputClassProbability))
+	if err := m.PredictIntoContext(ctx, preds, x, numRow, outputClassProbability); err != nil {
+		return nil, err
+	}
+	return preds, nil
+}
+
+// PredictInto is the same as Predict but writes the result into dst,
+// whose length must be the length of the result.
+func (m *FILModel) PredictInto(
+	dst []float32,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) error {
+	return m.predictInto(context.Background(), dst, x, numRow, outputClassProbability, numRow)
+}
+
+// PredictIntoContext is the context-aware variant of PredictInto.
+func (m *FILModel) PredictIntoContext(
+	ctx context.Context,
+	dst []float32,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) error {
+	return m.predictInto(ctx, dst, x, numRow, outputClassProbability, contextChunkRows)
+}
+
+// predictInto writes the prediction result of chunks of at most defaultRows rows into dst.
+func (m *FILModel) predictInto(
+	ctx context.Context,
+	dst []float32,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	defaultRows int,
+) error {
+	outputWidth := filOutputWidth(outputClassProbability)
+	if len(x) != numRow*m.numFeatures {
+		return ErrDimensionMismatch
+	}
+	if len(dst) != numRow*outputWidth {
+		return ErrInvalidOutputLength
+	}
+
+	return m.with(ctx, func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.chunking.forEachChunk(ctx, numRow, m.numFeatures*4, defaultRows, func(start, end int) error {
+			_, err := m.raw.PredictOn(
+				deviceResource,
+				x[start*m.numFeatures:end*m.numFeatures],
+				end-start,
+				outputClassProbability,
+				dst[start*outputWidth:end*outputWidth],
+			)
+			return err
+		})
+	})
+}
+
+// PredictCSR returns the prediction result for a sparse input.
+// absent entries are treated as missing values.
+// the input is densified in chunks of rows since FIL has no sparse kernel.
+// the layout of the result is the same as Predict.
+func (m *FILModel) PredictCSR(
+	x *CSRMatrix,
+	outputClassProbability bool,
+) ([]float32, error) {
+	return m.PredictCSRContext(context.Background(), x, outputClassProbability)
+}
+
+// PredictCSRContext is the context-aware variant of PredictCSR.
+// ctx is checked between chunks.
+func (m *FILModel) PredictCSRContext(
+	ctx context.Context,
+	x *CSRMatrix,
+	outputClassProbability bool,
+) ([]float32, error) {
+	if err := x.Validate(); err != nil {
+		return nil, err
+	}
+
+	preds := make([]float32, x.NumRow()*filOutputWidth(outputClassProbability))
+	if err := m.PredictCSRIntoContext(ctx, preds, x, outputClassProbability); err != nil {
+		return nil, err
+	}
+	return preds, nil
+}
+
+// PredictCSRInto is the same as PredictCSR but writes the result into dst,
+// whose length must be the length of the result.
+func (m *FILModel) PredictCSRInto(
+	dst []float32,
+	x *CSRMatrix,
+	outputClassProbability bool,
+) error {
+	return m.PredictCSRIntoContext(context.Background(), dst, x, outputClassProbability)
+}
+
+// PredictCSRIntoContext is the context-aware variant of PredictCSRInto.
+func (m *FILModel) PredictCSRIntoContext(
+	ctx context.Context,
+	dst []float32,
+	x *CSRMatrix,
+	outputClassProbability bool,
+) error {
+	if err := x.Validate(); err != nil {
+		return err
+	}
+	if x.NumCol != m.numFeatures {
+		return ErrDimensionMismatch
+	}
+
+	numRow := x.NumRow()
+	outputWidth := filOutputWidth(outputClassProbability)
+	if len(dst) != numRow*outputWidth {
+		return ErrInvalidOutputLength
+	}
+
+	dense := getFloat32Buffer(0)
+	defer putFloat32Buffer(dense)
+
+	return m.with(ctx, func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.chunking.forEachChunk(ctx, numRow, m.numFeatures*4, csrChunkRows, func(start, end int) error {
+			*dense = x.Densify(start, end, m.numFeatures, missingValue, *dense)
+
+			_, err := m.raw.PredictOn(
+				deviceResource,
+				*dense,
+				end-start,
+				outputClassProbability,
+				dst[start*outputWidth:end*outputWidth],
+			)
+			return err
+		})
+	})
+}
+
 // PredictSingleClassScore returns the prediction result of the 1 class of {0,1} classification.
 func (m *FILModel) PredictSingleClassScore(
 	x []float32,
 	numRow int,
 ) ([]float32, error) {
-	resultRaw, err := m.Predict(x, numRow, true)
-	if err != nil {
+	result := make([]float32, numRow)
+	if err := m.PredictSingleClassScoreInto(result, x, numRow); err != nil {
 		return nil, err
 	}
+	return result, nil
+}
+
+// PredictSingleClassScoreInto is the same as PredictSingleClassScore but writes the result into dst,
+// whose length must be numRow. the class probabilities are computed in a pooled buffer.
+func (m *FILModel) PredictSingleClassScoreInto(
+	dst []float32,
+	x []float32,
+	numRow int,
+) error {
+	if len(dst) != numRow {
+		return ErrInvalidOutputLength
+	}
+
+	resultRaw := getFloat32Buffer(numRow * 2)
+	defer putFloat32Buffer(resultRaw)
+
+	if err := m.PredictInto(*resultRaw, x, numRow, true); err != nil {
+		return err
+	}
 
-	result := make([]float32, numRow)
 	for i := 0; i < numRow; i++ {
-		result[i] = resultRaw[i*2+1]
+		dst[i] = (*resultRaw)[i*2+1]
 	}
-	return result, nil
+	return nil
 }
 
-// Close frees the model.
+// Close frees the model,
+// and the device resources unless they are shared by WithResources.
+// it waits for in-flight predictions, and does nothing if the model is already closed.
 func (m *FILModel) Close() error {
-	var err error
-	err = multierr.Append(err, m.raw.Close())
-	err = multierr.Append(err, m.deviceResource.Close())
-	return err
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+
+	err := m.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.raw.CloseOn(deviceResource)
+	})
+	return multierr.Append(err, closeResources(m.resources, m.ownsResources))
 }
 
+// filOutputWidth returns the number of outputs per row.
+func filOutputWidth(outputClassProbability bool) int {
+	if outputClassProbability {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,310 @@
+package cuml4go
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+	"go.uber.org/multierr"
+)
+
+// ErrUnsupportedOnDevice is returned when a forest built on the device has no equivalent in treelite.
+var ErrUnsupportedOnDevice = errors.New("forest is not supported on the device")
+
+const (
+	// maxBuilderNodes bounds the nodes of the trees built on the device,
+	// whose splits of forest.MissingZero copy their children.
+	maxBuilderNodes = 1 << 26
+)
+
+// loadFILModelFromTrees builds the trees of data on the device with the model builder of treelite
+// for what treelite cannot load, i.e. ONNX models, which it has no frontend for, and the rounds of WithIterationRange.
+func loadFILModelFromTrees(
+	cfg *config,
+	modelType FILModelType,
+	data []byte,
+	read func() ([]byte, error),
+	algo FILInferenceAlgorithm,
+	classification bool,
+	threshold float32,
+	storageType FILStorageType,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	parsed, err := parseForest(modelType, data)
+	if err != nil {
+		return nil, multierr.Append(ErrFILModelLoad, err)
+	}
+	if parsed, err = cfg.slice(parsed); err != nil {
+		return nil, err
+	}
+	trees, err := filTrees(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadFILModel(cfg, modelType, read, threshold, func(deviceResource *rawcuml4go.DeviceResource) (*rawcuml4go.FILModel, error) {
+		return rawcuml4go.NewFILModelFromTrees(
+			deviceResource,
+			trees,
+			int(algo),
+			classification,
+			threshold,
+			int(storageType),
+			blocksPerSm,
+			threadsPerTree,
+			nItems,
+		)
+	})
+}
+
+// builderMetadata is the metadata of the model builder of treelite.
+type builderMetadata struct {
+	ThresholdType  string `json:"threshold_type"`
+	LeafOutputType string `json:"leaf_output_type"`
+	Metadata       struct {
+		NumFeature        int    `json:"num_feature"`
+		TaskType          string `json:"task_type"`
+		AverageTreeOutput bool   `json:"average_tree_output"`
+		NumTarget         int    `json:"num_target"`
+		NumClass          []int  `json:"num_class"`
+		LeafVectorShape   []int  `json:"leaf_vector_shape"`
+	} `json:"metadata"`
+	TreeAnnotation struct {
+		NumTree  int   `json:"num_tree"`
+		TargetID []int `json:"target_id"`
+		ClassID  []int `json:"class_id"`
+	} `json:"tree_annotation"`
+	Postprocessor struct {
+		Name   string             `json:"name"`
+		Config map[string]float64 `json:"config,omitempty"`
+	} `json:"postprocessor"`
+	BaseScores []float64 `json:"base_scores"`
+}
+
+// filTrees converts f into the trees of the model builder of treelite,
+// with a class of a multi-class classifier for every group.
+func filTrees(f *forest.Forest) (*rawcuml4go.FILTrees, error) {
+	var metadata builderMetadata
+	metadata.ThresholdType = "float64"
+	metadata.LeafOutputType = "float64"
+	metadata.Metadata.NumFeature = f.NumFeature
+	metadata.Metadata.AverageTreeOutput = f.AverageTreeOutput
+	metadata.Metadata.NumTarget = 1
+	metadata.Metadata.NumClass = []int{f.NumGroup}
+	metadata.Metadata.LeafVectorShape = []int{1, 1}
+	metadata.BaseScores = f.BaseScore
+
+	sigmoid := map[string]float64{"sigmoid_alpha": f.SigmoidAlpha}
+	switch {
+	case f.NumGroup == 1 && f.PostTransform == forest.Identity:
+		metadata.Metadata.TaskType = "kRegressor"
+		metadata.Postprocessor.Name = "identity"
+	case f.NumGroup == 1 && f.PostTransform == forest.Exponential:
+		metadata.Metadata.TaskType = "kRegressor"
+		metadata.Postprocessor.Name = "exponential"
+	case f.NumGroup == 1 && f.PostTransform == forest.Sigmoid:
+		metadata.Metadata.TaskType = "kBinaryClf"
+		metadata.Postprocessor.Name = "sigmoid"
+		metadata.Postprocessor.Config = sigmoid
+	case f.NumGroup == 1 && f.PostTransform == forest.Hinge:
+		metadata.Metadata.TaskType = "kBinaryClf"
+		metadata.Postprocessor.Name = "hinge"
+	case f.NumGroup > 1 && f.PostTransform == forest.Identity:
+		metadata.Metadata.TaskType = "kMultiClf"
+		metadata.Postprocessor.Name = "identity_multiclass"
+	case f.NumGroup > 1 && f.PostTransform == forest.Softmax:
+		metadata.Metadata.TaskType = "kMultiClf"
+		metadata.Postprocessor.Name = "softmax"
+	case f.NumGroup > 1 && f.PostTransform == forest.Sigmoid:
+		metadata.Metadata.TaskType = "kMultiClf"
+		metadata.Postprocessor.Name = "multiclass_ova"
+		metadata.Postprocessor.Config = sigmoid
+	default:
+		return nil, ErrUnsupportedOnDevice
+	}
+
+	// FIL takes the trees of a multi-class classifier in the round robin order of the classes,
+	// where a class without a tree of a round has a tree of a leaf of zero
+	var rounds [][]*forest.Tree
+	count := make([]int, f.NumGroup)
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		if count[t.Group] == len(rounds) {
+			rounds = append(rounds, make([]*forest.Tree, f.NumGroup))
+		}
+		rounds[count[t.Group]][t.Group] = t
+		count[t.Group]++
+	}
+	zero := &forest.Tree{Nodes: []forest.Node{{Left: -1, Right: -1}}}
+	trees := &rawcuml4go.FILTrees{TreeOffsets: []int32{0}, CategoryOffsets: []int32{0}}
+	for _, round := range rounds {
+		for group, t := range round {
+			if t == nil {
+				if f.AverageTreeOutput {
+					// the tree would change the average
+					return nil, ErrUnsupportedOnDevice
+				}
+				t = zero
+			}
+			metadata.TreeAnnotation.TargetID = append(metadata.TreeAnnotation.TargetID, 0)
+			metadata.TreeAnnotation.ClassID = append(metadata.TreeAnnotation.ClassID, group)
+			w := treeWriter{trees: trees, nodes: t.Nodes, offset: int32(len(trees.Kinds))}
+			if _, err := w.write(0); err != nil {
+				return nil, err
+			}
+			trees.TreeOffsets = append(trees.TreeOffsets, int32(len(trees.Kinds)))
+		}
+	}
+	metadata.TreeAnnotation.NumTree = len(trees.TreeOffsets) - 1
+
+	data, err := json.Marshal(&metadata)
+	if err != nil {
+		return nil, err
+	}
+	trees.Metadata = string(data)
+	return trees, nil
+}
+
+// treeWriter appends the nodes of a tree to FILTrees in preorder.
+type treeWriter struct {
+	trees  *rawcuml4go.FILTrees
+	nodes  []forest.Node
+	offset int32
+}
+
+// write appends node i and its descendants, returning the index of i within the tree.
+func (w *treeWriter) write(i int32) (int32, error) {
+	n := &w.nodes[i]
+	switch {
+	case n.IsLeaf():
+		return w.add(rawcuml4go.FILNodeLeaf, 0, n.Value, false, nil)
+	case n.Categorical:
+		categories, defaultLeft := n.Categories, n.DefaultLeft
+		hasZero := n.ZeroGoesLeft()
+		switch n.Missing {
+		case forest.MissingNone:
+			// NaN is compared as zero
+			defaultLeft = hasZero
+		case forest.MissingZero:
+			// zero goes to the default child
+			if defaultLeft && !hasZero {
+				categories = append([]uint32{0}, categories...)
+			} else if !defaultLeft && hasZero {
+				categories = categories[1:]
+			}
+		}
+		return w.split(n, rawcuml4go.FILNodeCategorical, 0, defaultLeft, categories)
+	case n.Missing == forest.MissingZero:
+		return w.writeMissingZero(n)
+	case n.Missing == forest.MissingNone:
+		// NaN is compared as zero
+		return w.split(n, comparisonKind(n.Comparison), n.Threshold, n.ZeroGoesLeft(), nil)
+	default:
+		return w.split(n, comparisonKind(n.Comparison), n.Threshold, n.DefaultLeft, nil)
+	}
+}
+
+// writeMissingZero appends the numerical split n of forest.MissingZero.
+// NaN and -forest.ZeroThreshold <= x <= forest.ZeroThreshold go to the default child, and the others are compared.
+// the split is appended as it is if all of those values go to the default child when compared.
+// otherwise guard splits send them to the default child, which copies the children of n,
+// and the comparison is skipped on either side of the guard whose values the threshold already decides.
+func (w *treeWriter) writeMissingZero(n *forest.Node) (int32, error) {
+	// the values of the guard go left (right) when compared if the threshold is above (below) them
+	zeroLeft := n.Threshold > forest.ZeroThreshold || n.Comparison == forest.LessEqual && n.Threshold == forest.ZeroThreshold
+	zeroRight := n.Threshold < -forest.ZeroThreshold || n.Comparison == forest.LessThan && n.Threshold == -forest.ZeroThreshold
+	if n.DefaultLeft && zeroLeft || !n.DefaultLeft && zeroRight {
+		return w.split(n, comparisonKind(n.Comparison), n.Threshold, n.DefaultLeft, nil)
+	}
+
+	// x <= forest.ZeroThreshold, whose NaN goes to the guard
+	self, err := w.add(rawcuml4go.FILNodeLessEqual, n.Feature, forest.ZeroThreshold, true, nil)
+	if err != nil {
+		return 0, err
+	}
+	// x < -forest.ZeroThreshold, whose NaN goes to the default child
+	guard, err := w.add(rawcuml4go.FILNodeLessThan, n.Feature, -forest.ZeroThreshold, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	// the negative values all go left, and the positive values all go right, unless the threshold is beyond them
+	var negative int32
+	if n.Threshold >= -forest.ZeroThreshold {
+		negative, err = w.write(n.Left)
+	} else {
+		negative, err = w.compare(n)
+	}
+	if err != nil {
+		return 0, err
+	}
+	zero, err := w.write(n.DefaultChild())
+	if err != nil {
+		return 0, err
+	}
+	w.set(guard, negative, zero)
+	var positive int32
+	if n.Threshold <= forest.ZeroThreshold {
+		positive, err = w.write(n.Right)
+	} else {
+		positive, err = w.compare(n)
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.set(self, guard, positive)
+	return self, nil
+}
+
+// compare appends the numerical split of n without missing values.
+func (w *treeWriter) compare(n *forest.Node) (int32, error) {
+	return w.split(n, comparisonKind(n.Comparison), n.Threshold, false, nil)
+}
+
+// split appends a split of the children of n.
+func (w *treeWriter) split(n *forest.Node, kind rawcuml4go.FILNodeKind, value float64, defaultLeft bool, categories []uint32) (int32, error) {
+	self, err := w.add(kind, n.Feature, value, defaultLeft, categories)
+	if err != nil {
+		return 0, err
+	}
+	left, err := w.write(n.Left)
+	if err != nil {
+		return 0, err
+	}
+	right, err := w.write(n.Right)
+	if err != nil {
+		return 0, err
+	}
+	w.set(self, left, right)
+	return self, nil
+}
+
+func (w *treeWriter) add(kind rawcuml4go.FILNodeKind, feature int32, value float64, defaultLeft bool, categories []uint32) (int32, error) {
+	t := w.trees
+	if len(t.Kinds) >= maxBuilderNodes {
+		return 0, ErrUnsupportedOnDevice
+	}
+	t.Kinds = append(t.Kinds, kind)
+	t.LeftChildren = append(t.LeftChildren, -1)
+	t.RightChildren = append(t.RightChildren, -1)
+	t.Features = append(t.Features, feature)
+	t.Values = append(t.Values, value)
+	t.DefaultLeft = append(t.DefaultLeft, defaultLeft)
+	t.Categories = append(t.Categories, categories...)
+	t.CategoryOffsets = append(t.CategoryOffsets, int32(len(t.Categories)))
+	return int32(len(t.Kinds)-1) - w.offset, nil
+}
+
+func (w *treeWriter) set(i int32, left int32, right int32) {
+	w.trees.LeftChildren[w.offset+i] = left
+	w.trees.RightChildren[w.offset+i] = right
+}
+
+func comparisonKind(c forest.Comparison) rawcuml4go.FILNodeKind {
+	if c == forest.LessEqual {
+		return rawcuml4go.FILNodeLessEqual
+	}
+	return rawcuml4go.FILNodeLessThan
+}
@@ -0,0 +1,200 @@
+package cuml4go_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+// evaluateFILTrees returns the margins of rows of x by the trees, as the model builder of treelite defines them.
+func evaluateFILTrees(t *testing.T, trees *rawcuml4go.FILTrees, x []float32, numRow int, numClass int) []float64 {
+	var metadata struct {
+		TreeAnnotation struct {
+			ClassID []int `json:"class_id"`
+		} `json:"tree_annotation"`
+		BaseScores []float64 `json:"base_scores"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(trees.Metadata), &metadata))
+	numFeature := len(x) / numRow
+
+	margins := make([]float64, numRow*numClass)
+	for r := 0; r < numRow; r++ {
+		row := x[r*numFeature : (r+1)*numFeature]
+		copy(margins[r*numClass:], metadata.BaseScores)
+		for tree, class := range metadata.TreeAnnotation.ClassID {
+			offset := trees.TreeOffsets[tree]
+			i := offset
+			for trees.Kinds[i] != rawcuml4go.FILNodeLeaf {
+				v := row[trees.Features[i]]
+				var left bool
+				switch {
+				case math.IsNaN(float64(v)):
+					left = trees.DefaultLeft[i]
+				case trees.Kinds[i] == rawcuml4go.FILNodeLessThan:
+					left = float64(v) < trees.Values[i]
+				case trees.Kinds[i] == rawcuml4go.FILNodeLessEqual:
+					left = float64(v) <= trees.Values[i]
+				default:
+					for _, c := range trees.Categories[trees.CategoryOffsets[i]:trees.CategoryOffsets[i+1]] {
+						left = left || v >= 0 && uint32(v) == c
+					}
+				}
+				if left {
+					i = offset + trees.LeftChildren[i]
+				} else {
+					i = offset + trees.RightChildren[i]
+				}
+			}
+			margins[r*numClass+class] += trees.Values[i]
+		}
+	}
+	return margins
+}
+
+func TestFILTrees(t *testing.T) {
+	leaf := func(v float64) forest.Node {
+		return forest.Node{Left: -1, Right: -1, Value: v}
+	}
+	f := &forest.Forest{
+		Trees: []forest.Tree{
+			{Group: 0, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 0, Threshold: 0.5, Missing: forest.MissingZero, DefaultLeft: true},
+				leaf(1),
+				{Left: 3, Right: 4, Feature: 1, Threshold: -1, Comparison: forest.LessEqual, Missing: forest.MissingZero},
+				leaf(2),
+				leaf(4),
+			}},
+			{Group: 1, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 2, Categorical: true, Categories: []uint32{0, 2}, Missing: forest.MissingZero},
+				leaf(8),
+				{Left: 3, Right: 4, Feature: 0, Threshold: 0, Comparison: forest.LessEqual, Missing: forest.MissingNone},
+				leaf(16),
+				leaf(32),
+			}},
+			// the second tree of class 0 has no tree of class 1
+			{Group: 0, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 2, Categorical: true, Categories: []uint32{1}, Missing: forest.MissingNone},
+				leaf(64),
+				{Left: 3, Right: 4, Feature: 1, Threshold: 2, DefaultLeft: true},
+				leaf(128),
+				leaf(256),
+			}},
+		},
+		NumFeature:    3,
+		NumGroup:      2,
+		BaseScore:     []float64{0.5, -0.5},
+		PostTransform: forest.Softmax,
+		SigmoidAlpha:  1,
+	}
+	values := []float32{float32(math.NaN()), 0, 1e-36, -1e-36, -1, 0.5, 1, 2, 3}
+	var x []float32
+	for _, a := range values {
+		for _, b := range values {
+			for _, c := range []float32{float32(math.NaN()), 0, 1, 2, 3} {
+				x = append(x, a, b, c)
+			}
+		}
+	}
+	numRow := len(x) / f.NumFeature
+	expected := make([]float32, numRow*f.NumGroup)
+	require.NoError(t, f.PredictMargin(expected, x, numRow))
+
+	trees, err := cuml4go.FILTrees(f)
+	require.NoError(t, err)
+	// a tree of a leaf of zero is added for class 1
+	require.Len(t, trees.TreeOffsets, 5)
+	actual := evaluateFILTrees(t, trees, x, numRow, f.NumGroup)
+	require.InDeltaSlice(t, expected, actual, 1e-6)
+
+	f.AverageTreeOutput = true
+	_, err = cuml4go.FILTrees(f)
+	require.ErrorIs(t, err, cuml4go.ErrUnsupportedOnDevice)
+	f.AverageTreeOutput = false
+	f.PostTransform = forest.MaxIndex
+	_, err = cuml4go.FILTrees(f)
+	require.ErrorIs(t, err, cuml4go.ErrUnsupportedOnDevice)
+}
+
+func TestFILTreesDeepMissingZero(t *testing.T) {
+	// a chain of depth 64 whose zero goes to the default child when compared needs no guard
+	var nodes []forest.Node
+	depth := 64
+	for d := 0; d < depth; d++ {
+		i := int32(len(nodes))
+		threshold, defaultLeft := float64(d+1), true
+		if d%2 == 1 {
+			threshold, defaultLeft = -float64(d+1), false
+		}
+		nodes = append(nodes,
+			forest.Node{Left: i + 1, Right: i + 2, Feature: int32(d % 2), Threshold: threshold, Missing: forest.MissingZero, DefaultLeft: defaultLeft},
+			forest.Node{Left: -1, Right: -1, Value: float64(d)},
+		)
+		if !defaultLeft {
+			nodes[i].Left, nodes[i].Right = i+2, i+1
+		}
+	}
+	nodes = append(nodes, forest.Node{Left: -1, Right: -1, Value: -1})
+	chain := &forest.Forest{
+		Trees:         []forest.Tree{{Nodes: nodes}},
+		NumFeature:    2,
+		NumGroup:      1,
+		BaseScore:     []float64{0},
+		PostTransform: forest.Identity,
+		SigmoidAlpha:  1,
+	}
+
+	// a full tree of depth 8 whose zero goes to the other child half of the time needs guards
+	nodes = nil
+	var grow func(d int) int32
+	grow = func(d int) int32 {
+		i := int32(len(nodes))
+		nodes = append(nodes, forest.Node{Left: -1, Right: -1, Value: float64(i)})
+		if d == 8 {
+			return i
+		}
+		threshold := []float64{-2, -1e-36, 0, 1e-35, 1.5}[int(i)%5]
+		left := grow(d + 1)
+		right := grow(d + 1)
+		nodes[i] = forest.Node{
+			Left: left, Right: right, Feature: int32(d % 2), Threshold: threshold,
+			Comparison: forest.Comparison(d % 2), Missing: forest.MissingZero, DefaultLeft: i%2 == 0,
+		}
+		return i
+	}
+	grow(0)
+	full := &forest.Forest{
+		Trees:         []forest.Tree{{Nodes: nodes}},
+		NumFeature:    2,
+		NumGroup:      1,
+		BaseScore:     []float64{0},
+		PostTransform: forest.Identity,
+		SigmoidAlpha:  1,
+	}
+
+	values := []float32{float32(math.NaN()), 0, 1e-36, -1e-36, -3, -1, 0.5, 1, 2, 40, -40}
+	var x []float32
+	for _, a := range values {
+		for _, b := range values {
+			x = append(x, a, b)
+		}
+	}
+	numRow := len(x) / 2
+	for _, f := range []*forest.Forest{chain, full} {
+		expected := make([]float32, numRow)
+		require.NoError(t, f.PredictMargin(expected, x, numRow))
+		trees, err := cuml4go.FILTrees(f)
+		require.NoError(t, err)
+		actual := evaluateFILTrees(t, trees, x, numRow, 1)
+		require.InDeltaSlice(t, expected, actual, 1e-6)
+	}
+
+	trees, err := cuml4go.FILTrees(chain)
+	require.NoError(t, err)
+	require.Len(t, trees.Kinds, len(chain.Trees[0].Nodes))
+}
@@ -0,0 +1,197 @@
+package cuml4go_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+var errHandleShared = errors.New("device resource is used by two calls at once")
+
+// fakeForest predicts the sum s of the features of every row, or [1-s, s] for class probabilities,
+// and fails if a handle is shared or the forest is used after it is freed.
+// calls with more than oomRows rows fail with ErrOutOfMemory if oomRows is positive.
+type fakeForest struct {
+	numFeatures int
+	oomRows     int
+
+	mu     sync.Mutex
+	inUse  map[*rawcuml4go.DeviceResource]bool
+	freed  bool
+	misuse []error
+	calls  []int
+}
+
+func newFakeForest(numFeatures int) *fakeForest {
+	return &fakeForest{
+		numFeatures: numFeatures,
+		inUse:       make(map[*rawcuml4go.DeviceResource]bool),
+	}
+}
+
+func (f *fakeForest) enter(deviceResource *rawcuml4go.DeviceResource) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.inUse[deviceResource] {
+		f.misuse = append(f.misuse, errHandleShared)
+	}
+	if f.freed {
+		f.misuse = append(f.misuse, cuml4go.ErrFILModelClosed)
+	}
+	f.inUse[deviceResource] = true
+}
+
+func (f *fakeForest) leave(deviceResource *rawcuml4go.DeviceResource) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inUse[deviceResource] = false
+}
+
+func (f *fakeForest) PredictOn(
+	deviceResource *rawcuml4go.DeviceResource,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	preds []float32,
+) ([]float32, error) {
+	f.enter(deviceResource)
+	defer f.leave(deviceResource)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, numRow)
+	f.mu.Unlock()
+
+	if f.oomRows > 0 && numRow > f.oomRows {
+		return nil, rawcuml4go.ErrOutOfMemory
+	}
+
+	if preds == nil {
+		preds = make([]float32, numRow)
+	}
+	for r := 0; r < numRow; r++ {
+		var sum float32
+		for _, v := range x[r*f.numFeatures : (r+1)*f.numFeatures] {
+			sum += v
+		}
+		if outputClassProbability {
+			preds[2*r] = 1 - sum
+			preds[2*r+1] = sum
+		} else {
+			preds[r] = sum
+		}
+	}
+	// widen the window in which a shared handle would be observed
+	time.Sleep(50 * time.Microsecond)
+	return preds, nil
+}
+
+func (f *fakeForest) CloseOn(deviceResource *rawcuml4go.DeviceResource) error {
+	f.enter(deviceResource)
+	defer f.leave(deviceResource)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.freed = true
+	return nil
+}
+
+func (f *fakeForest) NumFeatures() (int, error) {
+	return f.numFeatures, nil
+}
+
+func (f *fakeForest) errors() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.misuse
+}
+
+func TestFILModelConcurrentPredict(t *testing.T) {
+	for _, size := range []int{1, 4} {
+		resources, err := cuml4go.NewFakeResources(size)
+		require.NoError(t, err)
+
+		forest := newFakeForest(3)
+		target, err := cuml4go.NewFILModelFromForest(forest, resources)
+		require.NoError(t, err)
+		require.Equal(t, 3, target.NumFeatures())
+
+		// the goroutines record their failures, which are asserted on the test goroutine
+		var wg sync.WaitGroup
+		errs := make([]error, 16)
+		for g := range errs {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					v := float32(g*100 + i)
+					x := []float32{v, v, v, 1, 2, 3}
+
+					preds, err := target.Predict(x, 2, false)
+					if err != nil {
+						errs[g] = err
+						return
+					}
+					if expected := []float32{3 * v, 6}; !slices.Equal(expected, preds) {
+						errs[g] = fmt.Errorf("predicted %v, expected %v", preds, expected)
+						return
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, target.Close())
+		require.NoError(t, resources.Close())
+		require.Empty(t, forest.errors())
+	}
+}
+
+func TestFILModelCloseDuringPredict(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(2)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	forest := newFakeForest(1)
+	target, err := cuml4go.NewFILModelFromForest(forest, resources)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for g := range errs {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := target.Predict([]float32{1}, 1, false); err != nil {
+					errs[g] = err
+					return
+				}
+			}
+		}(g)
+	}
+
+	time.Sleep(time.Millisecond)
+	require.NoError(t, target.Close())
+	require.NoError(t, target.Close())
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			require.ErrorIs(t, err, cuml4go.ErrFILModelClosed)
+		}
+	}
+
+	_, err = target.Predict([]float32{1}, 1, false)
+	require.ErrorIs(t, err, cuml4go.ErrFILModelClosed)
+	require.Empty(t, forest.errors())
+}
@@ -0,0 +1,157 @@
+package cuml4go
+
+import (
+	"context"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+	"go.uber.org/multierr"
+)
+
+// cpuForest evaluates a parsed forest on the host with the output layout of FIL.
+type cpuForest struct {
+	forest         *forest.Forest
+	classification bool
+	threshold      float32
+}
+
+// newCPUFILModel parses data and wraps it in a FILModel evaluated on the host.
+func newCPUFILModel(
+	cfg *config,
+	modelType FILModelType,
+	data []byte,
+	classification bool,
+	threshold float32,
+) (*FILModel, error) {
+	parsed, err := parseForest(modelType, data)
+	if err != nil {
+		return nil, multierr.Append(ErrFILModelLoad, err)
+	}
+	if parsed, err = cfg.slice(parsed); err != nil {
+		return nil, err
+	}
+
+	resources, owned, err := cfg.hostResources()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newFILModel(&cpuForest{
+		forest:         parsed,
+		classification: classification,
+		threshold:      threshold,
+	}, resources, owned, cfg.chunking)
+	if err != nil {
+		return nil, err
+	}
+	m.forest = parsed
+	m.threshold = threshold
+
+	names := parsed.FeatureNames
+	if cfg.featureNames != nil {
+		names = cfg.featureNames
+	}
+	if err := m.setFeatures(names, parsed.FeatureTypes); err != nil {
+		return nil, multierr.Append(err, m.Close())
+	}
+	return m, nil
+}
+
+// PredictOn writes [1-p, p] for every row if outputClassProbability is true.
+// otherwise it writes the class if classification is true, or the transformed output p.
+// a multi-class model writes the class with the largest probability, and fails with ErrFILModelPredict
+// for the class probabilities of more than 2 classes, which do not fit the 2 outputs per row of Predict.
+func (f *cpuForest) PredictOn(
+	_ *rawcuml4go.DeviceResource,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	preds []float32,
+) ([]float32, error) {
+	if preds == nil {
+		preds = make([]float32, numRow*filOutputWidth(outputClassProbability))
+	}
+
+	width := f.forest.OutputWidth()
+	if width > 1 && outputClassProbability && width != filOutputWidth(true) {
+		return nil, ErrFILModelPredict
+	}
+
+	out := getFloat32Buffer(numRow * width)
+	defer putFloat32Buffer(out)
+
+	if err := f.forest.Predict(*out, x, numRow); err != nil {
+		return nil, err
+	}
+
+	for r := 0; r < numRow; r++ {
+		row := (*out)[r*width : (r+1)*width]
+		switch {
+		case width > 1 && outputClassProbability:
+			copy(preds[r*width:(r+1)*width], row)
+		case width > 1:
+			best := 0
+			for c, p := range row {
+				if p > row[best] {
+					best = c
+				}
+			}
+			preds[r] = float32(best)
+		case outputClassProbability:
+			preds[2*r] = 1 - row[0]
+			preds[2*r+1] = row[0]
+		case f.classification:
+			if row[0] > f.threshold {
+				preds[r] = 1
+			} else {
+				preds[r] = 0
+			}
+		default:
+			preds[r] = row[0]
+		}
+	}
+	return preds, nil
+}
+
+// CloseOn does nothing since the forest is garbage collected.
+func (f *cpuForest) CloseOn(*rawcuml4go.DeviceResource) error {
+	return nil
+}
+
+func (f *cpuForest) NumFeatures() (int, error) {
+	return f.forest.NumFeature, nil
+}
+
+// predictOnHost evaluates the forest parsed on the host in chunks of rows.
+// method returns the number of outputs per row and the evaluation.
+func predictOnHost[T any](
+	ctx context.Context,
+	m *FILModel,
+	x []float32,
+	numRow int,
+	method func(f *forest.Forest) (int, func(dst []T, x []float32, numRow int) error),
+) ([]T, error) {
+	f, err := m.hostForest()
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != numRow*m.numFeatures {
+		return nil, ErrDimensionMismatch
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, ErrFILModelClosed
+	}
+
+	width, predict := method(f)
+	dst := make([]T, numRow*width)
+	err = m.chunking.forEachChunk(ctx, numRow, m.numFeatures*4, contextChunkRows, func(start, end int) error {
+		return predict(dst[start*width:end*width], x[start*m.numFeatures:end*m.numFeatures], end-start)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
@@ -0,0 +1,215 @@
+package cuml4go_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestFILCPUBackend(t *testing.T) {
+	data, err := os.ReadFile("../testdata/xgboost.json")
+	require.NoError(t, err)
+
+	load := map[string]func() (*cuml4go.FILModel, error){
+		"file": func() (*cuml4go.FILModel, error) {
+			return cuml4go.NewFILModel(
+				cuml4go.XGBoostJSON,
+				"../testdata/xgboost.json",
+				cuml4go.AlgoAuto,
+				true,
+				0.5,
+				cuml4go.Auto,
+				0,
+				1,
+				0,
+				cuml4go.WithBackend(cuml4go.CPUBackend))
+		},
+		"bytes": func() (*cuml4go.FILModel, error) {
+			return cuml4go.NewFILModelFromBytes(
+				cuml4go.XGBoostJSON,
+				data,
+				cuml4go.AlgoAuto,
+				true,
+				0.5,
+				cuml4go.Auto,
+				0,
+				1,
+				0,
+				cuml4go.WithBackend(cuml4go.CPUBackend))
+		},
+		"reader": func() (*cuml4go.FILModel, error) {
+			return cuml4go.NewFILModelFromReader(
+				cuml4go.XGBoostJSON,
+				bytes.NewReader(data),
+				cuml4go.AlgoAuto,
+				true,
+				0.5,
+				cuml4go.Auto,
+				0,
+				1,
+				0,
+				cuml4go.WithBackend(cuml4go.CPUBackend))
+		},
+		"ubjson": func() (*cuml4go.FILModel, error) {
+			return cuml4go.NewFILModel(
+				cuml4go.XGBoostUBJSON,
+				"../testdata/xgboost.model",
+				cuml4go.AlgoAuto,
+				true,
+				0.5,
+				cuml4go.Auto,
+				0,
+				1,
+				0,
+				cuml4go.WithBackend(cuml4go.CPUBackend))
+		},
+	}
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	for name, load := range load {
+		t.Run(name, func(t *testing.T) {
+			target, err := load()
+			require.NoError(t, err)
+			defer target.Close()
+
+			require.Equal(t, 30, target.NumFeatures())
+
+			actual, err := target.PredictSingleClassScore(features, nRow)
+			require.NoError(t, err)
+			require.InDeltaSlice(t, expectedScores, actual, 1e-5)
+
+			classes, err := target.Predict(features, nRow, false)
+			require.NoError(t, err)
+			require.Equal(t, nRow, len(classes))
+			for i, class := range classes {
+				if expectedScores[i] > 0.5 {
+					require.Equal(t, float32(1), class)
+				} else {
+					require.Equal(t, float32(0), class)
+				}
+			}
+		})
+	}
+}
+
+// xgboostMultiClassModel is a multi:softprob model of 3 classes,
+// whose class is the value of its single feature for 0, 1 and 2.
+func xgboostMultiClassModel() []byte {
+	tree := func(threshold, left, right string) string {
+		return `{
+			"left_children": [1, -1, -1], "right_children": [2, -1, -1],
+			"split_indices": [0, 0, 0], "split_conditions": [` + threshold + `, ` + left + `, ` + right + `],
+			"default_left": [1, 0, 0], "sum_hessian": [2, 1, 1]
+		}`
+	}
+	return []byte(`{"learner": {
+		"learner_model_param": {"base_score": "5E-1", "num_class": "3", "num_feature": "1"},
+		"objective": {"name": "multi:softprob"},
+		"gradient_booster": {"name": "gbtree", "model": {
+			"trees": [` + tree("0.5", "1", "-1") + `, ` + tree("0.5", "-1", "0") + `, ` + tree("1.5", "-1", "1") + `],
+			"tree_info": [0, 1, 2]
+		}}
+	}}`)
+}
+
+func TestFILCPUBackendMultiClass(t *testing.T) {
+	target, err := cuml4go.NewFILModelFromBytes(
+		cuml4go.XGBoostJSON,
+		xgboostMultiClassModel(),
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := []float32{0, 1, 2}
+	classes, err := target.Predict(x, 3, false)
+	require.NoError(t, err)
+	require.Equal(t, []float32{0, 1, 2}, classes)
+
+	// the probabilities of 3 classes do not fit the 2 outputs per row of Predict
+	_, err = target.Predict(x, 3, true)
+	require.ErrorIs(t, err, cuml4go.ErrFILModelPredict)
+}
+
+func TestFILCPUBackendInvalidModel(t *testing.T) {
+	_, err := cuml4go.NewFILModelFromBytes(
+		cuml4go.XGBoostJSON,
+		[]byte("{}"),
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.ErrorIs(t, err, cuml4go.ErrFILModelLoad)
+}
+
+func TestFILFromBytes(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	data, err := os.ReadFile("../testdata/xgboost.json")
+	require.NoError(t, err)
+
+	target, err := cuml4go.NewFILModelFromBytes(
+		cuml4go.XGBoostJSON,
+		data,
+		cuml4go.AlgoAuto,
+		true,
+		0.0,
+		cuml4go.Auto,
+		0,
+		1,
+		0)
+	require.NoError(t, err)
+	defer target.Close()
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	actual, err := target.PredictSingleClassScore(features, nRow)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, expectedScores, actual, 1e-4)
+}
+
+func TestParseFILModelType(t *testing.T) {
+	for name, expected := range map[string]cuml4go.FILModelType{
+		"xgboost":        cuml4go.XGBoost,
+		"xgboost_json":   cuml4go.XGBoostJSON,
+		"xgboost_ubjson": cuml4go.XGBoostUBJSON,
+		"lightgbm":       cuml4go.LightGBM,
+		"onnx":           cuml4go.ONNX,
+	} {
+		actual, err := cuml4go.ParseFILModelType(name)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, name)
+	}
+	_, err := cuml4go.ParseFILModelType("catboost")
+	require.Error(t, err)
+
+	backend, err := cuml4go.ParseBackend("cpu")
+	require.NoError(t, err)
+	require.Equal(t, cuml4go.CPUBackend, backend)
+	backend, err = cuml4go.ParseBackend("gpu")
+	require.NoError(t, err)
+	require.Equal(t, cuml4go.GPUBackend, backend)
+	backend, err = cuml4go.ParseBackend("auto")
+	require.NoError(t, err)
+	require.Equal(t, cuml4go.AutoBackend(), backend)
+	_, err = cuml4go.ParseBackend("tpu")
+	require.Error(t, err)
+}
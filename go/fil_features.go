@@ -0,0 +1,187 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+)
+
+var (
+	// ErrNoFeatureNames is returned by the name-based predictions of a model without feature names.
+	ErrNoFeatureNames = errors.New("model has no feature names")
+	// ErrUnknownFeature is returned when an input has a feature the model does not have.
+	ErrUnknownFeature = errors.New("unknown feature")
+	// ErrInvalidFeatureNames is returned when the feature names do not match the features of the model,
+	// or have duplicates.
+	ErrInvalidFeatureNames = errors.New("invalid feature names")
+)
+
+// loadFeatures sets the feature names and types stored in a model loaded on the device on the first call.
+// they are best-effort since the native library may load a model the Go parser does not support:
+// the error of the parse is logged, and the model has no stored feature names.
+// names given by WithFeatureNames override the stored names.
+func (m *FILModel) loadFeatures() {
+	m.featuresOnce.Do(func() {
+		if m.parseFeatures == nil {
+			return
+		}
+		names, types, err := m.parseFeatures()
+		m.parseFeatures = nil
+		if err != nil {
+			log.Printf("cuml4go: the feature names of the model are not parsed: %v", err)
+			return
+		}
+		if m.featureNames != nil {
+			names = m.featureNames
+		}
+		if err := m.setFeatures(names, types); err != nil {
+			log.Printf("cuml4go: the feature names of the model are not used: %v", err)
+		}
+	})
+}
+
+// setFeatures sets the feature names and types. names may be nil,
+// and types are dropped unless there is one for every name.
+func (m *FILModel) setFeatures(names []string, types []string) error {
+	if names == nil {
+		return nil
+	}
+	if len(names) != m.numFeatures {
+		return ErrInvalidFeatureNames
+	}
+
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		if _, ok := index[name]; ok {
+			return ErrInvalidFeatureNames
+		}
+		index[name] = i
+	}
+	if len(types) != len(names) {
+		types = nil
+	}
+
+	m.featureNames = append([]string(nil), names...)
+	m.featureTypes = append([]string(nil), types...)
+	m.featureIndex = index
+	return nil
+}
+
+// FeatureNames returns the names of the features in the order of the columns of Predict,
+// or nil if neither the model nor WithFeatureNames gives them.
+func (m *FILModel) FeatureNames() []string {
+	m.loadFeatures()
+	if m.featureNames == nil {
+		return nil
+	}
+	return append([]string(nil), m.featureNames...)
+}
+
+// FeatureTypes returns the types of the features stored in the model, e.g. float, int or c (categorical)
+// for XGBoost, or nil if the model does not store them.
+func (m *FILModel) FeatureTypes() []string {
+	m.loadFeatures()
+	if len(m.featureTypes) == 0 {
+		return nil
+	}
+	return append([]string(nil), m.featureTypes...)
+}
+
+// PredictRecords predicts rows whose features are given by name.
+// a feature absent from a record is NaN, which the model treats as a missing value.
+// it returns ErrNoFeatureNames if the model has no feature names,
+// and ErrUnknownFeature if a record has a feature the model does not have.
+// the layout of the result is the same as Predict.
+func (m *FILModel) PredictRecords(records []map[string]float32, outputClassProbability bool) ([]float32, error) {
+	return m.PredictRecordsContext(context.Background(), records, outputClassProbability)
+}
+
+// PredictRecordsContext is the context-aware variant of PredictRecords.
+func (m *FILModel) PredictRecordsContext(
+	ctx context.Context,
+	records []map[string]float32,
+	outputClassProbability bool,
+) ([]float32, error) {
+	return m.predictNamed(ctx, len(records), outputClassProbability, func(x []float32) error {
+		for r, record := range records {
+			row := x[r*m.numFeatures : (r+1)*m.numFeatures]
+			for name, value := range record {
+				i, ok := m.featureIndex[name]
+				if !ok {
+					return fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+				}
+				row[i] = value
+			}
+		}
+		return nil
+	})
+}
+
+// PredictColumns predicts numRow rows whose features are given by name as columns of numRow values.
+// a feature absent from columns is NaN, which the model treats as a missing value.
+// it returns ErrNoFeatureNames if the model has no feature names,
+// ErrUnknownFeature if columns has a feature the model does not have,
+// and ErrDimensionMismatch if a column does not have numRow values.
+// the layout of the result is the same as Predict.
+func (m *FILModel) PredictColumns(
+	columns map[string][]float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	return m.PredictColumnsContext(context.Background(), columns, numRow, outputClassProbability)
+}
+
+// PredictColumnsContext is the context-aware variant of PredictColumns.
+func (m *FILModel) PredictColumnsContext(
+	ctx context.Context,
+	columns map[string][]float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	return m.predictNamed(ctx, numRow, outputClassProbability, func(x []float32) error {
+		for name, column := range columns {
+			i, ok := m.featureIndex[name]
+			if !ok {
+				return fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+			}
+			if len(column) != numRow {
+				return ErrDimensionMismatch
+			}
+			for r, value := range column {
+				x[r*m.numFeatures+i] = value
+			}
+		}
+		return nil
+	})
+}
+
+// predictNamed predicts numRow rows which fill writes into a dense buffer of NaN.
+func (m *FILModel) predictNamed(
+	ctx context.Context,
+	numRow int,
+	outputClassProbability bool,
+	fill func(x []float32) error,
+) ([]float32, error) {
+	m.loadFeatures()
+	if m.featureIndex == nil {
+		return nil, ErrNoFeatureNames
+	}
+	if numRow < 0 {
+		return nil, ErrDimensionMismatch
+	}
+
+	buf := getFloat32Buffer(numRow * m.numFeatures)
+	defer putFloat32Buffer(buf)
+	x := *buf
+	nan := float32(math.NaN())
+	for i := range x {
+		x[i] = nan
+	}
+	if err := fill(x); err != nil {
+		return nil, err
+	}
+
+	return m.PredictContext(ctx, x, numRow, outputClassProbability)
+}
@@ -0,0 +1,168 @@
+package cuml4go_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func newCPUXGBoostModel(t *testing.T, opts ...cuml4go.Option) *cuml4go.FILModel {
+	t.Helper()
+	model, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		append([]cuml4go.Option{cuml4go.WithBackend(cuml4go.CPUBackend)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, model.Close()) })
+	return model
+}
+
+func TestFILFeatureNames(t *testing.T) {
+	target := newCPUXGBoostModel(t)
+
+	names := target.FeatureNames()
+	require.Len(t, names, 30)
+	require.Equal(t, "mean radius", names[0])
+	require.Equal(t, "worst fractal dimension", names[29])
+	require.Equal(t, "float", target.FeatureTypes()[0])
+
+	// the result is a copy
+	names[0] = "changed"
+	require.Equal(t, "mean radius", target.FeatureNames()[0])
+}
+
+func TestFILPredictRecords(t *testing.T) {
+	target := newCPUXGBoostModel(t)
+	names := target.FeatureNames()
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expected, err := target.Predict(features, nRow, true)
+	require.NoError(t, err)
+
+	records := make([]map[string]float32, nRow)
+	columns := make(map[string][]float32, len(names))
+	for r := range records {
+		records[r] = make(map[string]float32, len(names))
+		for c, name := range names {
+			records[r][name] = features[r*len(names)+c]
+			columns[name] = append(columns[name], features[r*len(names)+c])
+		}
+	}
+
+	actual, err := target.PredictRecords(records, true)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	actual, err = target.PredictColumns(columns, nRow, true)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	// an absent feature is missing
+	row := append([]float32(nil), features[:30]...)
+	row[3] = float32(math.NaN())
+	expected, err = target.Predict(row, 1, true)
+	require.NoError(t, err)
+	delete(records[0], "mean area")
+	actual, err = target.PredictRecords(records[:1], true)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	records[0]["mean aera"] = 1
+	_, err = target.PredictRecords(records[:1], true)
+	require.ErrorIs(t, err, cuml4go.ErrUnknownFeature)
+
+	_, err = target.PredictColumns(map[string][]float32{}, -1, true)
+	require.ErrorIs(t, err, cuml4go.ErrDimensionMismatch)
+
+	columns["mean radius"] = columns["mean radius"][:10]
+	_, err = target.PredictColumns(columns, nRow, true)
+	require.ErrorIs(t, err, cuml4go.ErrDimensionMismatch)
+}
+
+func TestFILWithFeatureNames(t *testing.T) {
+	names := make([]string, 30)
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	target := newCPUXGBoostModel(t, cuml4go.WithFeatureNames(names))
+	require.Equal(t, names, target.FeatureNames())
+
+	preds, err := target.PredictRecords([]map[string]float32{{"a": 1}}, false)
+	require.NoError(t, err)
+	require.Len(t, preds, 1)
+
+	_, err = cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend),
+		cuml4go.WithFeatureNames(names[:2]))
+	require.ErrorIs(t, err, cuml4go.ErrInvalidFeatureNames)
+}
+
+func TestFILNoFeatureNames(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.Nil(t, target.FeatureNames())
+	_, err = target.PredictRecords([]map[string]float32{{"a": 1}}, false)
+	require.ErrorIs(t, err, cuml4go.ErrNoFeatureNames)
+}
+
+func TestFILLazyFeatureNames(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+	calls := 0
+	target.SetParseFeatures(func() ([]string, []string, error) {
+		calls++
+		return []string{"a", "b"}, []string{"float", "int"}, nil
+	})
+
+	// the names are parsed once on the first use
+	require.Zero(t, calls)
+	preds, err := target.PredictRecords([]map[string]float32{{"a": 0.25, "b": 0.5}}, false)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, []float32{0.75}, preds, 1e-6)
+	require.Equal(t, []string{"a", "b"}, target.FeatureNames())
+	require.Equal(t, []string{"float", "int"}, target.FeatureTypes())
+	require.Equal(t, 1, calls)
+
+	// a model whose names fail to parse has none
+	failed, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer failed.Close()
+	failed.SetParseFeatures(func() ([]string, []string, error) {
+		return nil, nil, errors.New("unsupported model")
+	})
+	require.Nil(t, failed.FeatureNames())
+	_, err = failed.PredictRecords([]map[string]float32{{"a": 1}}, false)
+	require.ErrorIs(t, err, cuml4go.ErrNoFeatureNames)
+}
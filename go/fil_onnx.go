@@ -0,0 +1,28 @@
+package cuml4go
+
+import (
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/onnx"
+)
+
+// ONNX returns the model as a serialized ONNX tree ensemble, within WithIterationRange,
+// whose outputs are those of Predict with probability output. see onnx.Forest for the operators.
+func (m *FILModel) ONNX() ([]byte, error) {
+	f, err := m.hostForest()
+	if err != nil {
+		return nil, err
+	}
+	model, err := onnx.Forest(f)
+	if err != nil {
+		return nil, err
+	}
+	return model.Marshal(), nil
+}
+
+// parseForest parses a model of modelType on the host.
+func parseForest(modelType FILModelType, data []byte) (*forest.Forest, error) {
+	if modelType == ONNX {
+		return onnx.ParseForest(data)
+	}
+	return forest.Parse(forest.Format(modelType), data)
+}
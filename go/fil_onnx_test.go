@@ -0,0 +1,75 @@
+package cuml4go_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/onnx"
+)
+
+func TestFILONNX(t *testing.T) {
+	target := newCPUXGBoostModel(t, cuml4go.WithIterationRange(0, 10))
+	data, err := target.ONNX()
+	require.NoError(t, err)
+
+	m, err := onnx.Unmarshal(data)
+	require.NoError(t, err)
+	require.Len(t, m.Graph.Nodes, 1)
+	node := m.Graph.Nodes[0]
+	require.Equal(t, "TreeEnsembleClassifier", node.OpType)
+	require.Equal(t, onnx.MLDomain, node.Domain)
+
+	trees := make(map[int64]bool)
+	for _, id := range node.Attribute("nodes_treeids").Ints {
+		trees[id] = true
+	}
+	require.Len(t, trees, target.NumTrees())
+	require.Equal(t, int64(target.NumFeatures()), m.Graph.Inputs[0].Shape[1].Value)
+}
+
+func TestFILFromONNX(t *testing.T) {
+	expected := newCPUXGBoostModel(t)
+	data, err := expected.ONNX()
+	require.NoError(t, err)
+
+	target, err := cuml4go.NewFILModelFromBytes(
+		cuml4go.ONNX,
+		data,
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, target.Close()) }()
+	require.Equal(t, expected.NumFeatures(), target.NumFeatures())
+	require.Equal(t, expected.NumTrees(), target.NumTrees())
+
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	numRow := len(features) / expected.NumFeatures()
+	for _, probability := range []bool{false, true} {
+		want, err := expected.Predict(features, numRow, probability)
+		require.NoError(t, err)
+		got, err := target.Predict(features, numRow, probability)
+		require.NoError(t, err)
+		require.InDeltaSlice(t, want, got, 1e-6)
+	}
+
+	_, err = cuml4go.NewFILModelFromBytes(
+		cuml4go.ONNX,
+		[]byte("not onnx"),
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.ErrorIs(t, err, cuml4go.ErrFILModelLoad)
+}
@@ -0,0 +1,121 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// ErrInvalidOutputMode is returned when the output mode is unknown.
+var ErrInvalidOutputMode = errors.New("invalid output mode")
+
+// OutputMode is what PredictOutput returns for every row.
+type OutputMode int
+
+const (
+	// OutputMargin the raw margin of every output before the post transform,
+	// e.g. the log odds of a binary:logistic model
+	OutputMargin OutputMode = iota
+	// OutputProbability the class probabilities, in the layout of Predict with outputClassProbability
+	OutputProbability
+	// OutputClass the class, which is 1 if the probability of class 1 exceeds the threshold of the model
+	// whether or not the model is loaded with classification, or the class with the largest margin,
+	// which is evaluated on the host, for a multi-class model
+	OutputClass
+)
+
+// Objective returns the training objective stored in the model, e.g. binary:logistic,
+// or an empty string if the model is not parsed on the host or stores none.
+func (m *FILModel) Objective() string {
+	f, err := m.hostForest()
+	if err != nil {
+		return ""
+	}
+	return f.Objective
+}
+
+// PostTransform returns the transformation from the margins to the outputs,
+// or forest.Identity if the model is not parsed on the host.
+func (m *FILModel) PostTransform() forest.PostTransform {
+	f, err := m.hostForest()
+	if err != nil {
+		return forest.Identity
+	}
+	return f.PostTransform
+}
+
+// BaseScore returns the initial margin of every output, i.e. XGBoost's base_score converted to the margin,
+// or nil if the model is not parsed on the host.
+func (m *FILModel) BaseScore() []float64 {
+	f, err := m.hostForest()
+	if err != nil {
+		return nil
+	}
+	return append([]float64(nil), f.BaseScore...)
+}
+
+// PredictOutput returns the prediction result in mode.
+// the result of OutputMargin has len(BaseScore()) margins per row, which are evaluated on the host
+// since FIL outputs only the transformed scores.
+// the result of OutputProbability is the same as Predict with outputClassProbability,
+// and that of OutputClass has a class per row.
+func (m *FILModel) PredictOutput(x []float32, numRow int, mode OutputMode) ([]float32, error) {
+	return m.PredictOutputContext(context.Background(), x, numRow, mode)
+}
+
+// PredictOutputContext is the context-aware variant of PredictOutput.
+func (m *FILModel) PredictOutputContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	mode OutputMode,
+) ([]float32, error) {
+	switch mode {
+	case OutputMargin:
+		return predictOnHost(ctx, m, x, numRow, func(f *forest.Forest) (int, func(dst []float32, x []float32, numRow int) error) {
+			return f.NumGroup, f.PredictMargin
+		})
+	case OutputProbability:
+		return m.PredictContext(ctx, x, numRow, true)
+	case OutputClass:
+		if f, err := m.hostForest(); err == nil && f.NumGroup > 1 {
+			return m.predictMaxMargin(ctx, x, numRow)
+		}
+		probs, err := m.PredictContext(ctx, x, numRow, true)
+		if err != nil {
+			return nil, err
+		}
+		classes := make([]float32, numRow)
+		for r := range classes {
+			if probs[2*r+1] > m.threshold {
+				classes[r] = 1
+			}
+		}
+		return classes, nil
+	default:
+		return nil, ErrInvalidOutputMode
+	}
+}
+
+// predictMaxMargin returns the class with the largest margin of every row of a multi-class model,
+// which is the class with the largest probability since the post transforms are monotonic.
+func (m *FILModel) predictMaxMargin(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	margins, err := m.PredictOutputContext(ctx, x, numRow, OutputMargin)
+	if err != nil {
+		return nil, err
+	}
+	width := len(margins) / max(numRow, 1)
+	classes := make([]float32, numRow)
+	for r := range classes {
+		row := margins[r*width : (r+1)*width]
+		best := 0
+		for c, v := range row {
+			if v > row[best] {
+				best = c
+			}
+		}
+		classes[r] = float32(best)
+	}
+	return classes, nil
+}
@@ -0,0 +1,84 @@
+package cuml4go_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+func TestFILPredictOutput(t *testing.T) {
+	// the model is loaded without classification, so Predict returns the probability
+	target, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		false,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.NoError(t, err)
+	defer target.Close()
+
+	require.Equal(t, "binary:logistic", target.Objective())
+	require.Equal(t, forest.Sigmoid, target.PostTransform())
+	require.Len(t, target.BaseScore(), 1)
+	require.InDelta(t, xgboostBaseMargin, target.BaseScore()[0], 1e-6)
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	margins, err := target.PredictOutput(features, nRow, cuml4go.OutputMargin)
+	require.NoError(t, err)
+	require.Len(t, margins, nRow)
+	probs, err := target.PredictOutput(features, nRow, cuml4go.OutputProbability)
+	require.NoError(t, err)
+	require.Len(t, probs, 2*nRow)
+	classes, err := target.PredictOutput(features, nRow, cuml4go.OutputClass)
+	require.NoError(t, err)
+	require.Len(t, classes, nRow)
+
+	for r := 0; r < nRow; r++ {
+		require.InDelta(t, expectedScores[r], 1/(1+math.Exp(-float64(margins[r]))), 1e-5)
+		require.InDelta(t, expectedScores[r], probs[2*r+1], 1e-5)
+		if expectedScores[r] > 0.5 {
+			require.Equal(t, float32(1), classes[r])
+		} else {
+			require.Equal(t, float32(0), classes[r])
+		}
+	}
+
+	_, err = target.PredictOutput(features, nRow, cuml4go.OutputMode(-1))
+	require.ErrorIs(t, err, cuml4go.ErrInvalidOutputMode)
+}
+
+func TestFILPredictOutputMultiClass(t *testing.T) {
+	target, err := cuml4go.NewFILModelFromBytes(
+		cuml4go.XGBoostJSON,
+		xgboostMultiClassModel(),
+		cuml4go.AlgoAuto,
+		false,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := []float32{0, 1, 2, 1.2}
+	margins, err := target.PredictOutput(x, 4, cuml4go.OutputMargin)
+	require.NoError(t, err)
+	require.Len(t, margins, 4*3)
+	classes, err := target.PredictOutput(x, 4, cuml4go.OutputClass)
+	require.NoError(t, err)
+	require.Equal(t, []float32{0, 1, 2, 1}, classes)
+}
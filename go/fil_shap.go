@@ -0,0 +1,74 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"go.uber.org/multierr"
+)
+
+// ErrNoHostForest is returned by the methods evaluated on the host
+// when the model could be loaded by the native library but not parsed in Go, with the error of the parse.
+var ErrNoHostForest = errors.New("model is not parsed on the host")
+
+// hostForest returns the model parsed on the host. a model loaded on the device is parsed on the first call,
+// so that a model used only for the predictions of FIL does not keep its trees in Go.
+func (m *FILModel) hostForest() (*forest.Forest, error) {
+	m.hostOnce.Do(func() {
+		if m.forest != nil {
+			return
+		}
+		if m.parseHost == nil {
+			m.hostErr = ErrNoHostForest
+			return
+		}
+		f, err := m.parseHost()
+		m.parseHost = nil
+		if err != nil {
+			m.hostErr = multierr.Append(ErrNoHostForest, err)
+			return
+		}
+		m.forest = f
+	})
+	return m.forest, m.hostErr
+}
+
+// PredictContributions returns the SHAP values of the features of numRow rows,
+// as XGBoost's pred_contribs.
+// given a row r and output g, result[(r * num_group + g) * (num_features + 1) + i] is
+// the contribution of feature i to the margin, and the last one is the bias;
+// they sum to the raw margin before the sigmoid or softmax.
+// num_group is the number of classes of a multi-class model and 1 otherwise.
+// the values are computed by exact TreeSHAP on the host since FIL does not offer it,
+// so it returns forest.ErrNoCover if the model does not store the cover of its nodes.
+func (m *FILModel) PredictContributions(x []float32, numRow int) ([]float32, error) {
+	return m.PredictContributionsContext(context.Background(), x, numRow)
+}
+
+// PredictContributionsContext is the context-aware variant of PredictContributions.
+// ctx is checked between chunks of rows.
+func (m *FILModel) PredictContributionsContext(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	return predictOnHost(ctx, m, x, numRow, func(f *forest.Forest) (int, func(dst []float32, x []float32, numRow int) error) {
+		return f.NumGroup * (f.NumFeature + 1), f.PredictContributions
+	})
+}
+
+// PredictInteractions returns the SHAP interaction values of the pairs of features of numRow rows,
+// as XGBoost's pred_interactions.
+// given a row r and output g, the (num_features + 1) x (num_features + 1) matrix starting at
+// result[(r * num_group + g) * (num_features + 1) * (num_features + 1)] has the interactions
+// off the diagonal and the main effects on it, with the bias last;
+// row i sums to the contribution of feature i of PredictContributions.
+func (m *FILModel) PredictInteractions(x []float32, numRow int) ([]float32, error) {
+	return m.PredictInteractionsContext(context.Background(), x, numRow)
+}
+
+// PredictInteractionsContext is the context-aware variant of PredictInteractions.
+// ctx is checked between chunks of rows.
+func (m *FILModel) PredictInteractionsContext(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	return predictOnHost(ctx, m, x, numRow, func(f *forest.Forest) (int, func(dst []float32, x []float32, numRow int) error) {
+		numCol := f.NumFeature + 1
+		return f.NumGroup * numCol * numCol, f.PredictInteractions
+	})
+}
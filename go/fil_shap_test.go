@@ -0,0 +1,106 @@
+package cuml4go_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+func TestFILPredictContributions(t *testing.T) {
+	target := newCPUXGBoostModel(t)
+
+	nRow := 114
+	numCol := target.NumFeatures() + 1
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	contribs, err := target.PredictContributions(features, nRow)
+	require.NoError(t, err)
+	require.Len(t, contribs, nRow*numCol)
+	for r := 0; r < nRow; r++ {
+		var margin float64
+		for _, c := range contribs[r*numCol : (r+1)*numCol] {
+			margin += float64(c)
+		}
+		require.InDelta(t, expectedScores[r], 1/(1+math.Exp(-margin)), 1e-5)
+	}
+
+	interactions, err := target.PredictInteractions(features[:target.NumFeatures()], 1)
+	require.NoError(t, err)
+	require.Len(t, interactions, numCol*numCol)
+	for i := 0; i < numCol; i++ {
+		var sum float64
+		for _, v := range interactions[i*numCol : (i+1)*numCol] {
+			sum += float64(v)
+		}
+		require.InDelta(t, contribs[i], sum, 1e-4)
+	}
+
+	_, err = target.PredictContributions(features[:10], 1)
+	require.ErrorIs(t, err, cuml4go.ErrDimensionMismatch)
+}
+
+func TestFILPredictContributionsWithoutHostForest(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	_, err = target.PredictContributions(make([]float32, 2), 1)
+	require.ErrorIs(t, err, cuml4go.ErrNoHostForest)
+}
+
+func TestFILParseHostForestLazily(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	parsed := 0
+	target.SetParseHost(func() (*forest.Forest, error) {
+		parsed++
+		return &forest.Forest{
+			Trees:         []forest.Tree{{Nodes: []forest.Node{{Left: -1, Right: -1, Value: 1, Cover: 1}}}},
+			NumFeature:    2,
+			NumGroup:      1,
+			BaseScore:     []float64{0.5},
+			PostTransform: forest.Identity,
+		}, nil
+	})
+	require.Equal(t, 0, parsed)
+
+	contribs, err := target.PredictContributions(make([]float32, 2), 1)
+	require.NoError(t, err)
+	require.Equal(t, []float32{0, 0, 1.5}, contribs)
+	require.Equal(t, 1, target.NumTrees())
+	require.Equal(t, 1, parsed)
+}
+
+func TestFILParseHostForestError(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	target.SetParseHost(func() (*forest.Forest, error) {
+		return nil, forest.ErrInvalidModel
+	})
+
+	_, err = target.PredictContributions(make([]float32, 2), 1)
+	require.ErrorIs(t, err, cuml4go.ErrNoHostForest)
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+	require.Nil(t, target.Forest())
+}
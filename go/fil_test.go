@@ -1,6 +1,7 @@
 package cuml4go_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,9 +10,9 @@ import (
 )
 
 func TestFIL(t *testing.T) {
-
+	skipWithoutNativeLibrary(t)
 	target, err := cuml4go.NewFILModel(
-		cuml4go.XGBoost,
+		cuml4go.XGBoostUBJSON,
 		"../testdata/xgboost.model",
 		cuml4go.AlgoAuto,
 		true,
@@ -39,3 +40,82 @@ func TestFIL(t *testing.T) {
 
 	defer target.Close()
 }
+
+func TestFILPredictCSR(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	target, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.0,
+		cuml4go.Auto,
+		0,
+		1,
+		0)
+	require.NoError(t, err)
+	defer target.Close()
+
+	nRow := 114
+	nCol := 30
+
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	require.Equal(t, nCol, target.NumFeatures())
+
+	// store every entry explicitly since absent entries are treated as missing
+	indPtr := make([]int32, nRow+1)
+	indices := make([]int32, nRow*nCol)
+	for i := 0; i < nRow; i++ {
+		indPtr[i+1] = int32((i + 1) * nCol)
+		for j := 0; j < nCol; j++ {
+			indices[i*nCol+j] = int32(j)
+		}
+	}
+	x, err := cuml4go.NewCSRMatrix(indPtr, indices, features, nCol)
+	require.NoError(t, err)
+
+	actual, err := target.PredictCSR(x, true)
+	require.NoError(t, err)
+	require.Len(t, actual, 2*nRow)
+
+	for i := 0; i < nRow; i++ {
+		require.InDelta(t, expectedScores[i], actual[i*2+1], 1e-4)
+	}
+}
+
+func TestFILPredictContext(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	target, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.0,
+		cuml4go.Auto,
+		0,
+		1,
+		0)
+	require.NoError(t, err)
+	defer target.Close()
+
+	nRow := 114
+
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	actual, err := target.PredictContext(context.Background(), features, nRow, true)
+	require.NoError(t, err)
+	require.Len(t, actual, 2*nRow)
+
+	for i := 0; i < nRow; i++ {
+		require.InDelta(t, expectedScores[i], actual[i*2+1], 1e-4)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = target.PredictContext(ctx, features, nRow, true)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,71 @@
+package cuml4go
+
+import (
+	"context"
+	"os"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"go.uber.org/multierr"
+)
+
+// LoadForest parses a model file of NewFILModel on the host for inspection,
+// e.g. its trees, Summary and FeatureImportance. it does not need a device.
+func LoadForest(modelType FILModelType, filePath string) (*forest.Forest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseForest(modelType, data)
+	if err != nil {
+		return nil, multierr.Append(ErrFILModelLoad, err)
+	}
+	return f, nil
+}
+
+// Forest returns the model parsed on the host, within WithIterationRange,
+// or nil if the Go parser does not support the model. it is shared by the model and must not be modified.
+func (m *FILModel) Forest() *forest.Forest {
+	f, _ := m.hostForest()
+	return f
+}
+
+// NumTrees returns the number of trees of the model, within WithIterationRange,
+// or 0 if the model is not parsed on the host.
+func (m *FILModel) NumTrees() int {
+	f, err := m.hostForest()
+	if err != nil {
+		return 0
+	}
+	return f.NumTrees()
+}
+
+// PredictLeaf returns the leaf every row reaches in every tree, as XGBoost's pred_leaf.
+// given a row r and tree t, result[r * num_trees + t] is the index of the leaf among the nodes of t,
+// which is the node id of XGBoost. the leaves are evaluated on the host since FIL does not expose them.
+func (m *FILModel) PredictLeaf(x []float32, numRow int) ([]int32, error) {
+	return m.PredictLeafContext(context.Background(), x, numRow)
+}
+
+// PredictLeafContext is the context-aware variant of PredictLeaf.
+// ctx is checked between chunks of rows.
+func (m *FILModel) PredictLeafContext(ctx context.Context, x []float32, numRow int) ([]int32, error) {
+	return predictOnHost(ctx, m, x, numRow, func(f *forest.Forest) (int, func(dst []int32, x []float32, numRow int) error) {
+		return f.NumTrees(), f.PredictLeaf
+	})
+}
+
+// PredictPerTree returns the raw output of every tree for every row.
+// given a row r and tree t, result[r * num_trees + t] is the value of the leaf r reaches in t;
+// the margin of a row is the base score plus the sum of the outputs of the trees of each class,
+// or their average for a random forest.
+func (m *FILModel) PredictPerTree(x []float32, numRow int) ([]float32, error) {
+	return m.PredictPerTreeContext(context.Background(), x, numRow)
+}
+
+// PredictPerTreeContext is the context-aware variant of PredictPerTree.
+// ctx is checked between chunks of rows.
+func (m *FILModel) PredictPerTreeContext(ctx context.Context, x []float32, numRow int) ([]float32, error) {
+	return predictOnHost(ctx, m, x, numRow, func(f *forest.Forest) (int, func(dst []float32, x []float32, numRow int) error) {
+		return f.NumTrees(), f.PredictPerTree
+	})
+}
@@ -0,0 +1,126 @@
+package cuml4go_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+// xgboostBaseMargin is the base_score of testdata/xgboost.json converted to the margin.
+var xgboostBaseMargin = math.Log(0.62581056 / (1 - 0.62581056))
+
+func TestFILPredictLeaf(t *testing.T) {
+	target := newCPUXGBoostModel(t)
+	require.Equal(t, 100, target.NumTrees())
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+
+	leaves, err := target.PredictLeaf(features, nRow)
+	require.NoError(t, err)
+	require.Len(t, leaves, nRow*100)
+	for _, leaf := range leaves {
+		require.Positive(t, leaf)
+	}
+
+	perTree, err := target.PredictPerTree(features, nRow)
+	require.NoError(t, err)
+	require.Len(t, perTree, nRow*100)
+	contribs, err := target.PredictContributions(features, nRow)
+	require.NoError(t, err)
+	numCol := target.NumFeatures() + 1
+	for r := 0; r < nRow; r++ {
+		treeSum, contribSum := xgboostBaseMargin, 0.0
+		for _, v := range perTree[r*100 : (r+1)*100] {
+			treeSum += float64(v)
+		}
+		for _, v := range contribs[r*numCol : (r+1)*numCol] {
+			contribSum += float64(v)
+		}
+		require.InDelta(t, contribSum, treeSum, 1e-4)
+	}
+}
+
+func TestFILIterationRange(t *testing.T) {
+	full := newCPUXGBoostModel(t)
+	target := newCPUXGBoostModel(t, cuml4go.WithIterationRange(0, 10))
+	require.Equal(t, 10, target.NumTrees())
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	perTree, err := full.PredictPerTree(features, nRow)
+	require.NoError(t, err)
+	contribs, err := target.PredictContributions(features, nRow)
+	require.NoError(t, err)
+	scores, err := target.PredictSingleClassScore(features, nRow)
+	require.NoError(t, err)
+
+	numCol := target.NumFeatures() + 1
+	for r := 0; r < nRow; r++ {
+		margin, contribSum := xgboostBaseMargin, 0.0
+		for _, v := range perTree[r*100 : r*100+10] {
+			margin += float64(v)
+		}
+		for _, v := range contribs[r*numCol : (r+1)*numCol] {
+			contribSum += float64(v)
+		}
+		require.InDelta(t, margin, contribSum, 1e-4)
+		require.InDelta(t, 1/(1+math.Exp(-margin)), scores[r], 1e-5)
+	}
+
+	_, err = cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend),
+		cuml4go.WithIterationRange(0, 101))
+	require.Error(t, err)
+}
+
+func TestFILIterationRangeOnDevice(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	expected := newCPUXGBoostModel(t, cuml4go.WithIterationRange(0, 10))
+	target, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithIterationRange(0, 10))
+	require.NoError(t, err)
+	defer target.Close()
+	require.Equal(t, 10, target.NumTrees())
+
+	nRow := 114
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores, err := expected.PredictSingleClassScore(features, nRow)
+	require.NoError(t, err)
+	actual, err := target.PredictSingleClassScore(features, nRow)
+	require.NoError(t, err)
+	require.InDeltaSlice(t, expectedScores, actual, 1e-5)
+}
+
+func TestLoadForest(t *testing.T) {
+	f, err := cuml4go.LoadForest(cuml4go.XGBoostJSON, "../testdata/xgboost.json")
+	require.NoError(t, err)
+	require.Equal(t, 100, f.Summary().NumTrees)
+
+	target := newCPUXGBoostModel(t)
+	require.Equal(t, f.Summary(), target.Forest().Summary())
+
+	_, err = cuml4go.LoadForest(cuml4go.LightGBM, "../testdata/xgboost.json")
+	require.ErrorIs(t, err, cuml4go.ErrFILModelLoad)
+}
@@ -0,0 +1,384 @@
+// Package codegen compiles a forest into Go source code without cgo,
+// like tl2cgen compiles it into C.
+//
+// the generated package has no dependencies but the standard library and exports
+//
+//	const NumFeature = ... // the number of features of a row
+//	const NumOutput = ...  // the number of outputs of a row
+//	func Predict(features []float32) []float32
+//	func PredictMargin(features []float32) []float64
+//
+// which evaluate every tree as nested if statements
+// with the same results as forest.Forest.Predict and forest.Forest.PredictMargin.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// ErrInvalidPackage is returned when the package name is not a Go identifier.
+var ErrInvalidPackage = errors.New("invalid package name")
+
+// Config configures the generated code.
+type Config struct {
+	// Package is the name of the generated package.
+	Package string
+	// Quantize replaces the thresholds of the numerical splits by their index among the thresholds of the feature.
+	// a row is quantized once by a binary search per feature,
+	// and the splits compare integers, which makes large forests faster.
+	Quantize bool
+}
+
+// Generate writes the Go source code of f into w.
+func Generate(w io.Writer, f *forest.Forest, config Config) error {
+	if !token.IsIdentifier(config.Package) {
+		return ErrInvalidPackage
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+
+	g := &generator{forest: f, quantize: config.Quantize}
+	if g.quantize {
+		g.thresholds = thresholds(f)
+	}
+	src, err := format.Source(g.source(config.Package))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	forest   *forest.Forest
+	quantize bool
+	// thresholds are the sorted thresholds of the numerical splits of every feature if quantize is true.
+	thresholds [][]float64
+	// categories are the category sets of the categorical splits.
+	categories [][]uint32
+	usesMath   bool
+	usesSort   bool
+}
+
+// thresholds returns the sorted distinct thresholds of the numerical splits of every feature.
+func thresholds(f *forest.Forest) [][]float64 {
+	sets := make([]map[float64]bool, f.NumFeature)
+	for i := range f.Trees {
+		for _, n := range f.Trees[i].Nodes {
+			if n.IsLeaf() || n.Categorical {
+				continue
+			}
+			if sets[n.Feature] == nil {
+				sets[n.Feature] = make(map[float64]bool)
+			}
+			sets[n.Feature][n.Threshold] = true
+		}
+	}
+	result := make([][]float64, f.NumFeature)
+	for i, set := range sets {
+		for t := range set {
+			result[i] = append(result[i], t)
+		}
+		sort.Float64s(result[i])
+	}
+	return result
+}
+
+func (g *generator) source(pkg string) []byte {
+	f := g.forest
+	var trees bytes.Buffer
+	quantized := ""
+	if g.quantize {
+		quantized = ", q *[NumFeature]int32"
+	}
+	for i := range f.Trees {
+		fmt.Fprintf(&trees, "\nfunc tree%d(x []float32%s) float64 {\n", i, quantized)
+		g.node(&trees, &f.Trees[i], 0)
+		trees.WriteString("}\n")
+	}
+
+	var helpers bytes.Buffer
+	if g.quantize {
+		g.usesSort = true
+		helpers.WriteString(quantizeSource)
+		for i, t := range g.thresholds {
+			if len(t) == 0 {
+				continue
+			}
+			fmt.Fprintf(&helpers, "\t%d: {", i)
+			for j, v := range t {
+				if j > 0 {
+					helpers.WriteString(", ")
+				}
+				helpers.WriteString(g.float(v))
+			}
+			helpers.WriteString("},\n")
+		}
+		helpers.WriteString("}\n")
+	}
+	if len(g.categories) > 0 {
+		g.usesMath, g.usesSort = true, true
+		helpers.WriteString(hasCategorySource)
+		for i, set := range g.categories {
+			fmt.Fprintf(&helpers, "\nvar categories%d = []uint32{", i)
+			for j, c := range set {
+				if j > 0 {
+					helpers.WriteString(", ")
+				}
+				helpers.WriteString(strconv.FormatUint(uint64(c), 10))
+			}
+			helpers.WriteString("}\n")
+		}
+	}
+
+	margin := g.margin()
+	transform := g.transform()
+
+	// the imports are known after the rest is generated
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by github.com/getumen/cuml-bindings/go/forest/codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if g.usesMath {
+		buf.WriteString("import \"math\"\n")
+	}
+	if g.usesSort {
+		buf.WriteString("import \"sort\"\n")
+	}
+	fmt.Fprintf(&buf, predictSource, f.NumFeature, f.OutputWidth(), transform, f.NumGroup, margin)
+	buf.Write(helpers.Bytes())
+	buf.Write(trees.Bytes())
+	return buf.Bytes()
+}
+
+const predictSource = `
+// NumFeature is the number of features of a row.
+const NumFeature = %d
+
+// NumOutput is the number of outputs of a row.
+const NumOutput = %d
+
+// Predict returns the outputs of a row of NumFeature features, whose missing values are NaN.
+func Predict(features []float32) []float32 {
+	margin := PredictMargin(features)
+	out := make([]float32, NumOutput)
+%s	return out
+}
+
+// PredictMargin returns the margins of a row before the post transform.
+func PredictMargin(features []float32) []float64 {
+	x := features[:NumFeature]
+	margin := make([]float64, %d)
+%s	return margin
+}
+`
+
+const quantizeSource = `
+// quantize returns the index of every feature among the thresholds of its splits:
+// 2k for the k-th threshold, and 2k-1 for the values between the (k-1)-th and k-th thresholds.
+func quantize(x []float32) *[NumFeature]int32 {
+	var q [NumFeature]int32
+	for i, t := range thresholds {
+		if len(t) == 0 {
+			continue
+		}
+		v := float64(x[i])
+		// a split compares NaN as zero unless it sends NaN to its default child
+		if v != v {
+			v = 0
+		}
+		k := sort.SearchFloat64s(t, v)
+		if k < len(t) && t[k] == v {
+			q[i] = int32(2 * k)
+		} else {
+			q[i] = int32(2*k - 1)
+		}
+	}
+	return &q
+}
+
+var thresholds = [NumFeature][]float64{
+`
+
+const hasCategorySource = `
+// hasCategory returns true if the integer part of v is in categories.
+func hasCategory(v float32, categories []uint32) bool {
+	if !(v >= 0) || v > math.MaxUint32 {
+		return false
+	}
+	c := uint32(v)
+	i := sort.Search(len(categories), func(i int) bool { return categories[i] >= c })
+	return i < len(categories) && categories[i] == c
+}
+`
+
+// margin returns the statements which sum the trees into margin.
+func (g *generator) margin() string {
+	f := g.forest
+	var buf bytes.Buffer
+	args := "x"
+	if g.quantize {
+		buf.WriteString("\tq := quantize(x)\n")
+		args = "x, q"
+	}
+	if len(f.Trees) == 0 {
+		buf.WriteString("\t_ = x\n")
+	}
+	count := make([]int, f.NumGroup)
+	for i, t := range f.Trees {
+		fmt.Fprintf(&buf, "\tmargin[%d] += tree%d(%s)\n", t.Group, i, args)
+		count[t.Group]++
+	}
+	for group, n := range count {
+		if f.AverageTreeOutput && n > 0 {
+			fmt.Fprintf(&buf, "\tmargin[%d] /= %d\n", group, n)
+		}
+		fmt.Fprintf(&buf, "\tmargin[%d] += %s\n", group, g.float(f.BaseScore[group]))
+	}
+	return buf.String()
+}
+
+// transform returns the statements which write the post-transformed margin into out.
+func (g *generator) transform() string {
+	f := g.forest
+	switch f.PostTransform {
+	case forest.Sigmoid:
+		g.usesMath = true
+		return fmt.Sprintf(`	for i, m := range margin {
+		out[i] = float32(1 / (1 + math.Exp(-%s*m)))
+	}
+`, g.float(f.SigmoidAlpha))
+	case forest.Exponential:
+		g.usesMath = true
+		return `	for i, m := range margin {
+		out[i] = float32(math.Exp(m))
+	}
+`
+	case forest.Softmax:
+		g.usesMath = true
+		return `	maxMargin := math.Inf(-1)
+	for _, m := range margin {
+		maxMargin = math.Max(maxMargin, m)
+	}
+	var sum float64
+	for _, m := range margin {
+		sum += math.Exp(m - maxMargin)
+	}
+	for i, m := range margin {
+		out[i] = float32(math.Exp(m-maxMargin) / sum)
+	}
+`
+	case forest.MaxIndex:
+		return `	best := 0
+	for i, m := range margin {
+		if m > margin[best] {
+			best = i
+		}
+	}
+	out[0] = float32(best)
+`
+	case forest.Hinge:
+		return `	for i, m := range margin {
+		if m > 0 {
+			out[i] = 1
+		}
+	}
+`
+	default:
+		return `	for i, m := range margin {
+		out[i] = float32(m)
+	}
+`
+	}
+}
+
+// node writes the statements which return the value of the leaf a row reaches from node i.
+func (g *generator) node(buf *bytes.Buffer, t *forest.Tree, i int32) {
+	n := &t.Nodes[i]
+	if n.IsLeaf() {
+		fmt.Fprintf(buf, "return %s\n", g.float(n.Value))
+		return
+	}
+	fmt.Fprintf(buf, "if %s {\n", g.condition(n))
+	g.node(buf, t, n.Left)
+	buf.WriteString("}\n")
+	g.node(buf, t, n.Right)
+}
+
+// condition returns the expression which is true if a row goes to the left child of n,
+// with the semantics of the missing values of forest.Node.
+func (g *generator) condition(n *forest.Node) string {
+	x := fmt.Sprintf("x[%d]", n.Feature)
+	var test string
+	// nanIsFalse is true if test is false for NaN
+	nanIsFalse := true
+	switch {
+	case n.Categorical:
+		test = fmt.Sprintf("hasCategory(%s, categories%d)", x, len(g.categories))
+		g.categories = append(g.categories, n.Categories)
+	case g.quantize:
+		k := sort.SearchFloat64s(g.thresholds[n.Feature], n.Threshold)
+		op := "<"
+		if n.Comparison == forest.LessEqual {
+			op = "<="
+		}
+		// the quantized NaN is that of zero
+		test = fmt.Sprintf("q[%d] %s %d", n.Feature, op, 2*k)
+		nanIsFalse = false
+	default:
+		op := "<"
+		if n.Comparison == forest.LessEqual {
+			op = "<="
+		}
+		test = fmt.Sprintf("float64(%s) %s %s", x, op, g.float(n.Threshold))
+	}
+
+	switch n.Missing {
+	case forest.MissingNone:
+		// NaN is compared as zero, which the quantized test already does
+		if nanIsFalse && n.ZeroGoesLeft() {
+			return fmt.Sprintf("%s != %s || %s", x, x, test)
+		}
+		return test
+	case forest.MissingZero:
+		g.usesMath = true
+		if n.DefaultLeft {
+			return fmt.Sprintf("%s != %s || math.Abs(float64(%s)) <= %g || %s", x, x, x, forest.ZeroThreshold, test)
+		}
+		return fmt.Sprintf("%s == %s && math.Abs(float64(%s)) > %g && %s", x, x, x, forest.ZeroThreshold, test)
+	default:
+		if n.DefaultLeft {
+			return fmt.Sprintf("%s != %s || %s", x, x, test)
+		}
+		if nanIsFalse {
+			return test
+		}
+		return fmt.Sprintf("%s == %s && %s", x, x, test)
+	}
+}
+
+// float returns v as a Go expression which evaluates to v exactly.
+func (g *generator) float(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		g.usesMath = true
+		return "math.Inf(1)"
+	case math.IsInf(v, -1):
+		g.usesMath = true
+		return "math.Inf(-1)"
+	case v == 0:
+		// a constant is never -0
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,143 @@
+package codegen_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/codegen"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+)
+
+func TestGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the generated code")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command is not found")
+	}
+
+	data, err := os.ReadFile("../../../testdata/xgboost.json")
+	require.NoError(t, err)
+	xgboost, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+	xgboostRows := foresttest.ReadCSV(t, "../../../testdata/feature.csv")
+
+	models := map[string]struct {
+		forest *forest.Forest
+		rows   []float32
+	}{
+		"xgboost":   {xgboost, xgboostRows},
+		"synthetic": {foresttest.Synthetic(), foresttest.SyntheticRows()},
+	}
+
+	// a module of the generated packages and a command which prints their outputs
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module generated\n\ngo 1.23\n"), 0o644))
+	var imports, cases strings.Builder
+	for name, model := range models {
+		for _, quantize := range []bool{false, true} {
+			pkg := name
+			if quantize {
+				pkg += "q"
+			}
+			var src bytes.Buffer
+			require.NoError(t, codegen.Generate(&src, model.forest, codegen.Config{Package: pkg, Quantize: quantize}))
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, pkg), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, pkg, "model.go"), src.Bytes(), 0o644))
+			fmt.Fprintf(&imports, "\t%q\n", "generated/"+pkg)
+			fmt.Fprintf(&cases, "\tcase %q:\n\t\tpredict, numFeature = %s.Predict, %s.NumFeature\n", pkg, pkg, pkg)
+		}
+	}
+	mainSource := fmt.Sprintf(`package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+%s)
+
+func main() {
+	var predict func([]float32) []float32
+	var numFeature int
+	switch os.Args[1] {
+%s	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var row []float32
+		for _, s := range strings.Split(scanner.Text(), ",") {
+			v, _ := strconv.ParseFloat(s, 32)
+			row = append(row, float32(v))
+		}
+		for _, v := range predict(row[:numFeature]) {
+			fmt.Println(v)
+		}
+	}
+}
+`, imports.String(), cases.String())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(mainSource), 0o644))
+
+	binary := filepath.Join(dir, "predict")
+	build := exec.Command(goCommand, "build", "-o", binary, ".")
+	build.Dir = dir
+	output, err := build.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	for name, model := range models {
+		f := model.forest
+		numRow := len(model.rows) / f.NumFeature
+		expected := make([]float32, numRow*f.OutputWidth())
+		require.NoError(t, f.Predict(expected, model.rows, numRow))
+
+		var input strings.Builder
+		for r := 0; r < numRow; r++ {
+			for c, v := range model.rows[r*f.NumFeature : (r+1)*f.NumFeature] {
+				if c > 0 {
+					input.WriteByte(',')
+				}
+				input.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+			}
+			input.WriteByte('\n')
+		}
+
+		for _, pkg := range []string{name, name + "q"} {
+			t.Run(pkg, func(t *testing.T) {
+				cmd := exec.Command(binary, pkg)
+				cmd.Stdin = strings.NewReader(input.String())
+				output, err := cmd.Output()
+				require.NoError(t, err)
+
+				var actual []float32
+				scanner := bufio.NewScanner(bytes.NewReader(output))
+				for scanner.Scan() {
+					v, err := strconv.ParseFloat(scanner.Text(), 32)
+					require.NoError(t, err)
+					actual = append(actual, float32(v))
+				}
+				require.InDeltaSlice(t, expected, actual, 1e-6)
+			})
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	require.ErrorIs(t, codegen.Generate(&buf, foresttest.Synthetic(), codegen.Config{Package: "not a name"}), codegen.ErrInvalidPackage)
+
+	f := foresttest.Synthetic()
+	f.Trees[0].Nodes[0].Left = 0
+	require.ErrorIs(t, codegen.Generate(&buf, f, codegen.Config{Package: "model"}), forest.ErrInvalidModel)
+}
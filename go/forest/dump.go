@@ -0,0 +1,138 @@
+package forest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTree is returned when a tree index is out of the trees of a forest.
+var ErrInvalidTree = errors.New("invalid tree index")
+
+// WriteText writes the trees in the text format of XGBoost's dump_model,
+// e.g. "0:[f2<2.45] yes=1,no=2,missing=1" for a split and "1:leaf=0.43" for a leaf,
+// indented by depth with the nodes numbered by their index in Tree.Nodes.
+// features are named by featureNames, or by FeatureNames if it is nil, or as f0, f1, ... if both are empty.
+// withStats appends the gain of the splits and the cover of the nodes.
+func (f *Forest) WriteText(w io.Writer, featureNames []string, withStats bool) error {
+	bw := bufio.NewWriter(w)
+	names := f.dumpNames(featureNames)
+	for i := range f.Trees {
+		fmt.Fprintf(bw, "booster[%d]:\n", i)
+		t := &f.Trees[i]
+		depths := t.Depths()
+		// children follow their parent, so a stack gives the nodes in the preorder of dump_model
+		stack := []int32{0}
+		for len(stack) > 0 {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			n := &t.Nodes[j]
+			bw.WriteString(strings.Repeat("\t", depths[j]))
+			if n.IsLeaf() {
+				fmt.Fprintf(bw, "%d:leaf=%s", j, formatFloat(n.Value))
+				if withStats {
+					fmt.Fprintf(bw, ",cover=%s", formatFloat(n.Cover))
+				}
+				bw.WriteByte('\n')
+				continue
+			}
+
+			fmt.Fprintf(bw, "%d:[%s] yes=%d,no=%d", j, splitCondition(n, names), n.Left, n.Right)
+			if n.Missing != MissingNone {
+				fmt.Fprintf(bw, ",missing=%d", n.DefaultChild())
+			}
+			if withStats {
+				fmt.Fprintf(bw, ",gain=%s,cover=%s", formatFloat(n.Gain), formatFloat(n.Cover))
+			}
+			bw.WriteByte('\n')
+			stack = append(stack, n.Right, n.Left)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteDOT writes a tree as a Graphviz digraph, with the splits as boxes and the leaves as ellipses.
+// the edge of the left child is labeled yes and that of the right child no,
+// and the edge of the default child is also labeled missing.
+// features are named as by WriteText.
+func (f *Forest) WriteDOT(w io.Writer, tree int, featureNames []string) error {
+	if tree < 0 || tree >= len(f.Trees) {
+		return ErrInvalidTree
+	}
+	bw := bufio.NewWriter(w)
+	names := f.dumpNames(featureNames)
+	t := &f.Trees[tree]
+
+	fmt.Fprintf(bw, "digraph tree%d {\n", tree)
+	bw.WriteString("\tgraph [rankdir=TB];\n")
+	for j := range t.Nodes {
+		n := &t.Nodes[j]
+		if n.IsLeaf() {
+			fmt.Fprintf(bw, "\t%d [label=%s, shape=ellipse];\n", j, dotQuote("leaf="+formatFloat(n.Value)))
+			continue
+		}
+		fmt.Fprintf(bw, "\t%d [label=%s, shape=box];\n", j, dotQuote(splitCondition(n, names)))
+		yes, no := "yes", "no"
+		if n.Missing != MissingNone {
+			if n.DefaultLeft {
+				yes += ", missing"
+			} else {
+				no += ", missing"
+			}
+		}
+		fmt.Fprintf(bw, "\t%d -> %d [label=%s, color=\"#0000FF\"];\n", j, n.Left, dotQuote(yes))
+		fmt.Fprintf(bw, "\t%d -> %d [label=%s, color=\"#FF0000\"];\n", j, n.Right, dotQuote(no))
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// dumpNames returns the names of the features in a dump.
+func (f *Forest) dumpNames(featureNames []string) []string {
+	if featureNames == nil {
+		featureNames = f.FeatureNames
+	}
+	if len(featureNames) == f.NumFeature {
+		return featureNames
+	}
+	names := make([]string, f.NumFeature)
+	for i := range names {
+		names[i] = "f" + strconv.Itoa(i)
+	}
+	return names
+}
+
+// splitCondition returns the condition of the left child of a split,
+// e.g. f2<2.45, or f0:{1,3} for a categorical split.
+func splitCondition(n *Node, names []string) string {
+	name := names[n.Feature]
+	if n.Categorical {
+		categories := make([]string, len(n.Categories))
+		for i, c := range n.Categories {
+			categories[i] = strconv.FormatUint(uint64(c), 10)
+		}
+		return name + ":{" + strings.Join(categories, ",") + "}"
+	}
+	op := "<"
+	if n.Comparison == LessEqual {
+		op = "<="
+	}
+	return name + op + formatFloat(n.Threshold)
+}
+
+// formatFloat formats v in the shortest form, which is that of a float32 if v is one
+// since XGBoost stores float32 values.
+func formatFloat(v float64) string {
+	if float64(float32(v)) == v {
+		return strconv.FormatFloat(v, 'g', -1, 32)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
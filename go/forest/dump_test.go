@@ -0,0 +1,68 @@
+package forest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+func TestWriteText(t *testing.T) {
+	f := shapForest()
+
+	var buf strings.Builder
+	require.NoError(t, f.WriteText(&buf, nil, false))
+	require.Equal(t, `booster[0]:
+0:[f0<0.5] yes=1,no=2,missing=1
+	1:leaf=1
+	2:[f1<0.5] yes=3,no=4,missing=3
+		3:leaf=2
+		4:leaf=5
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, f.WriteText(&buf, []string{"age", "income"}, true))
+	require.Equal(t, `booster[0]:
+0:[age<0.5] yes=1,no=2,missing=1,gain=0,cover=10
+	1:leaf=1,cover=4
+	2:[income<0.5] yes=3,no=4,missing=3,gain=0,cover=6
+		3:leaf=2,cover=2
+		4:leaf=5,cover=4
+`, buf.String())
+}
+
+func TestWriteTextLightGBM(t *testing.T) {
+	f, err := forest.Parse(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+
+	var buf strings.Builder
+	require.NoError(t, f.WriteText(&buf, nil, false))
+	require.Contains(t, buf.String(), "0:[a<=0.5] yes=2,no=1\n")
+	require.Contains(t, buf.String(), "0:[b:{0,2}] yes=1,no=2")
+	require.Contains(t, buf.String(), "\t2:leaf=0.1\n")
+}
+
+func TestWriteDOT(t *testing.T) {
+	f := shapForest()
+	f.FeatureNames = []string{`say "hi"`, "b"}
+
+	var buf strings.Builder
+	require.NoError(t, f.WriteDOT(&buf, 0, nil))
+	require.Equal(t, `digraph tree0 {
+	graph [rankdir=TB];
+	0 [label="say \"hi\"<0.5", shape=box];
+	0 -> 1 [label="yes, missing", color="#0000FF"];
+	0 -> 2 [label="no", color="#FF0000"];
+	1 [label="leaf=1", shape=ellipse];
+	2 [label="b<0.5", shape=box];
+	2 -> 3 [label="yes, missing", color="#0000FF"];
+	2 -> 4 [label="no", color="#FF0000"];
+	3 [label="leaf=2", shape=ellipse];
+	4 [label="leaf=5", shape=ellipse];
+}
+`, buf.String())
+
+	require.ErrorIs(t, f.WriteDOT(&buf, 1, nil), forest.ErrInvalidTree)
+}
@@ -0,0 +1,53 @@
+package forest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseFeatures returns the feature names and types stored in a model without parsing its trees.
+// they are nil if the model does not store them, like the XGBoost legacy binary format.
+// LightGBM models store no feature types.
+func ParseFeatures(format Format, data []byte) (names []string, types []string, err error) {
+	switch format {
+	case XGBoostBinary:
+		return nil, nil, nil
+	case XGBoostJSON, XGBoostUBJSON:
+		if format == XGBoostUBJSON {
+			if data, err = ubjsonToJSON(data); err != nil {
+				return nil, nil, err
+			}
+		}
+		var model struct {
+			Learner struct {
+				FeatureNames []string `json:"feature_names"`
+				FeatureTypes []string `json:"feature_types"`
+			} `json:"learner"`
+		}
+		if err := json.Unmarshal(data, &model); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+		}
+		return model.Learner.FeatureNames, model.Learner.FeatureTypes, nil
+	case LightGBM:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "Tree=") {
+				break
+			}
+			if value, ok := strings.CutPrefix(line, "feature_names="); ok {
+				return strings.Fields(value), nil, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+		}
+		return nil, nil, nil
+	default:
+		return nil, nil, ErrUnsupportedFormat
+	}
+}
@@ -0,0 +1,389 @@
+// Package forest parses tree ensembles trained by XGBoost and LightGBM
+// and evaluates them on CPU with the same semantics as the Forest Inference Library.
+package forest
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrInvalidModel is returned when a model cannot be parsed.
+	ErrInvalidModel = errors.New("invalid model")
+	// ErrUnsupportedFormat is returned when the model format is unknown.
+	ErrUnsupportedFormat = errors.New("unsupported model format")
+	// ErrDimensionMismatch is returned when the input does not have the number of features of the model.
+	ErrDimensionMismatch = errors.New("dimension mismatch")
+	// ErrInvalidOutputLength is returned when the output buffer does not have the length of the result.
+	ErrInvalidOutputLength = errors.New("invalid output length")
+)
+
+// Format is the serialization format of a model.
+// the values are the same as cuml4go.FILModelType.
+type Format int
+
+const (
+	// XGBoostBinary xgboost legacy binary model
+	XGBoostBinary Format = iota
+	// XGBoostJSON xgboost json model
+	XGBoostJSON
+	// LightGBM lightgbm text model
+	LightGBM
+	// XGBoostUBJSON xgboost universal binary json model. 3 is cuml4go.ONNX, which package onnx parses.
+	XGBoostUBJSON Format = 4
+)
+
+// Parse parses a model serialized in format.
+func Parse(format Format, data []byte) (*Forest, error) {
+	switch format {
+	case XGBoostBinary:
+		return ParseXGBoostBinary(data)
+	case XGBoostJSON:
+		return ParseXGBoostJSON(data)
+	case LightGBM:
+		return ParseLightGBM(data)
+	case XGBoostUBJSON:
+		return ParseXGBoostUBJSON(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Comparison is the operator of a numerical split.
+// a row goes to the left child if the comparison of its value and the threshold holds.
+type Comparison uint8
+
+const (
+	// LessThan value < threshold (XGBoost)
+	LessThan Comparison = iota
+	// LessEqual value <= threshold (LightGBM)
+	LessEqual
+)
+
+// ZeroThreshold is the magnitude below which a value is zero for MissingZero, as in LightGBM.
+const ZeroThreshold = 1e-35
+
+// MissingMode is how a split handles missing values.
+type MissingMode uint8
+
+const (
+	// MissingNaN NaN goes to the default child
+	MissingNaN MissingMode = iota
+	// MissingZero NaN and zero go to the default child
+	MissingZero
+	// MissingNone NaN is compared as zero
+	MissingNone
+)
+
+// PostTransform is the transformation from the margin to the output.
+type PostTransform uint8
+
+const (
+	// Identity output the margin
+	Identity PostTransform = iota
+	// Sigmoid 1 / (1 + exp(-alpha * margin)) for every output
+	Sigmoid
+	// Softmax softmax over the outputs of a row
+	Softmax
+	// Exponential exp(margin) for every output
+	Exponential
+	// MaxIndex the index of the largest output of a row
+	MaxIndex
+	// Hinge 1 if the margin is positive and 0 otherwise
+	Hinge
+)
+
+// Node is a node of a tree. a node is a leaf if Left is negative.
+type Node struct {
+	// Left and Right are the indices of the children in Tree.Nodes. they are -1 for a leaf.
+	Left  int32
+	Right int32
+	// Feature is the index of the split feature.
+	Feature int32
+	// Threshold is the threshold of a numerical split.
+	Threshold float64
+	// Comparison is the operator of a numerical split.
+	Comparison Comparison
+	// DefaultLeft is true if missing values go to the left child.
+	DefaultLeft bool
+	// Missing is how missing values are detected.
+	Missing MissingMode
+	// Categorical is true for a categorical split,
+	// which sends a row to the left child if its value is one of Categories.
+	Categorical bool
+	// Categories is the sorted set of categories of a categorical split.
+	Categories []uint32
+	// Value is the output of a leaf.
+	Value float64
+	// Gain is the loss reduction of a split, if the model stores it.
+	Gain float64
+	// Cover is the sum of the hessian of the training rows reaching the node, if the model stores it.
+	Cover float64
+}
+
+// IsLeaf returns true if the node is a leaf.
+func (n *Node) IsLeaf() bool {
+	return n.Left < 0
+}
+
+// next returns the index of the child the value goes to.
+func (n *Node) next(value float32) int32 {
+	if math.IsNaN(float64(value)) {
+		if n.Missing != MissingNone {
+			return n.DefaultChild()
+		}
+		value = 0
+	}
+	if n.Missing == MissingZero && math.Abs(float64(value)) <= ZeroThreshold {
+		return n.DefaultChild()
+	}
+
+	if n.Categorical {
+		if n.hasCategory(value) {
+			return n.Left
+		}
+		return n.Right
+	}
+
+	v := float64(value)
+	if n.Comparison == LessEqual && v <= n.Threshold || n.Comparison == LessThan && v < n.Threshold {
+		return n.Left
+	}
+	return n.Right
+}
+
+// DefaultChild returns the child of the missing values.
+func (n *Node) DefaultChild() int32 {
+	if n.DefaultLeft {
+		return n.Left
+	}
+	return n.Right
+}
+
+// ZeroGoesLeft returns true if zero goes to the left child of the split when it is compared,
+// e.g. for NaN of MissingNone.
+func (n *Node) ZeroGoesLeft() bool {
+	if n.Categorical {
+		return len(n.Categories) > 0 && n.Categories[0] == 0
+	}
+	if n.Comparison == LessEqual {
+		return 0 <= n.Threshold
+	}
+	return 0 < n.Threshold
+}
+
+// hasCategory returns true if the integer part of value is in Categories.
+// negative values are in no category.
+func (n *Node) hasCategory(value float32) bool {
+	if value < 0 || value > math.MaxUint32 {
+		return false
+	}
+	category := uint32(value)
+	lo, hi := 0, len(n.Categories)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if n.Categories[mid] < category {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo < len(n.Categories) && n.Categories[lo] == category
+}
+
+// Tree is a decision tree. the root is Nodes[0].
+type Tree struct {
+	Nodes []Node
+	// Group is the output the tree contributes to.
+	Group int
+}
+
+// leaf returns the index of the leaf row reaches.
+func (t *Tree) leaf(row []float32) int {
+	i := int32(0)
+	for {
+		n := &t.Nodes[i]
+		if n.IsLeaf() {
+			return int(i)
+		}
+		i = n.next(row[n.Feature])
+	}
+}
+
+// Forest is a tree ensemble.
+// the margin of output g is BaseScore[g] plus the sum of the leaves of the trees of group g,
+// which is averaged over the trees of the group if AverageTreeOutput is true.
+type Forest struct {
+	Trees []Tree
+	// NumFeature is the number of features of a row.
+	NumFeature int
+	// NumGroup is the number of margins of a row, e.g. the number of classes of a multi-class model.
+	NumGroup int
+	// BaseScore is the initial margin of every group.
+	BaseScore []float64
+	// TreesPerIteration is the number of trees of a boosting round,
+	// which is NumGroup times the number of trees grown in parallel. zero means NumGroup.
+	TreesPerIteration int
+	// AverageTreeOutput is true for random forests.
+	AverageTreeOutput bool
+	// PostTransform is applied to the margins by Predict.
+	PostTransform PostTransform
+	// SigmoidAlpha is the alpha of Sigmoid.
+	SigmoidAlpha float64
+	// Objective is the name of the training objective, e.g. binary:logistic.
+	Objective string
+	// FeatureNames are the names of the features if the model stores them.
+	FeatureNames []string
+	// FeatureTypes are the types of the features if the model stores them.
+	FeatureTypes []string
+}
+
+// Validate checks that every tree is well formed,
+// so that the evaluation neither panics nor loops.
+func (f *Forest) Validate() error {
+	if f.NumFeature <= 0 || f.NumGroup <= 0 || len(f.BaseScore) != f.NumGroup || f.TreesPerIteration < 0 {
+		return ErrInvalidModel
+	}
+	for _, t := range f.Trees {
+		if len(t.Nodes) == 0 || t.Group < 0 || t.Group >= f.NumGroup {
+			return ErrInvalidModel
+		}
+		for i, n := range t.Nodes {
+			if n.IsLeaf() {
+				continue
+			}
+			// children follow their parent, so a path always terminates
+			if int(n.Left) <= i || int(n.Right) <= i || int(n.Left) >= len(t.Nodes) || int(n.Right) >= len(t.Nodes) {
+				return ErrInvalidModel
+			}
+			if n.Feature < 0 || int(n.Feature) >= f.NumFeature {
+				return ErrInvalidModel
+			}
+		}
+	}
+	return nil
+}
+
+// OutputWidth returns the number of outputs of a row of Predict.
+func (f *Forest) OutputWidth() int {
+	if f.PostTransform == MaxIndex {
+		return 1
+	}
+	return f.NumGroup
+}
+
+// PredictMargin writes the margins of numRow rows of x into dst,
+// whose length must be numRow * NumGroup.
+func (f *Forest) PredictMargin(dst []float32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	if len(dst) != numRow*f.NumGroup {
+		return ErrInvalidOutputLength
+	}
+
+	margin := make([]float64, f.NumGroup)
+	for r := 0; r < numRow; r++ {
+		f.margin(x[r*f.NumFeature:(r+1)*f.NumFeature], margin)
+		for g, m := range margin {
+			dst[r*f.NumGroup+g] = float32(m)
+		}
+	}
+	return nil
+}
+
+// Predict writes the transformed outputs of numRow rows of x into dst,
+// whose length must be numRow * OutputWidth().
+func (f *Forest) Predict(dst []float32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	width := f.OutputWidth()
+	if len(dst) != numRow*width {
+		return ErrInvalidOutputLength
+	}
+
+	margin := make([]float64, f.NumGroup)
+	for r := 0; r < numRow; r++ {
+		f.margin(x[r*f.NumFeature:(r+1)*f.NumFeature], margin)
+		f.transform(margin, dst[r*width:(r+1)*width])
+	}
+	return nil
+}
+
+// margin writes the margins of a row into margin.
+func (f *Forest) margin(row []float32, margin []float64) {
+	for g := range margin {
+		margin[g] = 0
+	}
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		margin[t.Group] += t.Nodes[t.leaf(row)].Value
+	}
+	if f.AverageTreeOutput {
+		for g := range margin {
+			if n := f.treesPerGroup(g); n > 0 {
+				margin[g] /= float64(n)
+			}
+		}
+	}
+	for g := range margin {
+		margin[g] += f.BaseScore[g]
+	}
+}
+
+func (f *Forest) treesPerGroup(group int) int {
+	n := 0
+	for i := range f.Trees {
+		if f.Trees[i].Group == group {
+			n++
+		}
+	}
+	return n
+}
+
+// transform writes the post-transformed margins into dst.
+func (f *Forest) transform(margin []float64, dst []float32) {
+	switch f.PostTransform {
+	case Sigmoid:
+		for g, m := range margin {
+			dst[g] = float32(1 / (1 + math.Exp(-f.SigmoidAlpha*m)))
+		}
+	case Exponential:
+		for g, m := range margin {
+			dst[g] = float32(math.Exp(m))
+		}
+	case Softmax:
+		maxMargin := math.Inf(-1)
+		for _, m := range margin {
+			maxMargin = math.Max(maxMargin, m)
+		}
+		var sum float64
+		for _, m := range margin {
+			sum += math.Exp(m - maxMargin)
+		}
+		for g, m := range margin {
+			dst[g] = float32(math.Exp(m-maxMargin) / sum)
+		}
+	case MaxIndex:
+		best := 0
+		for g, m := range margin {
+			if m > margin[best] {
+				best = g
+			}
+		}
+		dst[0] = float32(best)
+	case Hinge:
+		for g, m := range margin {
+			if m > 0 {
+				dst[g] = 1
+			} else {
+				dst[g] = 0
+			}
+		}
+	default:
+		for g, m := range margin {
+			dst[g] = float32(m)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package forest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+func TestNodeZeroGoesLeft(t *testing.T) {
+	for _, tc := range []struct {
+		node     forest.Node
+		expected bool
+	}{
+		{forest.Node{Threshold: 0.5}, true},
+		{forest.Node{Threshold: 0}, false},
+		{forest.Node{Threshold: 0, Comparison: forest.LessEqual}, true},
+		{forest.Node{Threshold: -1, Comparison: forest.LessEqual}, false},
+		{forest.Node{Categorical: true, Categories: []uint32{0, 2}}, true},
+		{forest.Node{Categorical: true, Categories: []uint32{1}}, false},
+		{forest.Node{Categorical: true}, false},
+	} {
+		require.Equal(t, tc.expected, tc.node.ZeroGoesLeft(), "%+v", tc.node)
+	}
+
+	n := forest.Node{Left: 1, Right: 2}
+	require.Equal(t, int32(2), n.DefaultChild())
+	n.DefaultLeft = true
+	require.Equal(t, int32(1), n.DefaultChild())
+}
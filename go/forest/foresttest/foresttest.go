@@ -0,0 +1,109 @@
+// Package foresttest provides the forests and inputs shared by the tests of the packages
+// which translate forests into other languages and formats.
+package foresttest
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// Synthetic returns a softmax model of two classes
+// whose splits cover every missing mode, comparison and categorical splits.
+func Synthetic() *forest.Forest {
+	leaf := func(v float64) forest.Node {
+		return forest.Node{Left: -1, Right: -1, Value: v}
+	}
+	return &forest.Forest{
+		Trees: []forest.Tree{
+			{Group: 0, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 0, Threshold: 0.5, DefaultLeft: true},
+				leaf(0.1),
+				{Left: 3, Right: 4, Feature: 1, Threshold: 1.5, Comparison: forest.LessEqual, Missing: forest.MissingZero},
+				leaf(0.2),
+				leaf(-0.3),
+			}},
+			{Group: 1, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 2, Categorical: true, Categories: []uint32{0, 2}, Missing: forest.MissingNone},
+				{Left: 3, Right: 4, Feature: 0, Threshold: -1, Comparison: forest.LessEqual, Missing: forest.MissingNone},
+				{Left: 5, Right: 6, Feature: 1, Threshold: 2},
+				leaf(0.4),
+				leaf(-0.5),
+				leaf(0.6),
+				leaf(-0.7),
+			}},
+			{Group: 0, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 0, Threshold: 1, Missing: forest.MissingNone},
+				leaf(1),
+				leaf(-1),
+			}},
+			{Group: 1, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 2, Categorical: true, Categories: []uint32{1}, Missing: forest.MissingZero, DefaultLeft: true},
+				leaf(0.25),
+				{Left: 3, Right: 4, Feature: 1, Threshold: 0.5, Missing: forest.MissingZero, DefaultLeft: true},
+				leaf(0.5),
+				leaf(0.75),
+			}},
+			{Group: 0, Nodes: []forest.Node{
+				{Left: 1, Right: 2, Feature: 2, Categorical: true, Categories: []uint32{0, 3}, Missing: forest.MissingZero},
+				leaf(-0.125),
+				{Left: 3, Right: 4, Feature: 0, Threshold: 0.1, Comparison: forest.LessEqual, Missing: forest.MissingZero},
+				leaf(0.375),
+				leaf(0.0625),
+			}},
+		},
+		NumFeature:    3,
+		NumGroup:      2,
+		BaseScore:     []float64{0.5, -0.5},
+		PostTransform: forest.Softmax,
+		SigmoidAlpha:  1,
+	}
+}
+
+// SyntheticRows returns the rows of every combination of values around the thresholds of Synthetic,
+// including NaN and zero, with the integers of its categorical feature.
+func SyntheticRows() []float32 {
+	values := []float32{float32(math.NaN()), 0, -1, 0.1, 0.5, 1, 1.5, 2, 2.5, 3}
+	categories := []float32{float32(math.NaN()), 0, -1, 1, 2, 3, 4}
+	var rows []float32
+	for _, a := range values {
+		for _, b := range values {
+			for _, c := range categories {
+				rows = append(rows, a, b, c)
+			}
+		}
+	}
+	return rows
+}
+
+// ReadCSV returns the values of a CSV file of numbers in row-major order.
+func ReadCSV(t testing.TB, csvPath string) []float32 {
+	t.Helper()
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var data []float32
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		for _, valueString := range strings.Split(scanner.Text(), ",") {
+			value, err := strconv.ParseFloat(valueString, 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, float32(value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
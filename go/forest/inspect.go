@@ -0,0 +1,143 @@
+package forest
+
+import "errors"
+
+// ErrInvalidImportanceType is returned when the importance type is unknown.
+var ErrInvalidImportanceType = errors.New("invalid importance type")
+
+// Depths returns the depth of every node of the tree. the root is at depth 0.
+func (t *Tree) Depths() []int {
+	// children follow their parent, so the depth of a node is known before its children
+	depths := make([]int, len(t.Nodes))
+	for i, n := range t.Nodes {
+		if n.IsLeaf() {
+			continue
+		}
+		depths[n.Left] = depths[i] + 1
+		depths[n.Right] = depths[i] + 1
+	}
+	return depths
+}
+
+// MaxDepth returns the depth of the deepest leaf.
+func (t *Tree) MaxDepth() int {
+	maxDepth := 0
+	for _, d := range t.Depths() {
+		maxDepth = max(maxDepth, d)
+	}
+	return maxDepth
+}
+
+// NumLeaves returns the number of leaves of the tree.
+func (t *Tree) NumLeaves() int {
+	n := 0
+	for i := range t.Nodes {
+		if t.Nodes[i].IsLeaf() {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary is the size of a forest.
+type Summary struct {
+	NumTrees  int
+	NumNodes  int
+	NumLeaves int
+	// MaxDepth is the depth of the deepest leaf of all trees.
+	MaxDepth int
+	// NumUsedFeatures is the number of features some split uses.
+	NumUsedFeatures int
+}
+
+// Summary returns the size of the forest.
+func (f *Forest) Summary() Summary {
+	s := Summary{
+		NumTrees:        len(f.Trees),
+		NumUsedFeatures: len(f.UsedFeatures()),
+	}
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		s.NumNodes += len(t.Nodes)
+		s.NumLeaves += t.NumLeaves()
+		s.MaxDepth = max(s.MaxDepth, t.MaxDepth())
+	}
+	return s
+}
+
+// UsedFeatures returns the sorted indices of the features some split uses.
+func (f *Forest) UsedFeatures() []int {
+	used := make([]bool, f.NumFeature)
+	for i := range f.Trees {
+		for _, n := range f.Trees[i].Nodes {
+			if !n.IsLeaf() {
+				used[n.Feature] = true
+			}
+		}
+	}
+	var features []int
+	for i, u := range used {
+		if u {
+			features = append(features, i)
+		}
+	}
+	return features
+}
+
+// ImportanceType is how FeatureImportance scores a feature, as XGBoost's get_score.
+type ImportanceType int
+
+const (
+	// ImportanceWeight the number of splits on the feature
+	ImportanceWeight ImportanceType = iota
+	// ImportanceGain the average Gain of the splits on the feature
+	ImportanceGain
+	// ImportanceCover the average Cover of the splits on the feature
+	ImportanceCover
+	// ImportanceTotalGain the sum of Gain of the splits on the feature
+	ImportanceTotalGain
+	// ImportanceTotalCover the sum of Cover of the splits on the feature
+	ImportanceTotalCover
+)
+
+// FeatureImportance returns the importance of every feature, which is 0 for a feature no split uses.
+// the gain and cover are 0 if the model does not store them.
+func (f *Forest) FeatureImportance(importanceType ImportanceType) ([]float64, error) {
+	if importanceType < ImportanceWeight || importanceType > ImportanceTotalCover {
+		return nil, ErrInvalidImportanceType
+	}
+
+	count := make([]float64, f.NumFeature)
+	gain := make([]float64, f.NumFeature)
+	cover := make([]float64, f.NumFeature)
+	for i := range f.Trees {
+		for _, n := range f.Trees[i].Nodes {
+			if n.IsLeaf() {
+				continue
+			}
+			count[n.Feature]++
+			gain[n.Feature] += n.Gain
+			cover[n.Feature] += n.Cover
+		}
+	}
+
+	switch importanceType {
+	case ImportanceWeight:
+		return count, nil
+	case ImportanceTotalGain:
+		return gain, nil
+	case ImportanceTotalCover:
+		return cover, nil
+	}
+
+	total := gain
+	if importanceType == ImportanceCover {
+		total = cover
+	}
+	for i, c := range count {
+		if c > 0 {
+			total[i] /= c
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,48 @@
+package forest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+func TestSummary(t *testing.T) {
+	f, err := forest.Parse(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+
+	require.Equal(t, []int{0, 1, 1, 2, 2}, f.Trees[0].Depths())
+	require.Equal(t, 2, f.Trees[0].MaxDepth())
+	require.Equal(t, 3, f.Trees[0].NumLeaves())
+	require.Equal(t, forest.Summary{
+		NumTrees:        2,
+		NumNodes:        8,
+		NumLeaves:       5,
+		MaxDepth:        2,
+		NumUsedFeatures: 2,
+	}, f.Summary())
+	require.Equal(t, []int{0, 1}, f.UsedFeatures())
+}
+
+func TestFeatureImportance(t *testing.T) {
+	f, err := forest.Parse(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+
+	// feature 0 has a split of gain 2 and cover 10,
+	// and feature 1 has splits of gain 1 and cover 5, and of gain 1 and no cover
+	for importanceType, expected := range map[forest.ImportanceType][]float64{
+		forest.ImportanceWeight:     {1, 2},
+		forest.ImportanceGain:       {2, 1},
+		forest.ImportanceCover:      {10, 2.5},
+		forest.ImportanceTotalGain:  {2, 2},
+		forest.ImportanceTotalCover: {10, 5},
+	} {
+		importance, err := f.FeatureImportance(importanceType)
+		require.NoError(t, err)
+		require.Equal(t, expected, importance, importanceType)
+	}
+
+	_, err = f.FeatureImportance(forest.ImportanceType(-1))
+	require.ErrorIs(t, err, forest.ErrInvalidImportanceType)
+}
@@ -0,0 +1,83 @@
+package forest
+
+import "errors"
+
+// ErrInvalidIterationRange is returned when an iteration range is out of the boosting rounds of a model.
+var ErrInvalidIterationRange = errors.New("invalid iteration range")
+
+// NumIterations returns the number of boosting rounds of the forest.
+func (f *Forest) NumIterations() int {
+	n := f.treesPerIteration()
+	return (len(f.Trees) + n - 1) / n
+}
+
+func (f *Forest) treesPerIteration() int {
+	if f.TreesPerIteration > 0 {
+		return f.TreesPerIteration
+	}
+	return f.NumGroup
+}
+
+// Slice returns the forest of the boosting rounds [begin, end), like XGBoost's iteration_range.
+// end == 0 means the last round. the trees are shared with f.
+func (f *Forest) Slice(begin int, end int) (*Forest, error) {
+	if end == 0 {
+		end = f.NumIterations()
+	}
+	if begin < 0 || end <= begin || end > f.NumIterations() {
+		return nil, ErrInvalidIterationRange
+	}
+
+	n := f.treesPerIteration()
+	sliced := *f
+	sliced.Trees = f.Trees[begin*n : min(end*n, len(f.Trees))]
+	return &sliced, nil
+}
+
+// NumTrees returns the number of trees of the forest.
+func (f *Forest) NumTrees() int {
+	return len(f.Trees)
+}
+
+// PredictLeaf writes the index in Tree.Nodes of the leaf which every row of x reaches in every tree into dst,
+// whose length must be numRow * NumTrees(). it is the node id of XGBoost's pred_leaf.
+func (f *Forest) PredictLeaf(dst []int32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	numTree := len(f.Trees)
+	if len(dst) != numRow*numTree {
+		return ErrInvalidOutputLength
+	}
+
+	for r := 0; r < numRow; r++ {
+		row := x[r*f.NumFeature : (r+1)*f.NumFeature]
+		for i := range f.Trees {
+			dst[r*numTree+i] = int32(f.Trees[i].leaf(row))
+		}
+	}
+	return nil
+}
+
+// PredictPerTree writes the value of the leaf which every row of x reaches in every tree into dst,
+// whose length must be numRow * NumTrees().
+// the margin of group g is BaseScore[g] plus the sum, or the average if AverageTreeOutput is true,
+// of the values of the trees of the group.
+func (f *Forest) PredictPerTree(dst []float32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	numTree := len(f.Trees)
+	if len(dst) != numRow*numTree {
+		return ErrInvalidOutputLength
+	}
+
+	for r := 0; r < numRow; r++ {
+		row := x[r*f.NumFeature : (r+1)*f.NumFeature]
+		for i := range f.Trees {
+			t := &f.Trees[i]
+			dst[r*numTree+i] = float32(t.Nodes[t.leaf(row)].Value)
+		}
+	}
+	return nil
+}
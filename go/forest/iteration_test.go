@@ -0,0 +1,68 @@
+package forest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+)
+
+func TestPredictLeaf(t *testing.T) {
+	f, err := forest.Parse(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+	require.Equal(t, 2, f.NumTrees())
+	require.Equal(t, 2, f.NumIterations())
+
+	x := []float32{0, 2, 1, 1}
+	leaves := make([]int32, 2*2)
+	require.NoError(t, f.PredictLeaf(leaves, x, 2))
+	// the leaves of LightGBM follow the internal nodes
+	require.Equal(t, []int32{2, 1, 3, 2}, leaves)
+
+	values := make([]float32, 2*2)
+	require.NoError(t, f.PredictPerTree(values, x, 2))
+	for i, leaf := range leaves {
+		require.Equal(t, float32(f.Trees[i%2].Nodes[leaf].Value), values[i])
+	}
+
+	require.ErrorIs(t, f.PredictLeaf(leaves[:1], x, 2), forest.ErrInvalidOutputLength)
+	require.ErrorIs(t, f.PredictPerTree(values, x[:1], 2), forest.ErrDimensionMismatch)
+}
+
+func TestSlice(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	f, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+	require.Equal(t, 100, f.NumIterations())
+
+	nRow := 114
+	features := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	perTree := make([]float32, nRow*f.NumTrees())
+	require.NoError(t, f.PredictPerTree(perTree, features, nRow))
+
+	sliced, err := f.Slice(10, 30)
+	require.NoError(t, err)
+	require.Equal(t, 20, sliced.NumTrees())
+	margin := make([]float32, nRow)
+	require.NoError(t, sliced.PredictMargin(margin, features, nRow))
+	for r := 0; r < nRow; r++ {
+		expected := f.BaseScore[0]
+		for _, v := range perTree[r*100+10 : r*100+30] {
+			expected += float64(v)
+		}
+		require.InDelta(t, expected, margin[r], 1e-5)
+	}
+
+	all, err := f.Slice(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 100, all.NumTrees())
+
+	for _, r := range [][2]int{{-1, 10}, {10, 10}, {0, 101}} {
+		_, err := f.Slice(r[0], r[1])
+		require.ErrorIs(t, err, forest.ErrInvalidIterationRange)
+	}
+}
@@ -0,0 +1,289 @@
+package forest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// LightGBM decision_type bits
+const (
+	lightGBMCategoricalMask = 1
+	lightGBMDefaultLeftMask = 2
+	lightGBMMissingTypeNaN  = 2
+	lightGBMMissingTypeZero = 1
+)
+
+// ParseLightGBM parses a model saved by LightGBM in the text format.
+func ParseLightGBM(data []byte) (*Forest, error) {
+	header, trees, err := splitLightGBM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFeatureIdx, err := strconv.Atoi(header["max_feature_idx"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: max_feature_idx", ErrInvalidModel)
+	}
+	numClass := 1
+	if s, ok := header["num_class"]; ok {
+		if numClass, err = strconv.Atoi(s); err != nil || numClass <= 0 {
+			return nil, fmt.Errorf("%w: num_class", ErrInvalidModel)
+		}
+	}
+	treesPerIteration := numClass
+	if s, ok := header["num_tree_per_iteration"]; ok {
+		if treesPerIteration, err = strconv.Atoi(s); err != nil || treesPerIteration <= 0 {
+			return nil, fmt.Errorf("%w: num_tree_per_iteration", ErrInvalidModel)
+		}
+	}
+
+	f := &Forest{
+		NumFeature:        maxFeatureIdx + 1,
+		NumGroup:          treesPerIteration,
+		TreesPerIteration: treesPerIteration,
+		BaseScore:         make([]float64, treesPerIteration),
+		AverageTreeOutput: header["average_output"] != "",
+		SigmoidAlpha:      1,
+	}
+	if names := header["feature_names"]; names != "" {
+		f.FeatureNames = strings.Fields(names)
+	}
+	if err := f.setLightGBMObjective(header["objective"]); err != nil {
+		return nil, err
+	}
+
+	for i, fields := range trees {
+		t, err := parseLightGBMTree(fields)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tree %d", err, i)
+		}
+		t.Group = i % treesPerIteration
+		f.Trees = append(f.Trees, t)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// splitLightGBM returns the key-value pairs of the header and of every tree.
+// a flag line without a value, like average_output, is stored with the value "true".
+func splitLightGBM(data []byte) (map[string]string, []map[string]string, error) {
+	header := make(map[string]string)
+	var trees []map[string]string
+	current := header
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "end of trees":
+			return header, trees, nil
+		case strings.HasPrefix(line, "Tree="):
+			current = make(map[string]string)
+			trees = append(trees, current)
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			value = "true"
+		}
+		current[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+	if len(trees) == 0 {
+		return nil, nil, fmt.Errorf("%w: no tree", ErrInvalidModel)
+	}
+	return header, trees, nil
+}
+
+// setLightGBMObjective sets the objective and the post transform from a value like "binary sigmoid:1".
+func (f *Forest) setLightGBMObjective(objective string) error {
+	fields := strings.Fields(objective)
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: objective", ErrInvalidModel)
+	}
+	f.Objective = fields[0]
+
+	for _, field := range fields[1:] {
+		if value, ok := strings.CutPrefix(field, "sigmoid:"); ok {
+			alpha, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%w: objective %q", ErrInvalidModel, objective)
+			}
+			f.SigmoidAlpha = alpha
+		}
+	}
+
+	switch f.Objective {
+	case "binary", "multiclassova", "cross_entropy", "xentropy":
+		f.PostTransform = Sigmoid
+	case "multiclass":
+		f.PostTransform = Softmax
+	case "poisson", "gamma", "tweedie":
+		f.PostTransform = Exponential
+	default:
+		f.PostTransform = Identity
+	}
+	return nil
+}
+
+// parseLightGBMTree converts a tree whose internal nodes are 0 to num_leaves-2
+// and whose negative children ~i refer to leaf i.
+// the leaves are stored after the internal nodes.
+func parseLightGBMTree(fields map[string]string) (Tree, error) {
+	numLeaves, err := strconv.Atoi(fields["num_leaves"])
+	if err != nil || numLeaves <= 0 {
+		return Tree{}, ErrInvalidModel
+	}
+	if fields["is_linear"] == "1" {
+		return Tree{}, fmt.Errorf("%w: linear tree", ErrInvalidModel)
+	}
+
+	leafValues, err := parseFloats(fields["leaf_value"], numLeaves)
+	if err != nil {
+		return Tree{}, err
+	}
+	numInternal := numLeaves - 1
+	t := Tree{Nodes: make([]Node, numInternal+numLeaves)}
+	for i, v := range leafValues {
+		t.Nodes[numInternal+i] = Node{Left: -1, Right: -1, Value: v}
+	}
+	if counts, err := parseFloats(fields["leaf_weight"], numLeaves); err == nil {
+		for i, c := range counts {
+			t.Nodes[numInternal+i].Cover = c
+		}
+	}
+	if numInternal == 0 {
+		return t, nil
+	}
+
+	splitFeature, err := parseInts(fields["split_feature"], numInternal)
+	if err != nil {
+		return Tree{}, err
+	}
+	threshold, err := parseFloats(fields["threshold"], numInternal)
+	if err != nil {
+		return Tree{}, err
+	}
+	decisionType, err := parseInts(fields["decision_type"], numInternal)
+	if err != nil {
+		return Tree{}, err
+	}
+	left, err := parseInts(fields["left_child"], numInternal)
+	if err != nil {
+		return Tree{}, err
+	}
+	right, err := parseInts(fields["right_child"], numInternal)
+	if err != nil {
+		return Tree{}, err
+	}
+	gain, _ := parseFloats(fields["split_gain"], numInternal)
+	cover, _ := parseFloats(fields["internal_weight"], numInternal)
+
+	var catBoundaries, catThreshold []int
+	if fields["num_cat"] != "" && fields["num_cat"] != "0" {
+		numCat, err := strconv.Atoi(fields["num_cat"])
+		if err != nil {
+			return Tree{}, ErrInvalidModel
+		}
+		if catBoundaries, err = parseInts(fields["cat_boundaries"], numCat+1); err != nil {
+			return Tree{}, err
+		}
+		if catThreshold, err = parseInts(fields["cat_threshold"], catBoundaries[numCat]); err != nil {
+			return Tree{}, err
+		}
+	}
+
+	child := func(c int) int32 {
+		if c < 0 {
+			return int32(numInternal + ^c)
+		}
+		return int32(c)
+	}
+
+	for i := 0; i < numInternal; i++ {
+		n := &t.Nodes[i]
+		n.Feature = int32(splitFeature[i])
+		n.Left = child(left[i])
+		n.Right = child(right[i])
+		n.Comparison = LessEqual
+		n.DefaultLeft = decisionType[i]&lightGBMDefaultLeftMask != 0
+		switch (decisionType[i] >> 2) & 3 {
+		case lightGBMMissingTypeNaN:
+			n.Missing = MissingNaN
+		case lightGBMMissingTypeZero:
+			n.Missing = MissingZero
+		default:
+			n.Missing = MissingNone
+		}
+		if gain != nil {
+			n.Gain = gain[i]
+		}
+		if cover != nil {
+			n.Cover = cover[i]
+		}
+
+		if decisionType[i]&lightGBMCategoricalMask == 0 {
+			n.Threshold = threshold[i]
+			continue
+		}
+
+		// the threshold is the index of the bitset of the categories which go left
+		n.Categorical = true
+		n.DefaultLeft = false
+		index := int(threshold[i])
+		if index < 0 || index+1 >= len(catBoundaries) {
+			return Tree{}, ErrInvalidModel
+		}
+		for w, word := range catThreshold[catBoundaries[index]:catBoundaries[index+1]] {
+			for word := uint32(word); word != 0; word &= word - 1 {
+				n.Categories = append(n.Categories, uint32(w*32+bits.TrailingZeros32(word)))
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) != n {
+		return nil, ErrInvalidModel
+	}
+	values := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, ErrInvalidModel
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func parseInts(s string, n int) ([]int, error) {
+	fields := strings.Fields(s)
+	if len(fields) != n {
+		return nil, ErrInvalidModel
+	}
+	values := make([]int, n)
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, ErrInvalidModel
+		}
+		values[i] = v
+	}
+	return values, nil
+}
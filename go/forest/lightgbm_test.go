@@ -0,0 +1,102 @@
+package forest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// lightGBMModel has a numerical tree whose missing values are compared as zero,
+// and a categorical tree which sends categories 0 and 2 to the left.
+const lightGBMModel = `tree
+version=v4
+num_class=1
+num_tree_per_iteration=1
+label_index=0
+max_feature_idx=1
+objective=binary sigmoid:1
+feature_names=a b
+feature_infos=[0:1] [0:3]
+tree_sizes=100 100
+
+Tree=0
+num_leaves=3
+num_cat=0
+split_feature=0 1
+split_gain=2 1
+threshold=0.5 1.5
+decision_type=2 0
+left_child=-1 -2
+right_child=1 -3
+leaf_value=0.1 0.2 0.3
+leaf_weight=5 3 2
+internal_value=0 0
+internal_weight=10 5
+shrinkage=1
+
+Tree=1
+num_leaves=2
+num_cat=1
+split_feature=1
+split_gain=1
+threshold=0
+decision_type=1
+left_child=-1
+right_child=-2
+leaf_value=-0.5 0.5
+cat_boundaries=0 1
+cat_threshold=5
+shrinkage=0.1
+
+end of trees
+
+feature_importances:
+a=1
+`
+
+func TestParseLightGBM(t *testing.T) {
+	target, err := forest.Parse(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, target.NumFeature)
+	require.Equal(t, []string{"a", "b"}, target.FeatureNames)
+	require.Equal(t, "binary", target.Objective)
+	require.Len(t, target.Trees, 2)
+	require.Equal(t, []uint32{0, 2}, target.Trees[1].Nodes[0].Categories)
+
+	nan := float32(math.NaN())
+	x := []float32{
+		0.2, 0,
+		1, 1,
+		nan, 2,
+		1, 3.7,
+	}
+	margin := make([]float32, 4)
+	require.NoError(t, target.PredictMargin(margin, x, 4))
+	require.InDeltaSlice(t, []float32{-0.4, 0.7, -0.4, 0.8}, margin, 1e-6)
+
+	actual := make([]float32, 4)
+	require.NoError(t, target.Predict(actual, x, 4))
+	for i := range actual {
+		require.InDelta(t, 1/(1+math.Exp(-float64(margin[i]))), actual[i], 1e-6)
+	}
+}
+
+func TestParseFeatures(t *testing.T) {
+	names, types, err := forest.ParseFeatures(forest.LightGBM, []byte(lightGBMModel))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, names)
+	require.Nil(t, types)
+
+	names, types, err = forest.ParseFeatures(forest.XGBoostJSON, []byte(`{"learner": {"feature_names": ["x"], "feature_types": ["c"]}}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"x"}, names)
+	require.Equal(t, []string{"c"}, types)
+
+	names, _, err = forest.ParseFeatures(forest.XGBoostBinary, nil)
+	require.NoError(t, err)
+	require.Nil(t, names)
+}
@@ -0,0 +1,310 @@
+package forest
+
+import (
+	"errors"
+)
+
+// ErrNoCover is returned by the SHAP values of a model without the cover of its nodes.
+var ErrNoCover = errors.New("model has no node cover")
+
+// PredictContributions writes the SHAP values of numRow rows of x into dst,
+// whose length must be numRow * NumGroup * (NumFeature + 1).
+// the values of a row and group are the contributions of the features followed by the bias,
+// which is the expected margin, and they sum to the margin of the row.
+// it is the exact path-dependent TreeSHAP of XGBoost's pred_contribs,
+// which weights the branches of a node by their Cover.
+func (f *Forest) PredictContributions(dst []float32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	width := f.NumGroup * (f.NumFeature + 1)
+	if len(dst) != numRow*width {
+		return ErrInvalidOutputLength
+	}
+	s, err := f.newSHAP()
+	if err != nil {
+		return err
+	}
+
+	phi := make([]float64, width)
+	for r := 0; r < numRow; r++ {
+		s.contributions(x[r*f.NumFeature:(r+1)*f.NumFeature], phi, 0, -1)
+		for i, v := range phi {
+			dst[r*width+i] = float32(v)
+		}
+	}
+	return nil
+}
+
+// PredictInteractions writes the SHAP interaction values of numRow rows of x into dst,
+// whose length must be numRow * NumGroup * (NumFeature + 1) * (NumFeature + 1).
+// the values of a row and group form a matrix whose element (i, j) is the interaction of features i and j,
+// with the main effects on the diagonal and the bias in the last row and column,
+// as XGBoost's pred_interactions. the sum of row i is the contribution of feature i.
+func (f *Forest) PredictInteractions(dst []float32, x []float32, numRow int) error {
+	if len(x) != numRow*f.NumFeature {
+		return ErrDimensionMismatch
+	}
+	numCol := f.NumFeature + 1
+	width := f.NumGroup * numCol * numCol
+	if len(dst) != numRow*width {
+		return ErrInvalidOutputLength
+	}
+	s, err := f.newSHAP()
+	if err != nil {
+		return err
+	}
+
+	diag := make([]float64, f.NumGroup*numCol)
+	on := make([]float64, f.NumGroup*numCol)
+	off := make([]float64, f.NumGroup*numCol)
+	for r := 0; r < numRow; r++ {
+		row := x[r*f.NumFeature : (r+1)*f.NumFeature]
+		out := dst[r*width : (r+1)*width]
+		s.contributions(row, diag, 0, -1)
+		for i := 0; i < numCol; i++ {
+			// the interaction of i and j is half the change of the contribution of j
+			// when i is known rather than missing
+			s.contributions(row, off, -1, int32(i))
+			s.contributions(row, on, 1, int32(i))
+			for g := 0; g < f.NumGroup; g++ {
+				o := out[g*numCol*numCol+i*numCol : g*numCol*numCol+(i+1)*numCol]
+				c := g * numCol
+				total := diag[c+i]
+				for j := 0; j < numCol; j++ {
+					if j == i {
+						continue
+					}
+					v := (on[c+j] - off[c+j]) / 2
+					o[j] = float32(v)
+					total -= v
+				}
+				o[i] = float32(total)
+			}
+		}
+	}
+	return nil
+}
+
+// shap holds what TreeSHAP needs of every tree.
+type shap struct {
+	forest *Forest
+	// expected is the expected output of every tree, scaled by scale.
+	expected []float64
+	// scale is the weight of the trees of every group.
+	scale []float64
+	// path is the storage of the paths of unique features of the deepest tree.
+	path []pathElement
+}
+
+// pathElement is a feature on the path to a node, as in Algorithm 2 of Lundberg et al.
+type pathElement struct {
+	feature int32
+	// zero is the fraction of the rows with the feature missing that take the path,
+	// and one is 1 if the row takes the path.
+	zero, one float64
+	// weight is the proportion of the subsets of the path of a given size.
+	weight float64
+}
+
+func (f *Forest) newSHAP() (*shap, error) {
+	s := &shap{
+		forest:   f,
+		expected: make([]float64, len(f.Trees)),
+		scale:    make([]float64, f.NumGroup),
+	}
+	for g := range s.scale {
+		s.scale[g] = 1
+		if n := f.treesPerGroup(g); f.AverageTreeOutput && n > 0 {
+			s.scale[g] /= float64(n)
+		}
+	}
+
+	maxDepth := 0
+	for i := range f.Trees {
+		nodes := f.Trees[i].Nodes
+		// children follow their parent, so the mean of the children is known before their parent
+		mean := make([]float64, len(nodes))
+		for j := len(nodes) - 1; j >= 0; j-- {
+			n := &nodes[j]
+			if n.IsLeaf() {
+				mean[j] = n.Value
+				continue
+			}
+			if !(n.Cover > 0) {
+				return nil, ErrNoCover
+			}
+			mean[j] = (mean[n.Left]*nodes[n.Left].Cover + mean[n.Right]*nodes[n.Right].Cover) / n.Cover
+		}
+		s.expected[i] = mean[0] * s.scale[f.Trees[i].Group]
+		maxDepth = max(maxDepth, f.Trees[i].MaxDepth())
+	}
+	// every level of the recursion copies the path of its parent, which is at most one longer
+	d := maxDepth + 2
+	s.path = make([]pathElement, d*(d+1)/2)
+	return s, nil
+}
+
+// contributions writes the SHAP values of row into phi, whose length is NumGroup * (NumFeature + 1).
+// if condition is 1 or -1, the values are conditioned on conditionFeature being known or missing.
+func (s *shap) contributions(row []float32, phi []float64, condition int, conditionFeature int32) {
+	f := s.forest
+	numCol := f.NumFeature + 1
+	for i := range phi {
+		phi[i] = 0
+	}
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		groupPhi := phi[t.Group*numCol : (t.Group+1)*numCol]
+		w := treeSHAPWalker{
+			tree:             t,
+			row:              row,
+			phi:              groupPhi,
+			scale:            s.scale[t.Group],
+			condition:        condition,
+			conditionFeature: conditionFeature,
+		}
+		w.walk(0, s.path, 0, 1, 1, -1, 1)
+		if condition == 0 {
+			groupPhi[f.NumFeature] += s.expected[i]
+		}
+	}
+	for g := 0; g < f.NumGroup; g++ {
+		phi[g*numCol+f.NumFeature] += f.BaseScore[g]
+	}
+}
+
+// treeSHAPWalker adds the SHAP values of a tree to phi.
+type treeSHAPWalker struct {
+	tree             *Tree
+	row              []float32
+	phi              []float64
+	scale            float64
+	condition        int
+	conditionFeature int32
+}
+
+// walk visits node, which the path of unique features of its parent leads to
+// with the zero and one fractions of the split on parentFeature.
+func (w *treeSHAPWalker) walk(
+	node int32,
+	parentPath []pathElement,
+	uniqueDepth int,
+	parentZero float64,
+	parentOne float64,
+	parentFeature int32,
+	conditionFraction float64,
+) {
+	if conditionFraction == 0 {
+		return
+	}
+
+	path := parentPath[uniqueDepth+1:]
+	copy(path, parentPath[:uniqueDepth+1])
+	if w.condition == 0 || w.conditionFeature != parentFeature {
+		extendPath(path, uniqueDepth, parentZero, parentOne, parentFeature)
+	}
+
+	n := &w.tree.Nodes[node]
+	if n.IsLeaf() {
+		for i := 1; i <= uniqueDepth; i++ {
+			weight := unwoundPathSum(path, uniqueDepth, i)
+			e := path[i]
+			w.phi[e.feature] += weight * (e.one - e.zero) * n.Value * w.scale * conditionFraction
+		}
+		return
+	}
+
+	hot := n.next(w.row[n.Feature])
+	cold := n.Left
+	if hot == n.Left {
+		cold = n.Right
+	}
+	hotZero := w.tree.Nodes[hot].Cover / n.Cover
+	coldZero := w.tree.Nodes[cold].Cover / n.Cover
+
+	// a feature already on the path is removed and split again here
+	incomingZero, incomingOne := 1.0, 1.0
+	pathIndex := 0
+	for ; pathIndex <= uniqueDepth; pathIndex++ {
+		if path[pathIndex].feature == n.Feature {
+			break
+		}
+	}
+	if pathIndex != uniqueDepth+1 {
+		incomingZero = path[pathIndex].zero
+		incomingOne = path[pathIndex].one
+		unwindPath(path, uniqueDepth, pathIndex)
+		uniqueDepth--
+	}
+
+	hotCondition, coldCondition := conditionFraction, conditionFraction
+	if n.Feature == w.conditionFeature {
+		switch {
+		case w.condition > 0:
+			coldCondition = 0
+			uniqueDepth--
+		case w.condition < 0:
+			hotCondition *= hotZero
+			coldCondition *= coldZero
+			uniqueDepth--
+		}
+	}
+
+	w.walk(hot, path, uniqueDepth+1, hotZero*incomingZero, incomingOne, n.Feature, hotCondition)
+	w.walk(cold, path, uniqueDepth+1, coldZero*incomingZero, 0, n.Feature, coldCondition)
+}
+
+// extendPath appends feature to the path and updates the weights of the subsets.
+func extendPath(path []pathElement, uniqueDepth int, zero float64, one float64, feature int32) {
+	weight := 0.0
+	if uniqueDepth == 0 {
+		weight = 1
+	}
+	path[uniqueDepth] = pathElement{feature: feature, zero: zero, one: one, weight: weight}
+	d := float64(uniqueDepth + 1)
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		path[i+1].weight += one * path[i].weight * float64(i+1) / d
+		path[i].weight = zero * path[i].weight * float64(uniqueDepth-i) / d
+	}
+}
+
+// unwindPath removes the element at pathIndex from the path, undoing extendPath.
+func unwindPath(path []pathElement, uniqueDepth int, pathIndex int) {
+	one, zero := path[pathIndex].one, path[pathIndex].zero
+	d := float64(uniqueDepth + 1)
+	nextOne := path[uniqueDepth].weight
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		if one != 0 {
+			tmp := path[i].weight
+			path[i].weight = nextOne * d / (float64(i+1) * one)
+			nextOne = tmp - path[i].weight*zero*float64(uniqueDepth-i)/d
+		} else {
+			path[i].weight = path[i].weight * d / (zero * float64(uniqueDepth-i))
+		}
+	}
+	for i := pathIndex; i < uniqueDepth; i++ {
+		path[i].feature = path[i+1].feature
+		path[i].zero = path[i+1].zero
+		path[i].one = path[i+1].one
+	}
+}
+
+// unwoundPathSum returns the total weight of the path without the element at pathIndex.
+func unwoundPathSum(path []pathElement, uniqueDepth int, pathIndex int) float64 {
+	one, zero := path[pathIndex].one, path[pathIndex].zero
+	d := float64(uniqueDepth + 1)
+	nextOne := path[uniqueDepth].weight
+	total := 0.0
+	for i := uniqueDepth - 1; i >= 0; i-- {
+		switch {
+		case one != 0:
+			tmp := nextOne * d / (float64(i+1) * one)
+			total += tmp
+			nextOne = path[i].weight - tmp*zero*float64(uniqueDepth-i)/d
+		case zero != 0:
+			total += path[i].weight / zero / (float64(uniqueDepth-i) / d)
+		}
+	}
+	return total
+}
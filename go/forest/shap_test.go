@@ -0,0 +1,109 @@
+package forest_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+)
+
+// shapForest is a tree which splits on feature 0 < 0.5 and then on feature 1 < 0.5 on the right.
+// the expected outputs of the subsets of the features of the row (1, 1) are
+// f() = 2.8, f(0) = 4, f(1) = 3.4 and f(0, 1) = 5.
+func shapForest() *forest.Forest {
+	return &forest.Forest{
+		Trees: []forest.Tree{{Nodes: []forest.Node{
+			{Left: 1, Right: 2, Feature: 0, Threshold: 0.5, DefaultLeft: true, Cover: 10},
+			{Left: -1, Right: -1, Value: 1, Cover: 4},
+			{Left: 3, Right: 4, Feature: 1, Threshold: 0.5, DefaultLeft: true, Cover: 6},
+			{Left: -1, Right: -1, Value: 2, Cover: 2},
+			{Left: -1, Right: -1, Value: 5, Cover: 4},
+		}}},
+		NumFeature: 2,
+		NumGroup:   1,
+		BaseScore:  []float64{0.5},
+	}
+}
+
+func TestPredictContributions(t *testing.T) {
+	f := shapForest()
+	x := []float32{1, 1, 0, 1}
+
+	contribs := make([]float32, 2*3)
+	require.NoError(t, f.PredictContributions(contribs, x, 2))
+	// feature 1 of the second row does not change its leaf but moves the expectation
+	require.InDeltaSlice(t, []float32{1.4, 0.8, 3.3, -2.1, 0.3, 3.3}, contribs, 1e-6)
+
+	interactions := make([]float32, 2*3*3)
+	require.NoError(t, f.PredictInteractions(interactions, x, 2))
+	require.InDeltaSlice(t, []float32{
+		1.2, 0.2, 0,
+		0.2, 0.6, 0,
+		0, 0, 3.3,
+	}, interactions[:9], 1e-6)
+
+	require.ErrorIs(t, f.PredictContributions(contribs, x[:3], 2), forest.ErrDimensionMismatch)
+	require.ErrorIs(t, f.PredictContributions(contribs[:3], x, 2), forest.ErrInvalidOutputLength)
+	require.ErrorIs(t, f.PredictInteractions(interactions[:9], x, 2), forest.ErrInvalidOutputLength)
+
+	f.Trees[0].Nodes[0].Cover = 0
+	require.ErrorIs(t, f.PredictContributions(contribs, x, 2), forest.ErrNoCover)
+}
+
+func TestPredictContributionsAverageTreeOutput(t *testing.T) {
+	f := shapForest()
+	f.Trees = append(f.Trees, f.Trees[0])
+	f.Trees[1].Nodes = append([]forest.Node(nil), f.Trees[0].Nodes...)
+	f.Trees[1].Nodes[4].Value = 7
+	f.AverageTreeOutput = true
+	x := []float32{1, 1}
+
+	margin := make([]float32, 1)
+	require.NoError(t, f.PredictMargin(margin, x, 1))
+	contribs := make([]float32, 3)
+	require.NoError(t, f.PredictContributions(contribs, x, 1))
+	require.InDelta(t, margin[0], contribs[0]+contribs[1]+contribs[2], 1e-6)
+}
+
+func TestPredictContributionsXGBoost(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	f, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+
+	nRow := 114
+	numCol := f.NumFeature + 1
+	features := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+
+	margin := make([]float32, nRow)
+	require.NoError(t, f.PredictMargin(margin, features, nRow))
+	contribs := make([]float32, nRow*numCol)
+	require.NoError(t, f.PredictContributions(contribs, features, nRow))
+	for r := 0; r < nRow; r++ {
+		var sum float64
+		for _, c := range contribs[r*numCol : (r+1)*numCol] {
+			sum += float64(c)
+		}
+		require.InDelta(t, margin[r], sum, 1e-4)
+	}
+
+	// the rows of the interactions sum to the contributions
+	interactions := make([]float32, 3*numCol*numCol)
+	require.NoError(t, f.PredictInteractions(interactions, features[:3*f.NumFeature], 3))
+	for r := 0; r < 3; r++ {
+		for i := 0; i < numCol; i++ {
+			var sum float64
+			for _, v := range interactions[(r*numCol+i)*numCol : (r*numCol+i+1)*numCol] {
+				sum += float64(v)
+			}
+			require.InDelta(t, contribs[r*numCol+i], sum, 1e-4)
+		}
+	}
+
+	// contrib-xgboost.csv is written by testdata/main.py with the pred_contribs of xgboost
+	expected := foresttest.ReadCSV(t, "../../testdata/contrib-xgboost.csv")
+	require.InDeltaSlice(t, expected, contribs, 1e-5)
+}
@@ -0,0 +1,216 @@
+package forest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxUBJSONDepth bounds the nesting of containers, which XGBoost models keep shallow.
+const maxUBJSONDepth = 256
+
+// ubjsonToJSON converts a UBJSON document, as XGBoost saves it, into JSON,
+// so that the models in UBJSON share the parser of the models in JSON.
+// it supports the optimized containers of a type and a count, and big-endian numbers as the specification.
+// non-finite floats, which JSON cannot represent, are invalid.
+func ubjsonToJSON(data []byte) ([]byte, error) {
+	d := &ubjsonDecoder{data: data}
+	d.out.Grow(len(data) * 2)
+	d.value(d.marker(), 0)
+	if d.err == nil && len(d.data) != 0 {
+		d.fail("trailing data")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.out.Bytes(), nil
+}
+
+// ubjsonDecoder writes the JSON of a UBJSON document and records the first error.
+type ubjsonDecoder struct {
+	data []byte
+	out  bytes.Buffer
+	err  error
+}
+
+func (d *ubjsonDecoder) fail(reason string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: ubjson: %s", ErrInvalidModel, reason)
+	}
+	d.data = nil
+}
+
+func (d *ubjsonDecoder) next(n int) []byte {
+	if d.err != nil || n < 0 || n > len(d.data) {
+		d.fail("unexpected end of data")
+		return make([]byte, max(n, 0))
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+// marker reads a type marker, skipping the no-op markers.
+func (d *ubjsonDecoder) marker() byte {
+	for {
+		m := d.next(1)[0]
+		if m != 'N' {
+			return m
+		}
+	}
+}
+
+// integer reads an integer of marker m.
+func (d *ubjsonDecoder) integer(m byte) (int64, bool) {
+	switch m {
+	case 'i':
+		return int64(int8(d.next(1)[0])), true
+	case 'U':
+		return int64(d.next(1)[0]), true
+	case 'I':
+		return int64(int16(binary.BigEndian.Uint16(d.next(2)))), true
+	case 'l':
+		return int64(int32(binary.BigEndian.Uint32(d.next(4)))), true
+	case 'L':
+		return int64(binary.BigEndian.Uint64(d.next(8))), true
+	default:
+		return 0, false
+	}
+}
+
+// length reads a non-negative integer of marker m, which is a string length or a container count.
+func (d *ubjsonDecoder) length(m byte) int {
+	n, ok := d.integer(m)
+	if !ok || n < 0 || n > int64(len(d.data)) {
+		// every element of a container takes at least a byte
+		d.fail("invalid length")
+		return 0
+	}
+	return int(n)
+}
+
+// string writes a string whose length has marker m.
+func (d *ubjsonDecoder) string(m byte) {
+	s := d.next(d.length(m))
+	quoted, err := json.Marshal(string(s))
+	if err != nil {
+		d.fail(err.Error())
+		return
+	}
+	d.out.Write(quoted)
+}
+
+func (d *ubjsonDecoder) float(v float64, bitSize int) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		d.fail("non-finite number")
+		return
+	}
+	d.out.WriteString(strconv.FormatFloat(v, 'g', -1, bitSize))
+}
+
+// value writes the value of marker m.
+func (d *ubjsonDecoder) value(m byte, depth int) {
+	if d.err != nil {
+		return
+	}
+	if v, ok := d.integer(m); ok {
+		d.out.WriteString(strconv.FormatInt(v, 10))
+		return
+	}
+	switch m {
+	case 'Z':
+		d.out.WriteString("null")
+	case 'T':
+		d.out.WriteString("true")
+	case 'F':
+		d.out.WriteString("false")
+	case 'd':
+		d.float(float64(math.Float32frombits(binary.BigEndian.Uint32(d.next(4)))), 32)
+	case 'D':
+		d.float(math.Float64frombits(binary.BigEndian.Uint64(d.next(8))), 64)
+	case 'H':
+		// a high-precision number is a string of its decimal digits
+		s := string(d.next(d.length(d.marker())))
+		if _, err := strconv.ParseFloat(s, 64); err != nil || !json.Valid([]byte(s)) {
+			d.fail("invalid high-precision number")
+			return
+		}
+		d.out.WriteString(s)
+	case 'C':
+		quoted, _ := json.Marshal(string(rune(d.next(1)[0])))
+		d.out.Write(quoted)
+	case 'S':
+		d.string(d.marker())
+	case '[', '{':
+		d.container(m == '{', depth+1)
+	default:
+		d.fail(fmt.Sprintf("unknown marker %q", m))
+	}
+}
+
+// container writes an array or an object, whose opening marker is read.
+func (d *ubjsonDecoder) container(object bool, depth int) {
+	if depth > maxUBJSONDepth {
+		d.fail("too deep")
+		return
+	}
+	open, end := byte('['), byte(']')
+	if object {
+		open, end = '{', '}'
+	}
+	d.out.WriteByte(open)
+
+	// the optimized format of a type of every value and a count
+	var elementType byte
+	count := -1
+	if len(d.data) > 0 && d.data[0] == '$' {
+		d.next(1)
+		elementType = d.next(1)[0]
+		switch elementType {
+		case 'Z', 'T', 'F', 'N':
+			// values without payload would make a count unbounded by the data
+			d.fail("unsupported container type")
+			return
+		}
+		if len(d.data) == 0 || d.data[0] != '#' {
+			d.fail("container type without count")
+			return
+		}
+	}
+	if len(d.data) > 0 && d.data[0] == '#' {
+		d.next(1)
+		count = d.length(d.marker())
+	}
+
+	for i := 0; d.err == nil && i != count; i++ {
+		var m byte
+		if count < 0 {
+			if m = d.marker(); m == end {
+				break
+			}
+		}
+		if i > 0 {
+			d.out.WriteByte(',')
+		}
+		if object {
+			// a key is a string without the marker
+			if m == 0 {
+				m = d.marker()
+			}
+			d.string(m)
+			d.out.WriteByte(':')
+			m = 0
+		}
+		if m == 0 {
+			m = elementType
+		}
+		if m == 0 {
+			m = d.marker()
+		}
+		d.value(m, depth)
+	}
+	d.out.WriteByte(end)
+}
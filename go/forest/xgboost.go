@@ -0,0 +1,481 @@
+package forest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseXGBoostJSON parses a model saved by XGBoost in JSON.
+// the categorical splits of XGBoost, which send the categories of the set to the right child,
+// become splits whose children are swapped. a tree of vector leaves, of XGBoost's multi_output_tree,
+// becomes a tree for every output in a row.
+func ParseXGBoostJSON(data []byte) (*Forest, error) {
+	var model xgboostJSONModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+	return model.forest()
+}
+
+// ParseXGBoostUBJSON parses a model saved by XGBoost in UBJSON, the default format of XGBoost 2,
+// whose schema is the same as ParseXGBoostJSON.
+func ParseXGBoostUBJSON(data []byte) (*Forest, error) {
+	data, err := ubjsonToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseXGBoostJSON(data)
+}
+
+type xgboostJSONModel struct {
+	Learner struct {
+		FeatureNames      []string `json:"feature_names"`
+		FeatureTypes      []string `json:"feature_types"`
+		LearnerModelParam struct {
+			BaseScore  string `json:"base_score"`
+			NumClass   string `json:"num_class"`
+			NumFeature string `json:"num_feature"`
+			NumTarget  string `json:"num_target"`
+		} `json:"learner_model_param"`
+		Objective struct {
+			Name string `json:"name"`
+		} `json:"objective"`
+		GradientBooster xgboostJSONBooster `json:"gradient_booster"`
+	} `json:"learner"`
+}
+
+// xgboostJSONBooster is a gbtree booster, or a dart booster which wraps a gbtree booster.
+type xgboostJSONBooster struct {
+	Name       string              `json:"name"`
+	Model      *xgboostJSONGBTree  `json:"model"`
+	GBTree     *xgboostJSONBooster `json:"gbtree"`
+	WeightDrop []float64           `json:"weight_drop"`
+}
+
+type xgboostJSONGBTree struct {
+	Param struct {
+		NumParallelTree string `json:"num_parallel_tree"`
+	} `json:"gbtree_model_param"`
+	TreeInfo []int             `json:"tree_info"`
+	Trees    []xgboostJSONTree `json:"trees"`
+}
+
+type xgboostJSONTree struct {
+	Param struct {
+		SizeLeafVector string `json:"size_leaf_vector"`
+	} `json:"tree_param"`
+	LeftChildren    []int32     `json:"left_children"`
+	RightChildren   []int32     `json:"right_children"`
+	SplitIndices    []int32     `json:"split_indices"`
+	SplitConditions []float32   `json:"split_conditions"`
+	DefaultLeft     xgboostBool `json:"default_left"`
+	LossChanges     []float64   `json:"loss_changes"`
+	SumHessian      []float64   `json:"sum_hessian"`
+	// BaseWeights are the vectors of the nodes of a tree of vector leaves.
+	BaseWeights []float32 `json:"base_weights"`
+	// SplitType is 1 for a categorical split.
+	SplitType []int `json:"split_type"`
+	// the categories of node CategoriesNodes[i] are Categories[CategoriesSegments[i]:][:CategoriesSizes[i]].
+	Categories         []uint32 `json:"categories"`
+	CategoriesNodes    []int32  `json:"categories_nodes"`
+	CategoriesSegments []int    `json:"categories_segments"`
+	CategoriesSizes    []int    `json:"categories_sizes"`
+}
+
+// xgboostBool is an array of flags, which older versions store as booleans and newer as integers.
+type xgboostBool []bool
+
+func (b *xgboostBool) UnmarshalJSON(data []byte) error {
+	var values []any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*b = make([]bool, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case bool:
+			(*b)[i] = v
+		case float64:
+			(*b)[i] = v != 0
+		default:
+			return ErrInvalidModel
+		}
+	}
+	return nil
+}
+
+func (m *xgboostJSONModel) forest() (*Forest, error) {
+	learner := &m.Learner
+	param := &learner.LearnerModelParam
+
+	numFeature, err := strconv.Atoi(param.NumFeature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: num_feature %q", ErrInvalidModel, param.NumFeature)
+	}
+	numClass := 0
+	if param.NumClass != "" {
+		if numClass, err = strconv.Atoi(param.NumClass); err != nil {
+			return nil, fmt.Errorf("%w: num_class %q", ErrInvalidModel, param.NumClass)
+		}
+	}
+	// a model of several targets has a group for every target
+	if param.NumTarget != "" {
+		numTarget, err := strconv.Atoi(param.NumTarget)
+		if err != nil {
+			return nil, fmt.Errorf("%w: num_target %q", ErrInvalidModel, param.NumTarget)
+		}
+		numClass = max(numClass, numTarget)
+	}
+	baseScore, err := parseXGBoostBaseScore(param.BaseScore)
+	if err != nil {
+		return nil, err
+	}
+
+	booster := &learner.GradientBooster
+	var weightDrop []float64
+	if booster.Name == "dart" && booster.GBTree != nil {
+		weightDrop = booster.WeightDrop
+		booster = booster.GBTree
+	}
+	if booster.Model == nil {
+		return nil, fmt.Errorf("%w: unsupported booster %q", ErrInvalidModel, booster.Name)
+	}
+	gbtree := booster.Model
+	if len(gbtree.TreeInfo) != len(gbtree.Trees) {
+		return nil, fmt.Errorf("%w: tree_info", ErrInvalidModel)
+	}
+
+	numParallelTree := 1
+	if gbtree.Param.NumParallelTree != "" {
+		if numParallelTree, err = strconv.Atoi(gbtree.Param.NumParallelTree); err != nil {
+			return nil, fmt.Errorf("%w: num_parallel_tree %q", ErrInvalidModel, gbtree.Param.NumParallelTree)
+		}
+	}
+
+	f := newXGBoostForest(learner.Objective.Name, numFeature, numClass, baseScore)
+	f.TreesPerIteration = f.NumGroup * max(numParallelTree, 1)
+	f.FeatureNames = learner.FeatureNames
+	f.FeatureTypes = learner.FeatureTypes
+
+	for i := range gbtree.Trees {
+		src := &gbtree.Trees[i]
+		scale := 1.0
+		if weightDrop != nil {
+			if i >= len(weightDrop) {
+				return nil, fmt.Errorf("%w: weight_drop", ErrInvalidModel)
+			}
+			scale = weightDrop[i]
+		}
+		trees, err := src.trees(gbtree.TreeInfo[i], f.NumGroup, scale)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tree %d", err, i)
+		}
+		f.Trees = append(f.Trees, trees...)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// trees returns the tree of group, or a tree for every group if the leaves are vectors of numGroup outputs.
+// the outputs of the leaves are multiplied by scale.
+func (src *xgboostJSONTree) trees(group int, numGroup int, scale float64) ([]Tree, error) {
+	numNodes := len(src.LeftChildren)
+	if len(src.RightChildren) != numNodes || len(src.SplitIndices) != numNodes ||
+		len(src.SplitConditions) != numNodes || len(src.DefaultLeft) != numNodes ||
+		(src.SplitType != nil && len(src.SplitType) != numNodes) {
+		return nil, ErrInvalidModel
+	}
+	sizeLeafVector := 1
+	if src.Param.SizeLeafVector != "" {
+		var err error
+		if sizeLeafVector, err = strconv.Atoi(src.Param.SizeLeafVector); err != nil {
+			return nil, ErrInvalidModel
+		}
+	}
+	vectorLeaf := sizeLeafVector > 1
+	if vectorLeaf && (sizeLeafVector != numGroup || len(src.BaseWeights) != numNodes*sizeLeafVector) {
+		return nil, ErrInvalidModel
+	}
+
+	categories, err := src.categories()
+	if err != nil {
+		return nil, err
+	}
+
+	t := Tree{
+		Nodes: make([]Node, numNodes),
+		Group: group,
+	}
+	for j := range t.Nodes {
+		n := &t.Nodes[j]
+		n.Left = src.LeftChildren[j]
+		n.Right = src.RightChildren[j]
+		if j < len(src.SumHessian) {
+			n.Cover = src.SumHessian[j]
+		}
+		if n.IsLeaf() {
+			n.Left, n.Right = -1, -1
+			n.Value = float64(src.SplitConditions[j]) * scale
+			continue
+		}
+		n.Feature = src.SplitIndices[j]
+		n.DefaultLeft = src.DefaultLeft[j]
+		if j < len(src.LossChanges) {
+			n.Gain = src.LossChanges[j]
+		}
+		if src.SplitType != nil && src.SplitType[j] == 1 {
+			set, ok := categories[int32(j)]
+			if !ok {
+				return nil, ErrInvalidModel
+			}
+			// XGBoost sends the categories of the set to the right child
+			n.Categorical = true
+			n.Categories = set
+			n.Left, n.Right = n.Right, n.Left
+			n.DefaultLeft = !n.DefaultLeft
+			continue
+		}
+		n.Threshold = float64(src.SplitConditions[j])
+		n.Comparison = LessThan
+	}
+	if !vectorLeaf {
+		return []Tree{t}, nil
+	}
+
+	trees := make([]Tree, numGroup)
+	for g := range trees {
+		trees[g] = Tree{Nodes: slices.Clone(t.Nodes), Group: g}
+		for j := range trees[g].Nodes {
+			if n := &trees[g].Nodes[j]; n.IsLeaf() {
+				n.Value = float64(src.BaseWeights[j*sizeLeafVector+g]) * scale
+			}
+		}
+	}
+	return trees, nil
+}
+
+// categories returns the sorted set of categories of every categorical split by node.
+func (src *xgboostJSONTree) categories() (map[int32][]uint32, error) {
+	if len(src.CategoriesSegments) != len(src.CategoriesNodes) || len(src.CategoriesSizes) != len(src.CategoriesNodes) {
+		return nil, ErrInvalidModel
+	}
+	sets := make(map[int32][]uint32, len(src.CategoriesNodes))
+	for i, node := range src.CategoriesNodes {
+		begin, size := src.CategoriesSegments[i], src.CategoriesSizes[i]
+		if begin < 0 || size < 0 || begin+size > len(src.Categories) {
+			return nil, ErrInvalidModel
+		}
+		set := slices.Clone(src.Categories[begin : begin+size])
+		slices.Sort(set)
+		sets[node] = slices.Compact(set)
+	}
+	return sets, nil
+}
+
+// parseXGBoostBaseScore parses a base score, which newer versions store as a vector like [5E-1].
+func parseXGBoostBaseScore(s string) ([]float64, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+	if s == "" {
+		return []float64{0.5}, nil
+	}
+	var scores []float64
+	for _, field := range strings.Split(s, ",") {
+		score, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: base_score %q", ErrInvalidModel, s)
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
+// newXGBoostForest creates an empty forest with the post transform of the objective
+// and the base score converted to the margin.
+func newXGBoostForest(objective string, numFeature int, numClass int, baseScore []float64) *Forest {
+	f := &Forest{
+		NumFeature:    numFeature,
+		NumGroup:      max(numClass, 1),
+		Objective:     objective,
+		PostTransform: Identity,
+		SigmoidAlpha:  1,
+	}
+
+	probToMargin := func(p float64) float64 { return p }
+	switch objective {
+	case "binary:logistic", "reg:logistic":
+		f.PostTransform = Sigmoid
+		probToMargin = func(p float64) float64 { return -math.Log(1/p - 1) }
+	case "multi:softprob":
+		f.PostTransform = Softmax
+	case "multi:softmax":
+		f.PostTransform = MaxIndex
+	case "count:poisson", "reg:gamma", "reg:tweedie", "survival:cox", "survival:aft":
+		f.PostTransform = Exponential
+		probToMargin = math.Log
+	case "binary:hinge":
+		f.PostTransform = Hinge
+	}
+
+	f.BaseScore = make([]float64, f.NumGroup)
+	for g := range f.BaseScore {
+		score := baseScore[0]
+		if len(baseScore) == f.NumGroup {
+			score = baseScore[g]
+		}
+		f.BaseScore[g] = probToMargin(score)
+	}
+	return f
+}
+
+// ParseXGBoostBinary parses a model saved by XGBoost in the legacy binary format.
+func ParseXGBoostBinary(data []byte) (*Forest, error) {
+	r := &binaryReader{data: bytes.TrimPrefix(data, []byte("binf"))}
+
+	// LearnerModelParam: base_score, num_feature, num_class, and 31 fields unused here
+	baseScore := float64(r.float32())
+	numFeature := int(r.int32())
+	numClass := int(r.int32())
+	r.skip(4 * 31)
+
+	objective := r.string()
+	booster := r.string()
+	if booster != "gbtree" && booster != "dart" {
+		return nil, fmt.Errorf("%w: unsupported booster %q", ErrInvalidModel, booster)
+	}
+
+	// GBTreeModelParam: num_trees, num_parallel_tree, and 38 words unused here
+	numTrees := int(r.int32())
+	numParallelTree := int(r.int32())
+	r.skip(4*2 + 8 + 4*2 + 4*32)
+	if r.err != nil || numTrees < 0 || numTrees > len(r.data) {
+		return nil, ErrInvalidModel
+	}
+
+	f := newXGBoostForest(objective, numFeature, numClass, []float64{baseScore})
+	// models of old versions store zero parallel trees
+	f.TreesPerIteration = f.NumGroup * max(numParallelTree, 1)
+	f.Trees = make([]Tree, numTrees)
+
+	for i := range f.Trees {
+		// TreeParam: num_roots, num_nodes, num_deleted, max_depth, num_feature, size_leaf_vector, reserved[31]
+		r.skip(4)
+		numNodes := int(r.int32())
+		r.skip(4 * 3)
+		sizeLeafVector := r.int32()
+		r.skip(4 * 31)
+		if r.err != nil || numNodes <= 0 || numNodes > len(r.data) {
+			return nil, ErrInvalidModel
+		}
+
+		nodes := make([]Node, numNodes)
+		for j := range nodes {
+			n := &nodes[j]
+			r.skip(4) // parent
+			n.Left = r.int32()
+			n.Right = r.int32()
+			splitIndex := r.uint32()
+			info := r.float32()
+			if n.IsLeaf() {
+				n.Left, n.Right = -1, -1
+				n.Value = float64(info)
+				continue
+			}
+			n.Feature = int32(splitIndex & (1<<31 - 1))
+			n.DefaultLeft = splitIndex>>31 != 0
+			n.Threshold = float64(info)
+			n.Comparison = LessThan
+		}
+		for j := range nodes {
+			// RTreeNodeStat: loss_chg, sum_hess, base_weight, leaf_child_cnt
+			nodes[j].Gain = float64(r.float32())
+			nodes[j].Cover = float64(r.float32())
+			r.skip(8)
+			if nodes[j].IsLeaf() {
+				nodes[j].Gain = 0
+			}
+		}
+		if sizeLeafVector != 0 {
+			r.skip(4 * int(r.uint64()))
+		}
+		f.Trees[i].Nodes = nodes
+	}
+
+	for i := range f.Trees {
+		f.Trees[i].Group = int(r.int32())
+	}
+
+	if booster == "dart" && numTrees > 0 {
+		if int(r.uint64()) != numTrees {
+			return nil, fmt.Errorf("%w: weight_drop", ErrInvalidModel)
+		}
+		for i := range f.Trees {
+			weight := float64(r.float32())
+			for j := range f.Trees[i].Nodes {
+				f.Trees[i].Nodes[j].Value *= weight
+			}
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// binaryReader reads little-endian values and records the first error.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidModel)
+		return make([]byte, max(n, 0))
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) skip(n int) {
+	r.next(n)
+}
+
+func (r *binaryReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *binaryReader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *binaryReader) uint64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+func (r *binaryReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+// string reads a string prefixed by its 64-bit length.
+func (r *binaryReader) string() string {
+	n := r.uint64()
+	if n > uint64(len(r.data)) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidModel)
+		return ""
+	}
+	return string(r.next(int(n)))
+}
@@ -0,0 +1,259 @@
+package forest_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+)
+
+func TestParseXGBoostJSON(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+
+	target, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+
+	require.Equal(t, 30, target.NumFeature)
+	require.Equal(t, 1, target.NumGroup)
+	require.Len(t, target.Trees, 100)
+	require.Len(t, target.FeatureNames, 30)
+	require.Equal(t, "mean radius", target.FeatureNames[0])
+	require.Equal(t, "binary:logistic", target.Objective)
+	require.Equal(t, forest.Sigmoid, target.PostTransform)
+
+	nRow := 114
+	features := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	expectedScores := foresttest.ReadCSV(t, "../../testdata/score-xgboost.csv")
+
+	actual := make([]float32, nRow)
+	require.NoError(t, target.Predict(actual, features, nRow))
+	require.InDeltaSlice(t, expectedScores, actual, 1e-5)
+
+	margin := make([]float32, nRow)
+	require.NoError(t, target.PredictMargin(margin, features, nRow))
+	for i := range margin {
+		require.InDelta(t, expectedScores[i], 1/(1+math.Exp(-float64(margin[i]))), 1e-5)
+	}
+
+	require.ErrorIs(t, target.Predict(actual, features[:10], nRow), forest.ErrDimensionMismatch)
+	require.ErrorIs(t, target.Predict(actual[:1], features, nRow), forest.ErrInvalidOutputLength)
+}
+
+func TestParseInvalidModel(t *testing.T) {
+	_, err := forest.Parse(forest.XGBoostJSON, []byte("{"))
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+
+	_, err = forest.Parse(forest.XGBoostBinary, []byte("binf"))
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+
+	_, err = forest.Parse(forest.LightGBM, []byte("version=v4\n"))
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+
+	_, err = forest.Parse(forest.Format(-1), nil)
+	require.ErrorIs(t, err, forest.ErrUnsupportedFormat)
+}
+
+// xgboostBinaryModel encodes a binary:logistic model of a single tree
+// which splits on feature 1 < 0.5 with missing values going left.
+func xgboostBinaryModel(t *testing.T) []byte {
+	var buf bytes.Buffer
+	write := func(v any) {
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, v))
+	}
+	writeString := func(s string) {
+		write(uint64(len(s)))
+		buf.WriteString(s)
+	}
+
+	buf.WriteString("binf")
+	// LearnerModelParam
+	write(float32(0.5))
+	write(uint32(2))
+	write(int32(0))
+	write(make([]int32, 31))
+	writeString("binary:logistic")
+	writeString("gbtree")
+	// GBTreeModelParam
+	write(int32(1))
+	write(make([]byte, 156))
+	// TreeParam
+	write(int32(1))
+	write(int32(3))
+	write(make([]int32, 3))
+	write(int32(0))
+	write(make([]int32, 31))
+	// nodes: parent, left, right, split index, split condition or leaf value
+	write([]int32{-1, 1, 2})
+	write(uint32(1 | 1<<31))
+	write(float32(0.5))
+	write([]int32{0, -1, -1, 0})
+	write(float32(0.3))
+	write([]int32{0, -1, -1, 0})
+	write(float32(-0.2))
+	// stats: loss_chg, sum_hess, base_weight, leaf_child_cnt
+	write([]float32{1.5, 10, 0, 0})
+	write([]float32{0, 6, 0, 0})
+	write([]float32{0, 4, 0, 0})
+	// tree_info
+	write(int32(0))
+
+	return buf.Bytes()
+}
+
+func TestParseXGBoostBinary(t *testing.T) {
+	target, err := forest.Parse(forest.XGBoostBinary, xgboostBinaryModel(t))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, target.NumFeature)
+	require.Len(t, target.Trees, 1)
+	require.Equal(t, 1.5, target.Trees[0].Nodes[0].Gain)
+	require.Equal(t, 10.0, target.Trees[0].Nodes[0].Cover)
+
+	nan := float32(math.NaN())
+	x := []float32{
+		0, 0.2,
+		0, nan,
+		0, 0.7,
+	}
+	actual := make([]float32, 3)
+	require.NoError(t, target.PredictMargin(actual, x, 3))
+	require.InDeltaSlice(t, []float32{0.3, 0.3, -0.2}, actual, 1e-6)
+
+	require.NoError(t, target.Predict(actual, x, 3))
+	require.InDelta(t, 1/(1+math.Exp(-0.3)), actual[0], 1e-6)
+
+	_, err = forest.Parse(forest.XGBoostBinary, xgboostBinaryModel(t)[:200])
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+}
+
+func TestParseXGBoostUBJSON(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.model")
+	require.NoError(t, err)
+	target, err := forest.Parse(forest.XGBoostUBJSON, data)
+	require.NoError(t, err)
+
+	jsonData, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	expected, err := forest.Parse(forest.XGBoostJSON, jsonData)
+	require.NoError(t, err)
+	require.Equal(t, expected, target)
+
+	names, types, err := forest.ParseFeatures(forest.XGBoostUBJSON, data)
+	require.NoError(t, err)
+	require.Equal(t, expected.FeatureNames, names)
+	require.Equal(t, expected.FeatureTypes, types)
+
+	nRow := 114
+	features := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	expectedScores := foresttest.ReadCSV(t, "../../testdata/score-xgboost.csv")
+	actual := make([]float32, nRow)
+	require.NoError(t, target.Predict(actual, features, nRow))
+	require.InDeltaSlice(t, expectedScores, actual, 1e-5)
+
+	for _, invalid := range [][]byte{
+		nil,
+		data[:len(data)/2],
+		append(slices.Clone(data), 'Z'),
+		[]byte("{U\x01a[$Z#U\x10}"),
+		[]byte("[D\x7f\xf8\x00\x00\x00\x00\x00\x00]"),
+		[]byte("{q}"),
+	} {
+		_, err = forest.Parse(forest.XGBoostUBJSON, invalid)
+		require.ErrorIs(t, err, forest.ErrInvalidModel)
+	}
+}
+
+// xgboostJSONModel returns a model of the trees in JSON with the learner model params.
+func xgboostJSONModel(params string, objective string, trees string, treeInfo string) []byte {
+	return []byte(`{"learner": {
+		"learner_model_param": {` + params + `},
+		"objective": {"name": "` + objective + `"},
+		"gradient_booster": {"name": "gbtree", "model": {"trees": [` + trees + `], "tree_info": ` + treeInfo + `}}
+	}}`)
+}
+
+func TestParseXGBoostJSONCategorical(t *testing.T) {
+	// node 0 sends categories 1 and 3 of feature 0 to the right child, and missing values to the left,
+	// and node 2 compares feature 1 with 0.5
+	data := xgboostJSONModel(`"base_score": "0", "num_feature": "2"`, "reg:squarederror", `{
+		"left_children": [1, -1, 3, -1, -1],
+		"right_children": [2, -1, 4, -1, -1],
+		"split_indices": [0, 0, 1, 0, 0],
+		"split_conditions": [0, 1, 0.5, 2, 4],
+		"default_left": [1, 0, 0, 0, 0],
+		"split_type": [1, 0, 0, 0, 0],
+		"categories": [3, 1],
+		"categories_nodes": [0],
+		"categories_segments": [0],
+		"categories_sizes": [2]
+	}`, `[0]`)
+	target, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+
+	root := target.Trees[0].Nodes[0]
+	require.True(t, root.Categorical)
+	require.Equal(t, []uint32{1, 3}, root.Categories)
+	require.Equal(t, int32(2), root.Left)
+	require.Equal(t, int32(1), root.Right)
+	require.False(t, root.DefaultLeft)
+
+	nan := float32(math.NaN())
+	x := []float32{
+		0, 0,
+		1, 0,
+		3, 1,
+		2, 0,
+		nan, 1,
+	}
+	actual := make([]float32, 5)
+	require.NoError(t, target.PredictMargin(actual, x, 5))
+	require.InDeltaSlice(t, []float32{1, 2, 4, 1, 1}, actual, 1e-6)
+
+	// a categorical split without categories
+	data = bytes.Replace(data, []byte(`"categories_nodes": [0]`), []byte(`"categories_nodes": [2]`), 1)
+	_, err = forest.Parse(forest.XGBoostJSON, data)
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+}
+
+func TestParseXGBoostJSONVectorLeaf(t *testing.T) {
+	// a tree of multi_output_tree, whose leaves are vectors of the 2 targets
+	tree := `{
+		"tree_param": {"size_leaf_vector": "2"},
+		"left_children": [1, -1, -1],
+		"right_children": [2, -1, -1],
+		"split_indices": [0, 0, 0],
+		"split_conditions": [0.5, 0, 0],
+		"default_left": [1, 0, 0],
+		"base_weights": [0, 0, 1, 2, 3, 4]
+	}`
+	data := xgboostJSONModel(`"base_score": "[5E-1,1E0]", "num_feature": "1", "num_target": "2"`,
+		"reg:squarederror", tree+","+tree, `[0, 0]`)
+	target, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, target.NumGroup)
+	require.Equal(t, 2, target.TreesPerIteration)
+	require.Len(t, target.Trees, 4)
+	require.Equal(t, []int{0, 1, 0, 1}, []int{
+		target.Trees[0].Group, target.Trees[1].Group, target.Trees[2].Group, target.Trees[3].Group,
+	})
+
+	x := []float32{0, 1}
+	actual := make([]float32, 4)
+	require.NoError(t, target.PredictMargin(actual, x, 2))
+	require.InDeltaSlice(t, []float32{2.5, 5, 6.5, 9}, actual, 1e-6)
+
+	// the leaf vectors of a tree are not of the targets
+	data = xgboostJSONModel(`"base_score": "0", "num_feature": "1", "num_target": "3"`,
+		"reg:squarederror", tree, `[0]`)
+	_, err = forest.Parse(forest.XGBoostJSON, data)
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+}
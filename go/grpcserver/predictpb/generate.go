@@ -0,0 +1,4 @@
+// Package predictpb is the generated code of the prediction service.
+package predictpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative grpcserver/predictpb/predict.proto
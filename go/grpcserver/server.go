@@ -0,0 +1,240 @@
+// Package grpcserver serves cuml4go predictors with the gRPC PredictionService of package predictpb.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/grpcserver/predictpb"
+)
+
+// ErrDuplicateModel is returned when two models have the same name.
+var ErrDuplicateModel = errors.New("duplicate model name")
+
+// Model is a predictor served under a name.
+type Model struct {
+	Name      string
+	Predictor cuml4go.Predictor
+	// ModelType is the format of the model reported by ModelMetadata, e.g. xgboost_json.
+	ModelType string
+	// NumClasses is reported by ModelMetadata. it is 0 for a regression model.
+	NumClasses int
+}
+
+// Config is the configuration of Server.
+type Config struct {
+	// MaxInFlightPerStream is the number of requests of a stream predicted at once.
+	// it defaults to 1, which keeps the responses in the order of the requests.
+	MaxInFlightPerStream int
+}
+
+// Server implements PredictionService and the standard health service.
+// the health of the whole server and of PredictionService is SERVING until Shutdown.
+// Server is safe for concurrent use.
+type Server struct {
+	predictpb.UnimplementedPredictionServiceServer
+
+	models map[string]*Model
+	config Config
+	health *health.Server
+}
+
+// NewServer returns a server of models. the server does not close the predictors.
+func NewServer(models []Model, config Config) (*Server, error) {
+	if config.MaxInFlightPerStream <= 0 {
+		config.MaxInFlightPerStream = 1
+	}
+
+	s := &Server{
+		models: make(map[string]*Model, len(models)),
+		config: config,
+		health: health.NewServer(),
+	}
+	for i := range models {
+		m := &models[i]
+		if _, ok := s.models[m.Name]; ok {
+			return nil, ErrDuplicateModel
+		}
+		s.models[m.Name] = m
+	}
+
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.health.SetServingStatus(predictpb.PredictionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return s, nil
+}
+
+// Register registers the prediction and the health services to registrar.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	predictpb.RegisterPredictionServiceServer(registrar, s)
+	healthpb.RegisterHealthServer(registrar, s.health)
+}
+
+// Shutdown makes the health service report NOT_SERVING,
+// so that clients stop sending requests before the grpc.Server stops.
+func (s *Server) Shutdown() {
+	s.health.Shutdown()
+}
+
+// Predict predicts the rows of a request.
+func (s *Server) Predict(ctx context.Context, req *predictpb.PredictRequest) (*predictpb.PredictResponse, error) {
+	resp, err := s.predict(ctx, req)
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return resp, nil
+}
+
+// PredictStream predicts every request of the stream with at most MaxInFlightPerStream at once.
+func (s *Server) PredictStream(stream predictpb.PredictionService_PredictStreamServer) error {
+	ctx := stream.Context()
+	slots := make(chan struct{}, s.config.MaxInFlightPerStream)
+
+	var (
+		wg      sync.WaitGroup
+		sendMu  sync.Mutex
+		sendErr error
+	)
+	send := func(resp *predictpb.PredictResponse) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(resp)
+		}
+	}
+
+	var recvErr error
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				recvErr = err
+			}
+			break
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			recvErr = ctx.Err()
+		}
+		if recvErr != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			resp, err := s.predict(ctx, req)
+			if err != nil {
+				st := toStatus(err)
+				resp = &predictpb.PredictResponse{
+					ModelName: req.GetModelName(),
+					Id:        req.GetId(),
+					Error:     &predictpb.Error{Code: int32(st.Code()), Message: st.Message()},
+				}
+			}
+			send(resp)
+		}()
+	}
+	wg.Wait()
+
+	if recvErr != nil {
+		return toStatus(recvErr).Err()
+	}
+	if sendErr != nil {
+		return toStatus(sendErr).Err()
+	}
+	return nil
+}
+
+// ModelMetadata returns the metadata of a model.
+func (s *Server) ModelMetadata(
+	_ context.Context,
+	req *predictpb.ModelMetadataRequest,
+) (*predictpb.ModelMetadataResponse, error) {
+	m, err := s.model(req.GetModelName())
+	if err != nil {
+		return nil, err
+	}
+	return &predictpb.ModelMetadataResponse{
+		ModelName:   m.Name,
+		ModelType:   m.ModelType,
+		NumFeatures: int64(m.Predictor.NumFeatures()),
+		NumClasses:  int64(m.NumClasses),
+	}, nil
+}
+
+func (s *Server) model(name string) (*Model, error) {
+	m, ok := s.models[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown model %q", name)
+	}
+	return m, nil
+}
+
+func (s *Server) predict(ctx context.Context, req *predictpb.PredictRequest) (*predictpb.PredictResponse, error) {
+	m, err := s.model(req.GetModelName())
+	if err != nil {
+		return nil, err
+	}
+
+	numFeatures := m.Predictor.NumFeatures()
+	input := req.GetInput()
+	shape := input.GetShape()
+	if len(shape) != 2 || shape[0] <= 0 || shape[1] != int64(numFeatures) {
+		return nil, status.Errorf(codes.InvalidArgument, "input shape %v, expected [N %d]", shape, numFeatures)
+	}
+	numRow := int(shape[0])
+	if len(input.GetData()) != numRow*numFeatures {
+		return nil, status.Errorf(codes.InvalidArgument, "%d values for input shape %v", len(input.GetData()), shape)
+	}
+
+	preds, err := m.Predictor.PredictContext(ctx, input.GetData(), numRow, req.GetOutputClassProbability())
+	if err != nil {
+		return nil, err
+	}
+
+	outputShape := []int64{int64(numRow)}
+	if req.GetOutputClassProbability() {
+		outputShape = append(outputShape, 2)
+	}
+	return &predictpb.PredictResponse{
+		ModelName: m.Name,
+		Id:        req.GetId(),
+		Output:    &predictpb.Tensor{Shape: outputShape, Data: preds},
+	}, nil
+}
+
+// toStatus converts an error to a gRPC status.
+func toStatus(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, cuml4go.ErrDimensionMismatch):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, cuml4go.ErrFILModelClosed), errors.Is(err, cuml4go.ErrReloadableModelClosed),
+		errors.Is(err, cuml4go.ErrBatcherClosed), errors.Is(err, cuml4go.ErrResourcesClosed),
+		errors.Is(err, cuml4go.ErrOutOfMemory):
+		return status.New(codes.Unavailable, err.Error())
+	default:
+		return status.New(codes.Internal, err.Error())
+	}
+}
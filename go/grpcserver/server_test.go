@@ -0,0 +1,211 @@
+package grpcserver_test
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+	"github.com/getumen/cuml-bindings/go/grpcserver"
+	"github.com/getumen/cuml-bindings/go/grpcserver/predictpb"
+)
+
+const (
+	numRow     = 114
+	numFeature = 30
+)
+
+func readCSV(t *testing.T, path string) []float32 {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+
+	var values []float32
+	for _, record := range records {
+		for _, field := range record {
+			v, err := strconv.ParseFloat(field, 32)
+			require.NoError(t, err)
+			values = append(values, float32(v))
+		}
+	}
+	return values
+}
+
+// newClient serves the xgboost test model as "xgboost" in-process and returns a connection to it.
+func newClient(t *testing.T, config grpcserver.Config) (*grpc.ClientConn, *grpcserver.Server) {
+	t.Helper()
+	model, err := cuml4go.NewFILModel(
+		cuml4go.XGBoostJSON,
+		"../../testdata/xgboost.json",
+		cuml4go.AlgoAuto,
+		true,
+		0.5,
+		cuml4go.Auto,
+		0,
+		1,
+		0,
+		cuml4go.WithBackend(cuml4go.CPUBackend))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, model.Close()) })
+
+	server, err := grpcserver.NewServer([]grpcserver.Model{{
+		Name:       "xgboost",
+		Predictor:  model,
+		ModelType:  "xgboost_json",
+		NumClasses: 2,
+	}}, config)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	server.Register(grpcServer)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, conn.Close()) })
+	return conn, server
+}
+
+func TestPredict(t *testing.T) {
+	conn, _ := newClient(t, grpcserver.Config{})
+	client := predictpb.NewPredictionServiceClient(conn)
+
+	features := readCSV(t, "../../testdata/feature.csv")
+	expectedScores := readCSV(t, "../../testdata/score-xgboost.csv")
+
+	resp, err := client.Predict(context.Background(), &predictpb.PredictRequest{
+		ModelName:              "xgboost",
+		Id:                     "1",
+		Input:                  &predictpb.Tensor{Shape: []int64{numRow, numFeature}, Data: features},
+		OutputClassProbability: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1", resp.GetId())
+	require.Equal(t, []int64{numRow, 2}, resp.GetOutput().GetShape())
+	for i, score := range expectedScores {
+		require.InDelta(t, score, resp.GetOutput().GetData()[2*i+1], 1e-5)
+	}
+
+	resp, err = client.Predict(context.Background(), &predictpb.PredictRequest{
+		ModelName: "xgboost",
+		Input:     &predictpb.Tensor{Shape: []int64{numRow, numFeature}, Data: features},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{numRow}, resp.GetOutput().GetShape())
+
+	_, err = client.Predict(context.Background(), &predictpb.PredictRequest{
+		ModelName: "unknown",
+		Input:     &predictpb.Tensor{Shape: []int64{numRow, numFeature}, Data: features},
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Predict(context.Background(), &predictpb.PredictRequest{
+		ModelName: "xgboost",
+		Input:     &predictpb.Tensor{Shape: []int64{numRow, numFeature}, Data: features[:10]},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPredictStream(t *testing.T) {
+	for _, maxInFlight := range []int{1, 8} {
+		conn, _ := newClient(t, grpcserver.Config{MaxInFlightPerStream: maxInFlight})
+		client := predictpb.NewPredictionServiceClient(conn)
+
+		features := readCSV(t, "../../testdata/feature.csv")
+		expectedScores := readCSV(t, "../../testdata/score-xgboost.csv")
+
+		stream, err := client.PredictStream(context.Background())
+		require.NoError(t, err)
+
+		go func() {
+			for i := 0; i < numRow; i++ {
+				_ = stream.Send(&predictpb.PredictRequest{
+					ModelName:              "xgboost",
+					Id:                     strconv.Itoa(i),
+					Input:                  &predictpb.Tensor{Shape: []int64{1, numFeature}, Data: features[i*numFeature : (i+1)*numFeature]},
+					OutputClassProbability: true,
+				})
+			}
+			// a failed request does not end the stream
+			_ = stream.Send(&predictpb.PredictRequest{ModelName: "xgboost", Id: "invalid"})
+			_ = stream.CloseSend()
+		}()
+
+		seen := make(map[string]bool)
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			seen[resp.GetId()] = true
+
+			if resp.GetId() == "invalid" {
+				require.Equal(t, int32(codes.InvalidArgument), resp.GetError().GetCode())
+				continue
+			}
+			require.Nil(t, resp.GetError())
+			i, err := strconv.Atoi(resp.GetId())
+			require.NoError(t, err)
+			require.InDelta(t, expectedScores[i], resp.GetOutput().GetData()[1], 1e-5)
+		}
+		require.Len(t, seen, numRow+1)
+	}
+}
+
+func TestModelMetadataAndHealth(t *testing.T) {
+	conn, server := newClient(t, grpcserver.Config{})
+
+	metadata, err := predictpb.NewPredictionServiceClient(conn).ModelMetadata(
+		context.Background(),
+		&predictpb.ModelMetadataRequest{ModelName: "xgboost"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "xgboost", metadata.GetModelName())
+	require.Equal(t, "xgboost_json", metadata.GetModelType())
+	require.Equal(t, int64(numFeature), metadata.GetNumFeatures())
+	require.Equal(t, int64(2), metadata.GetNumClasses())
+
+	healthClient := healthpb.NewHealthClient(conn)
+	for _, service := range []string{"", predictpb.PredictionService_ServiceDesc.ServiceName} {
+		resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	server.Shutdown()
+	resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestNewServerDuplicateModel(t *testing.T) {
+	_, err := grpcserver.NewServer([]grpcserver.Model{{Name: "a"}, {Name: "a"}}, grpcserver.Config{})
+	require.ErrorIs(t, err, grpcserver.ErrDuplicateModel)
+}
@@ -1,6 +1,7 @@
 package cuml4go
 
 import (
+	"context"
 	"errors"
 
 	"github.com/getumen/cuml-bindings/go/rawcuml4go"
@@ -18,15 +19,19 @@ const (
 	Array
 )
 
+// kmeansIterationsPerCheck is the number of iterations FitContext runs between cancellation checks.
+const kmeansIterationsPerCheck = 5
+
 type Kmeans struct {
-	deviceResource *rawcuml4go.DeviceResource
-	k              int
-	maxIter        int
-	tol            float64
-	init           KmeansInit
-	metric         Metric
-	seed           int
-	verbosity      LogLevel
+	resources     *Resources
+	ownsResources bool
+	k             int
+	maxIter       int
+	tol           float64
+	init          KmeansInit
+	metric        Metric
+	seed          int
+	verbosity     LogLevel
 }
 
 func NewKmeans(
@@ -37,22 +42,24 @@ func NewKmeans(
 	metric Metric,
 	seed int,
 	verbosity LogLevel,
+	opts ...Option,
 ) (*Kmeans, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	resources, owned, err := newConfig(opts).deviceResources()
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &Kmeans{
-		deviceResource: deviceResource,
-		k:              k,
-		maxIter:        maxIter,
-		tol:            tol,
-		init:           init,
-		metric:         metric,
-		seed:           seed,
-		verbosity:      verbosity,
+		resources:     resources,
+		ownsResources: owned,
+		k:             k,
+		maxIter:       maxIter,
+		tol:           tol,
+		init:          init,
+		metric:        metric,
+		seed:          seed,
+		verbosity:     verbosity,
 	}, nil
 }
 
@@ -70,24 +77,142 @@ func (k *Kmeans) Fit(
 	err error,
 ) {
 
-	labels, centroids, inertia, nIter, err = rawcuml4go.Kmeans(
-		k.deviceResource,
-		x,
-		numRow,
-		numCol,
-		k.k,
-		k.maxIter,
-		k.tol,
-		int(k.init),
-		int(k.metric),
-		k.seed,
-		int(k.verbosity),
-		nil,
-		nil,
-	)
+	err = k.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		labels, centroids, inertia, nIter, err = rawcuml4go.Kmeans(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			k.k,
+			k.maxIter,
+			k.tol,
+			int(k.init),
+			int(k.metric),
+			k.seed,
+			int(k.verbosity),
+			nil,
+			nil,
+		)
+		if err != nil {
+			return ErrKmeans
+		}
+		return nil
+	})
+
+	return
+}
+
+// FitContext is the context-aware variant of Fit.
+// it runs the iterations in blocks of a few iterations, restarting every block
+// from the centroids of the previous one, and checks ctx between blocks.
+func (k *Kmeans) FitContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	numCol int,
+	sampleWeight []float32,
+) (
+	labels []int32,
+	centroids []float32,
+	inertia float32,
+	nIter int32,
+	err error,
+) {
+	err = k.resources.with(ctx, func(deviceResource *rawcuml4go.DeviceResource) error {
+		init := k.init
+		remaining := max(k.maxIter, 1)
+
+		for remaining > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			step := min(kmeansIterationsPerCheck, remaining)
+
+			var (
+				blockIter int32
+				err       error
+			)
+			labels, centroids, inertia, blockIter, err = rawcuml4go.Kmeans(
+				deviceResource,
+				x,
+				numRow,
+				numCol,
+				k.k,
+				step,
+				k.tol,
+				int(init),
+				int(k.metric),
+				k.seed,
+				int(k.verbosity),
+				labels,
+				centroids,
+			)
+			if err != nil {
+				return ErrKmeans
+			}
+
+			nIter += blockIter
+			remaining -= step
+			init = Array
+
+			if int(blockIter) < step {
+				// converged
+				break
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		err = ErrKmeans
+		return nil, nil, 0, 0, err
 	}
 
 	return
 }
+
+// Fit64 is the double precision variant of Fit.
+func (k *Kmeans) Fit64(
+	x []float64,
+	numRow int,
+	numCol int,
+	sampleWeight []float64,
+
+) (
+	labels []int32,
+	centroids []float64,
+	inertia float64,
+	nIter int32,
+	err error,
+) {
+
+	err = k.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		var err error
+		labels, centroids, inertia, nIter, err = rawcuml4go.Kmeans64(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			k.k,
+			k.maxIter,
+			k.tol,
+			int(k.init),
+			int(k.metric),
+			k.seed,
+			int(k.verbosity),
+			nil,
+			nil,
+		)
+		if err != nil {
+			return ErrKmeans
+		}
+		return nil
+	})
+
+	return
+}
+
+// Close frees the device resources unless they are shared by WithResources.
+func (k *Kmeans) Close() error {
+	return closeResources(k.resources, k.ownsResources)
+}
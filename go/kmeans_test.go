@@ -1,6 +1,7 @@
 package cuml4go_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,7 +10,7 @@ import (
 )
 
 func TestKmeans(t *testing.T) {
-
+	skipWithoutNativeLibrary(t)
 	features := csvToFloat32Array(t, "../testdata/feature.csv")
 	featureCol := 30
 	featureRow := 114
@@ -42,3 +43,74 @@ func TestKmeans(t *testing.T) {
 	require.LessOrEqual(t, nIter, int32(10))
 
 }
+
+func TestKmeans64(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	features := csvToFloat64Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+	k := 3
+
+	target, err := cuml4go.NewKmeans(
+		k,
+		10,
+		0.0,
+		cuml4go.KMeansPlusPlus,
+		cuml4go.L2Expanded,
+		42,
+		cuml4go.Info,
+	)
+	require.NoError(t, err)
+
+	labels, centroids, inertia, nIter, err := target.Fit64(
+		features,
+		featureRow,
+		featureCol,
+		nil,
+	)
+
+	require.NoError(t, err)
+
+	require.Equal(t, len(labels), featureRow)
+	require.Equal(t, len(centroids), k*featureCol)
+	require.GreaterOrEqual(t, inertia, 0.0)
+	require.GreaterOrEqual(t, nIter, int32(0))
+	require.LessOrEqual(t, nIter, int32(10))
+
+}
+
+func TestKmeansFitContext(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+	k := 3
+
+	target, err := cuml4go.NewKmeans(
+		k,
+		12,
+		0.0,
+		cuml4go.KMeansPlusPlus,
+		cuml4go.L2Expanded,
+		42,
+		cuml4go.Info,
+	)
+	require.NoError(t, err)
+
+	labels, centroids, inertia, nIter, err := target.FitContext(
+		context.Background(),
+		features,
+		featureRow,
+		featureCol,
+		nil,
+	)
+
+	require.NoError(t, err)
+
+	require.Equal(t, len(labels), featureRow)
+	require.Equal(t, len(centroids), k*featureCol)
+	require.GreaterOrEqual(t, inertia, float32(0.0))
+	require.GreaterOrEqual(t, nIter, int32(0))
+	require.LessOrEqual(t, nIter, int32(12))
+
+}
@@ -0,0 +1,13 @@
+package cuml4go
+
+import "github.com/getumen/cuml-bindings/go/onnx"
+
+// ONNX returns the fitted model as a serialized ONNX LinearRegressor.
+func (m *LinearRegression) ONNX() []byte {
+	return onnx.Linear(m.GetParams(), m.GetIntercept()).Marshal()
+}
+
+// ONNX returns the fitted model as a serialized ONNX LinearRegressor.
+func (m *RidgeRegression) ONNX() []byte {
+	return onnx.Linear(m.GetParams(), m.GetIntercept()).Marshal()
+}
@@ -1,6 +1,9 @@
 package cuml4go
 
 import (
+	"context"
+	"unsafe"
+
 	"github.com/getumen/cuml-bindings/go/rawcuml4go"
 )
 
@@ -13,16 +16,20 @@ const (
 )
 
 type LinearRegression struct {
-	deviceResource *rawcuml4go.DeviceResource
-	raw            *rawcuml4go.LinearRegression
+	resources     *Resources
+	ownsResources bool
+	chunking      chunking
+	raw           *rawcuml4go.LinearRegression
 }
 
 func NewLinearRegression(
 	fitIntercept bool,
 	normalize bool,
 	algo GlmSolverAlgo,
+	opts ...Option,
 ) (*LinearRegression, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	cfg := newConfig(opts)
+	resources, owned, err := cfg.deviceResources()
 
 	if err != nil {
 		return nil, err
@@ -35,8 +42,10 @@ func NewLinearRegression(
 	)
 
 	return &LinearRegression{
-		deviceResource: deviceResource,
-		raw:            raw,
+		resources:     resources,
+		ownsResources: owned,
+		chunking:      cfg.chunking,
+		raw:           raw,
 	}, nil
 }
 
@@ -46,29 +55,27 @@ func (m *LinearRegression) Fit(
 	numCol int,
 	labels []float32,
 ) error {
-	return m.raw.Fit(
-		m.deviceResource,
-		x,
-		numRow,
-		numCol,
-		labels,
-	)
+	return m.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.raw.Fit(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			labels,
+		)
+	})
 }
 
+// Predict returns the prediction result.
+// the input is split into chunks if it exceeds WithMaxRowsPerCall or WithMaxBytesPerCall.
+// result is allocated if it is nil.
 func (m *LinearRegression) Predict(
 	x []float32,
 	numRow int,
 	numCol int,
 	result []float32,
 ) ([]float32, error) {
-
-	return m.raw.Predict(
-		m.deviceResource,
-		x,
-		numRow,
-		numCol,
-		result,
-	)
+	return predictChunked(context.Background(), m.resources, m.chunking, numRow, x, numRow, numCol, result, m.raw.Predict)
 }
 
 func (m *LinearRegression) GetParams() []float32 {
@@ -79,13 +86,124 @@ func (m *LinearRegression) SetParams(coef []float32) {
 	m.raw.SetParams(coef)
 }
 
+func (m *LinearRegression) GetIntercept() float32 {
+	return m.raw.GetIntercept()
+}
+
+func (m *LinearRegression) SetIntercept(intercept float32) {
+	m.raw.SetIntercept(intercept)
+}
+
+// PredictContext is the context-aware variant of Predict.
+// the input is passed to the native call in chunks of rows and ctx is checked between chunks.
+func (m *LinearRegression) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	numCol int,
+	result []float32,
+) ([]float32, error) {
+	return predictChunked(ctx, m.resources, m.chunking, contextChunkRows, x, numRow, numCol, result, m.raw.Predict)
+}
+
+// PredictInto is the same as Predict but writes the result into dst,
+// whose length must be numRow.
+func (m *LinearRegression) PredictInto(
+	dst []float32,
+	x []float32,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(context.Background(), m.resources, m.chunking, numRow, dst, x, numRow, numCol, m.raw.Predict)
+}
+
+// PredictIntoContext is the context-aware variant of PredictInto.
+func (m *LinearRegression) PredictIntoContext(
+	ctx context.Context,
+	dst []float32,
+	x []float32,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(ctx, m.resources, m.chunking, contextChunkRows, dst, x, numRow, numCol, m.raw.Predict)
+}
+
+// PredictCSR returns the prediction result for a sparse input.
+// it is computed on CPU since only the stored entries contribute.
+// result is allocated if it is nil.
+func (m *LinearRegression) PredictCSR(
+	x *CSRMatrix,
+	result []float32,
+) ([]float32, error) {
+	return m.PredictCSRContext(context.Background(), x, result)
+}
+
+// PredictCSRContext is the context-aware variant of PredictCSR.
+func (m *LinearRegression) PredictCSRContext(
+	ctx context.Context,
+	x *CSRMatrix,
+	result []float32,
+) ([]float32, error) {
+	return predictLinearCSR(ctx, x, m.raw.GetParams(), m.raw.GetIntercept(), result)
+}
+
+// Fit64 is the double precision variant of Fit.
+// Parameters fitted by Fit64 are only used by Predict64.
+func (m *LinearRegression) Fit64(
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	return m.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.raw.Fit64(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			labels,
+		)
+	})
+}
+
+// Predict64 is the double precision variant of Predict.
+func (m *LinearRegression) Predict64(
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	return predictChunked(context.Background(), m.resources, m.chunking, numRow, x, numRow, numCol, result, m.raw.Predict64)
+}
+
+// Predict64Into is the double precision variant of PredictInto.
+func (m *LinearRegression) Predict64Into(
+	dst []float64,
+	x []float64,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(context.Background(), m.resources, m.chunking, numRow, dst, x, numRow, numCol, m.raw.Predict64)
+}
+
+func (m *LinearRegression) GetParams64() []float64 {
+	return m.raw.GetParams64()
+}
+
+func (m *LinearRegression) SetParams64(coef []float64) {
+	m.raw.SetParams64(coef)
+}
+
+// Close frees the device resources unless they are shared by WithResources.
 func (m *LinearRegression) Close() error {
-	return m.deviceResource.Close()
+	return closeResources(m.resources, m.ownsResources)
 }
 
 type RidgeRegression struct {
-	deviceResource *rawcuml4go.DeviceResource
-	raw            *rawcuml4go.RidgeRegression
+	resources     *Resources
+	ownsResources bool
+	chunking      chunking
+	raw           *rawcuml4go.RidgeRegression
 }
 
 func NewRidgeRegression(
@@ -93,8 +211,10 @@ func NewRidgeRegression(
 	fitIntercept bool,
 	normalize bool,
 	algo GlmSolverAlgo,
+	opts ...Option,
 ) (*RidgeRegression, error) {
-	deviceResource, err := rawcuml4go.NewDeviceResource()
+	cfg := newConfig(opts)
+	resources, owned, err := cfg.deviceResources()
 
 	if err != nil {
 		return nil, err
@@ -108,8 +228,10 @@ func NewRidgeRegression(
 	)
 
 	return &RidgeRegression{
-		deviceResource: deviceResource,
-		raw:            raw,
+		resources:     resources,
+		ownsResources: owned,
+		chunking:      cfg.chunking,
+		raw:           raw,
 	}, nil
 }
 
@@ -119,28 +241,27 @@ func (m *RidgeRegression) Fit(
 	numCol int,
 	labels []float32,
 ) error {
-	return m.raw.Fit(
-		m.deviceResource,
-		x,
-		numRow,
-		numCol,
-		labels,
-	)
+	return m.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.raw.Fit(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			labels,
+		)
+	})
 }
 
+// Predict returns the prediction result.
+// the input is split into chunks if it exceeds WithMaxRowsPerCall or WithMaxBytesPerCall.
+// result is allocated if it is nil.
 func (m *RidgeRegression) Predict(
 	x []float32,
 	numRow int,
 	numCol int,
 	result []float32,
 ) ([]float32, error) {
-	return m.raw.Predict(
-		m.deviceResource,
-		x,
-		numRow,
-		numCol,
-		result,
-	)
+	return predictChunked(context.Background(), m.resources, m.chunking, numRow, x, numRow, numCol, result, m.raw.Predict)
 }
 
 func (m *RidgeRegression) GetParams() []float32 {
@@ -151,6 +272,217 @@ func (m *RidgeRegression) SetParams(coef []float32) {
 	m.raw.SetParams(coef)
 }
 
+func (m *RidgeRegression) GetIntercept() float32 {
+	return m.raw.GetIntercept()
+}
+
+func (m *RidgeRegression) SetIntercept(intercept float32) {
+	m.raw.SetIntercept(intercept)
+}
+
+// PredictContext is the context-aware variant of Predict.
+// the input is passed to the native call in chunks of rows and ctx is checked between chunks.
+func (m *RidgeRegression) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	numCol int,
+	result []float32,
+) ([]float32, error) {
+	return predictChunked(ctx, m.resources, m.chunking, contextChunkRows, x, numRow, numCol, result, m.raw.Predict)
+}
+
+// PredictInto is the same as Predict but writes the result into dst,
+// whose length must be numRow.
+func (m *RidgeRegression) PredictInto(
+	dst []float32,
+	x []float32,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(context.Background(), m.resources, m.chunking, numRow, dst, x, numRow, numCol, m.raw.Predict)
+}
+
+// PredictIntoContext is the context-aware variant of PredictInto.
+func (m *RidgeRegression) PredictIntoContext(
+	ctx context.Context,
+	dst []float32,
+	x []float32,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(ctx, m.resources, m.chunking, contextChunkRows, dst, x, numRow, numCol, m.raw.Predict)
+}
+
+// PredictCSR returns the prediction result for a sparse input.
+// it is computed on CPU since only the stored entries contribute.
+// result is allocated if it is nil.
+func (m *RidgeRegression) PredictCSR(
+	x *CSRMatrix,
+	result []float32,
+) ([]float32, error) {
+	return m.PredictCSRContext(context.Background(), x, result)
+}
+
+// PredictCSRContext is the context-aware variant of PredictCSR.
+func (m *RidgeRegression) PredictCSRContext(
+	ctx context.Context,
+	x *CSRMatrix,
+	result []float32,
+) ([]float32, error) {
+	return predictLinearCSR(ctx, x, m.raw.GetParams(), m.raw.GetIntercept(), result)
+}
+
+// Fit64 is the double precision variant of Fit.
+// Parameters fitted by Fit64 are only used by Predict64.
+func (m *RidgeRegression) Fit64(
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	return m.resources.with(context.Background(), func(deviceResource *rawcuml4go.DeviceResource) error {
+		return m.raw.Fit64(
+			deviceResource,
+			x,
+			numRow,
+			numCol,
+			labels,
+		)
+	})
+}
+
+// Predict64 is the double precision variant of Predict.
+func (m *RidgeRegression) Predict64(
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	return predictChunked(context.Background(), m.resources, m.chunking, numRow, x, numRow, numCol, result, m.raw.Predict64)
+}
+
+// Predict64Into is the double precision variant of PredictInto.
+func (m *RidgeRegression) Predict64Into(
+	dst []float64,
+	x []float64,
+	numRow int,
+	numCol int,
+) error {
+	return predictInto(context.Background(), m.resources, m.chunking, numRow, dst, x, numRow, numCol, m.raw.Predict64)
+}
+
+func (m *RidgeRegression) GetParams64() []float64 {
+	return m.raw.GetParams64()
+}
+
+func (m *RidgeRegression) SetParams64(coef []float64) {
+	m.raw.SetParams64(coef)
+}
+
+// Close frees the device resources unless they are shared by WithResources.
 func (m *RidgeRegression) Close() error {
-	return m.deviceResource.Close()
+	return closeResources(m.resources, m.ownsResources)
+}
+
+func predictLinearCSR(
+	ctx context.Context,
+	x *CSRMatrix,
+	coef []float32,
+	intercept float32,
+	result []float32,
+) ([]float32, error) {
+	if err := x.Validate(); err != nil {
+		return nil, err
+	}
+	if x.NumCol != len(coef) {
+		return nil, ErrDimensionMismatch
+	}
+
+	if result == nil {
+		result = make([]float32, x.NumRow())
+	}
+
+	err := forEachChunk(ctx, x.NumRow(), contextChunkRows, func(start, end int) error {
+		for r := start; r < end; r++ {
+			result[r] = x.rowDot(r, coef) + intercept
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// predictChunked runs predict for chunks of rows of x with a handle borrowed from resources,
+// writing into result. defaultRows is the number of rows per call unless chunking limits it.
+func predictChunked[T float32 | float64](
+	ctx context.Context,
+	resources *Resources,
+	chunking chunking,
+	defaultRows int,
+	x []T,
+	numRow int,
+	numCol int,
+	result []T,
+	predict func(
+		deviceResource *rawcuml4go.DeviceResource,
+		x []T,
+		numRow int,
+		numCol int,
+		result []T,
+	) ([]T, error),
+) ([]T, error) {
+	if result == nil {
+		result = make([]T, numRow)
+	}
+
+	rowBytes := numCol * int(unsafe.Sizeof(T(0)))
+	err := resources.with(ctx, func(deviceResource *rawcuml4go.DeviceResource) error {
+		return chunking.forEachChunk(ctx, numRow, rowBytes, defaultRows, func(start, end int) error {
+			_, err := predict(
+				deviceResource,
+				x[start*numCol:end*numCol],
+				end-start,
+				numCol,
+				result[start:end],
+			)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// predictInto is predictChunked with validated input and output lengths.
+func predictInto[T float32 | float64](
+	ctx context.Context,
+	resources *Resources,
+	chunking chunking,
+	defaultRows int,
+	dst []T,
+	x []T,
+	numRow int,
+	numCol int,
+	predict func(
+		deviceResource *rawcuml4go.DeviceResource,
+		x []T,
+		numRow int,
+		numCol int,
+		result []T,
+	) ([]T, error),
+) error {
+	if len(x) != numRow*numCol {
+		return ErrDimensionMismatch
+	}
+	if len(dst) != numRow {
+		return ErrInvalidOutputLength
+	}
+
+	_, err := predictChunked(ctx, resources, chunking, defaultRows, x, numRow, numCol, dst, predict)
+	return err
 }
@@ -8,7 +8,7 @@ import (
 )
 
 func TestLinearRegression(t *testing.T) {
-
+	skipWithoutNativeLibrary(t)
 	target, err := cuml4go.NewLinearRegression(
 		true,
 		false,
@@ -33,6 +33,7 @@ func TestLinearRegression(t *testing.T) {
 }
 
 func TestRidgeRegression(t *testing.T) {
+	skipWithoutNativeLibrary(t)
 	target, err := cuml4go.NewRidgeRegression(
 		0.5,
 		true,
@@ -56,3 +57,66 @@ func TestRidgeRegression(t *testing.T) {
 
 	require.Equal(t, len(labels), len(preds))
 }
+
+func TestLinearRegression64(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	target, err := cuml4go.NewLinearRegression(
+		true,
+		false,
+		cuml4go.Svd,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	features := csvToFloat64Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	labels := csvToFloat64Array(t, "../testdata/label.csv")
+
+	err = target.Fit64(features, featureRow, featureCol, labels)
+	require.NoError(t, err)
+
+	preds, err := target.Predict64(features, featureRow, featureCol, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, len(labels), len(preds))
+	require.Len(t, target.GetParams64(), featureCol)
+
+	dst := make([]float64, featureRow)
+	require.NoError(t, target.Predict64Into(dst, features, featureRow, featureCol))
+	require.Equal(t, preds, dst)
+	require.ErrorIs(t, target.Predict64Into(dst[1:], features, featureRow, featureCol), cuml4go.ErrInvalidOutputLength)
+}
+
+func TestRidgeRegression64(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	target, err := cuml4go.NewRidgeRegression(
+		0.5,
+		true,
+		false,
+		cuml4go.Svd,
+	)
+	require.NoError(t, err)
+	defer target.Close()
+
+	features := csvToFloat64Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	labels := csvToFloat64Array(t, "../testdata/label.csv")
+
+	err = target.Fit64(features, featureRow, featureCol, labels)
+	require.NoError(t, err)
+
+	preds, err := target.Predict64(features, featureRow, featureCol, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, len(labels), len(preds))
+	require.Len(t, target.GetParams64(), featureCol)
+
+	dst := make([]float64, featureRow)
+	require.NoError(t, target.Predict64Into(dst, features, featureRow, featureCol))
+	require.Equal(t, preds, dst)
+	require.ErrorIs(t, target.Predict64Into(dst[1:], features, featureRow, featureCol), cuml4go.ErrInvalidOutputLength)
+}
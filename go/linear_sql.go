@@ -0,0 +1,15 @@
+package cuml4go
+
+import "github.com/getumen/cuml-bindings/go/sqlgen"
+
+// SQL returns the prediction of the fitted model as a SQL expression of the feature columns,
+// which the columns of config name in the order of the coefficients.
+func (m *LinearRegression) SQL(config sqlgen.Config) (string, error) {
+	return sqlgen.Linear(m.GetParams(), m.GetIntercept(), config)
+}
+
+// SQL returns the prediction of the fitted model as a SQL expression of the feature columns,
+// which the columns of config name in the order of the coefficients.
+func (m *RidgeRegression) SQL(config sqlgen.Config) (string, error) {
+	return sqlgen.Linear(m.GetParams(), m.GetIntercept(), config)
+}
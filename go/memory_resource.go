@@ -0,0 +1,175 @@
+package cuml4go
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+var (
+	// ErrInvalidMemoryConfig is returned when a memory config is invalid.
+	ErrInvalidMemoryConfig = errors.New("invalid memory config")
+	// ErrMemoryResourceRestoreOrder is returned when a memory resource is restored
+	// while a resource set after it is still active.
+	ErrMemoryResourceRestoreOrder = errors.New("memory resources must be restored in reverse order")
+	// ErrNoMemoryResource is returned when no memory resource is set by SetMemoryResource.
+	ErrNoMemoryResource = errors.New("no memory resource is set")
+)
+
+// memoryAlignment is the alignment of the device allocations, in bytes.
+const memoryAlignment = 256
+
+// MemoryResourceType is the type of the device memory resource.
+type MemoryResourceType int
+
+const (
+	// PoolMemoryResource coalescing pool suballocator;
+	// allocations are served from a pool which grows up to the maximum size
+	PoolMemoryResource MemoryResourceType = iota
+	// BinningMemoryResource allocations are served from fixed size bins
+	// of powers of two between 2^MinSizeExponent and 2^MaxSizeExponent bytes;
+	// larger allocations are served by the previous resource
+	BinningMemoryResource
+	// ArenaMemoryResource allocations are served from a global arena
+	// and per-thread arenas to reduce fragmentation and contention
+	ArenaMemoryResource
+)
+
+// MemoryConfig is the configuration of the device memory resource.
+type MemoryConfig struct {
+	Type MemoryResourceType
+
+	// InitialPoolSize is the initial size of the pool in bytes. used by PoolMemoryResource.
+	InitialPoolSize uint64
+	// MaximumPoolSize is the maximum size of the pool in bytes. used by PoolMemoryResource.
+	MaximumPoolSize uint64
+
+	// MinSizeExponent is the exponent of the smallest bin. used by BinningMemoryResource.
+	MinSizeExponent uint8
+	// MaxSizeExponent is the exponent of the largest bin. used by BinningMemoryResource.
+	MaxSizeExponent uint8
+
+	// ArenaSize is the size of the global arena in bytes. used by ArenaMemoryResource.
+	ArenaSize uint64
+}
+
+// Validate checks the configuration.
+func (c MemoryConfig) Validate() error {
+	switch c.Type {
+	case PoolMemoryResource:
+		if c.InitialPoolSize == 0 || c.InitialPoolSize%memoryAlignment != 0 {
+			return ErrInvalidMemoryConfig
+		}
+		if c.MaximumPoolSize < c.InitialPoolSize || c.MaximumPoolSize%memoryAlignment != 0 {
+			return ErrInvalidMemoryConfig
+		}
+	case BinningMemoryResource:
+		if c.MinSizeExponent > c.MaxSizeExponent || c.MaxSizeExponent >= 64 {
+			return ErrInvalidMemoryConfig
+		}
+	case ArenaMemoryResource:
+		if c.ArenaSize == 0 || c.ArenaSize%memoryAlignment != 0 {
+			return ErrInvalidMemoryConfig
+		}
+	default:
+		return ErrInvalidMemoryConfig
+	}
+	return nil
+}
+
+// MemoryStatistics is the usage of the memory resource set by SetMemoryResource.
+type MemoryStatistics struct {
+	// AllocatedBytes is the number of bytes currently allocated.
+	AllocatedBytes uint64
+	// PeakBytes is the maximum of AllocatedBytes since the resource is set.
+	PeakBytes uint64
+	// PoolSize is the current size of the pool. valid only if HasPoolSize is true.
+	PoolSize uint64
+	// HasPoolSize is true if the resource is a PoolMemoryResource.
+	HasPoolSize bool
+}
+
+var (
+	memoryResourceMu sync.Mutex
+	// memoryResources is the stack of the resources set by SetMemoryResource.
+	memoryResources []*rawcuml4go.MemoryResource
+)
+
+// SetMemoryResource sets the process-wide device memory resource.
+// the resource is used by every estimator, and is stacked on the resource which was current before.
+// restore frees the resource and restores the previous one;
+// when resources are set more than once, they must be restored in reverse order.
+// every allocation served by the resource must be freed before restore, i.e. the estimators
+// and models which allocated device memory while it was current must be closed.
+func SetMemoryResource(config MemoryConfig) (restore func() error, err error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	memoryResourceMu.Lock()
+	defer memoryResourceMu.Unlock()
+
+	var resource *rawcuml4go.MemoryResource
+	switch config.Type {
+	case PoolMemoryResource:
+		resource, err = rawcuml4go.UsePoolMemoryResource(config.InitialPoolSize, config.MaximumPoolSize)
+	case BinningMemoryResource:
+		resource, err = rawcuml4go.UseBinningMemoryResource(config.MinSizeExponent, config.MaxSizeExponent)
+	case ArenaMemoryResource:
+		resource, err = rawcuml4go.UseArenaMemoryResource(config.ArenaSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	memoryResources = append(memoryResources, resource)
+
+	restore = func() error {
+		return restoreMemoryResource(resource)
+	}
+	return restore, nil
+}
+
+// restoreMemoryResource frees resource if it is on the top of the stack.
+// it does nothing if resource is already restored.
+func restoreMemoryResource(resource *rawcuml4go.MemoryResource) error {
+	memoryResourceMu.Lock()
+	defer memoryResourceMu.Unlock()
+
+	if !slices.Contains(memoryResources, resource) {
+		return nil
+	}
+	if memoryResources[len(memoryResources)-1] != resource {
+		return ErrMemoryResourceRestoreOrder
+	}
+
+	if err := resource.Close(); err != nil {
+		return err
+	}
+	memoryResources = memoryResources[:len(memoryResources)-1]
+	return nil
+}
+
+// CurrentMemoryStatistics returns the statistics of the resource set by SetMemoryResource.
+func CurrentMemoryStatistics() (MemoryStatistics, error) {
+	memoryResourceMu.Lock()
+	defer memoryResourceMu.Unlock()
+
+	if len(memoryResources) == 0 {
+		return MemoryStatistics{}, ErrNoMemoryResource
+	}
+
+	allocated, peak, poolSize, hasPoolSize, err := memoryResources[len(memoryResources)-1].Statistics()
+	if err != nil {
+		return MemoryStatistics{}, err
+	}
+
+	return MemoryStatistics{
+		AllocatedBytes: allocated,
+		PeakBytes:      peak,
+		PoolSize:       poolSize,
+		HasPoolSize:    hasPoolSize,
+	}, nil
+}
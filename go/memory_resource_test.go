@@ -0,0 +1,79 @@
+package cuml4go_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestMemoryConfigValidate(t *testing.T) {
+	valid := []cuml4go.MemoryConfig{
+		{Type: cuml4go.PoolMemoryResource, InitialPoolSize: 1 << 20, MaximumPoolSize: 8 << 20},
+		{Type: cuml4go.BinningMemoryResource, MinSizeExponent: 10, MaxSizeExponent: 20},
+		{Type: cuml4go.ArenaMemoryResource, ArenaSize: 1 << 20},
+	}
+	for _, config := range valid {
+		require.NoError(t, config.Validate())
+	}
+
+	invalid := []cuml4go.MemoryConfig{
+		{Type: cuml4go.PoolMemoryResource},
+		{Type: cuml4go.PoolMemoryResource, InitialPoolSize: 8 << 20, MaximumPoolSize: 1 << 20},
+		{Type: cuml4go.PoolMemoryResource, InitialPoolSize: 1000, MaximumPoolSize: 8 << 20},
+		{Type: cuml4go.BinningMemoryResource, MinSizeExponent: 20, MaxSizeExponent: 10},
+		{Type: cuml4go.ArenaMemoryResource},
+		{Type: cuml4go.MemoryResourceType(-1)},
+	}
+	for _, config := range invalid {
+		require.ErrorIs(t, config.Validate(), cuml4go.ErrInvalidMemoryConfig)
+	}
+
+	_, err := cuml4go.SetMemoryResource(invalid[0])
+	require.ErrorIs(t, err, cuml4go.ErrInvalidMemoryConfig)
+}
+
+func TestSetMemoryResource(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	restorePool, err := cuml4go.SetMemoryResource(cuml4go.MemoryConfig{
+		Type:            cuml4go.PoolMemoryResource,
+		InitialPoolSize: 1 << 20,
+		MaximumPoolSize: 8 << 20,
+	})
+	require.NoError(t, err)
+
+	target, err := cuml4go.NewKmeans(3, 10, 0.0, cuml4go.KMeansPlusPlus, cuml4go.L2Expanded, 42, cuml4go.Info)
+	require.NoError(t, err)
+
+	_, _, _, _, err = target.Fit(csvToFloat32Array(t, "../testdata/feature.csv"), 114, 30, nil)
+	require.NoError(t, err)
+
+	stats, err := cuml4go.CurrentMemoryStatistics()
+	require.NoError(t, err)
+	require.True(t, stats.HasPoolSize)
+	require.GreaterOrEqual(t, stats.PoolSize, uint64(1<<20))
+	require.Greater(t, stats.PeakBytes, uint64(0))
+
+	// the allocations of the estimator are freed before the pool is restored
+	require.NoError(t, target.Close())
+
+	restoreBinning, err := cuml4go.SetMemoryResource(cuml4go.MemoryConfig{
+		Type:            cuml4go.BinningMemoryResource,
+		MinSizeExponent: 10,
+		MaxSizeExponent: 20,
+	})
+	require.NoError(t, err)
+
+	stats, err = cuml4go.CurrentMemoryStatistics()
+	require.NoError(t, err)
+	require.False(t, stats.HasPoolSize)
+
+	require.ErrorIs(t, restorePool(), cuml4go.ErrMemoryResourceRestoreOrder)
+	require.NoError(t, restoreBinning())
+	require.NoError(t, restorePool())
+	require.NoError(t, restorePool())
+
+	_, err = cuml4go.CurrentMemoryStatistics()
+	require.ErrorIs(t, err, cuml4go.ErrNoMemoryResource)
+}
@@ -0,0 +1,403 @@
+package onnx
+
+import (
+	"errors"
+	"math"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// ErrUnsupportedModel is returned when a model has no equivalent of the supported operators.
+var ErrUnsupportedModel = errors.New("unsupported model")
+
+const (
+	// MLDomain is the domain of the classical ML operators.
+	MLDomain = "ai.onnx.ml"
+
+	// InputName is the name of the input of an exported model, a float tensor of shape [N, numFeature].
+	InputName = "input"
+	// PredictionName is the output of a regressor, a float tensor of shape [N, numTarget].
+	PredictionName = "prediction"
+	// LabelName is the output of the classes of a classifier, an int64 tensor of shape [N].
+	LabelName = "label"
+	// ProbabilitiesName is the output of the scores of the classes of a classifier, a float tensor of shape [N, numClass].
+	ProbabilitiesName = "probabilities"
+)
+
+// newModel returns a model of the operators of the default domain of opset 13 and those of MLDomain of opset 1,
+// which every runtime of the classical ML operators supports.
+func newModel(name string, nodes []Node, numFeature int, outputs []ValueInfo) *Model {
+	return &Model{
+		IRVersion: 7,
+		OpsetImports: []OperatorSetID{
+			{Domain: "", Version: 13},
+			{Domain: MLDomain, Version: 1},
+		},
+		ProducerName: "cuml4go",
+		Graph: Graph{
+			Name:  name,
+			Nodes: nodes,
+			Inputs: []ValueInfo{
+				{Name: InputName, ElemType: TypeFloat, Shape: []Dimension{{Param: "N"}, {Value: int64(numFeature)}}},
+			},
+			Outputs: outputs,
+		},
+	}
+}
+
+func regressorOutput(numTarget int) []ValueInfo {
+	return []ValueInfo{
+		{Name: PredictionName, ElemType: TypeFloat, Shape: []Dimension{{Param: "N"}, {Value: int64(numTarget)}}},
+	}
+}
+
+func classifierOutputs(numClass int) []ValueInfo {
+	return []ValueInfo{
+		{Name: LabelName, ElemType: TypeInt64, Shape: []Dimension{{Param: "N"}}},
+		{Name: ProbabilitiesName, ElemType: TypeFloat, Shape: []Dimension{{Param: "N"}, {Value: int64(numClass)}}},
+	}
+}
+
+// Linear returns a LinearRegressor of the prediction intercept + coef[0] * x0 + coef[1] * x1 + ...,
+// e.g. of the parameters of cuml4go.LinearRegression.
+func Linear(coef []float32, intercept float32) *Model {
+	node := Node{
+		Name:    "LinearRegressor",
+		OpType:  "LinearRegressor",
+		Domain:  MLDomain,
+		Inputs:  []string{InputName},
+		Outputs: []string{PredictionName},
+		Attributes: []Attribute{
+			floatsAttribute("coefficients", coef),
+			floatsAttribute("intercepts", []float32{intercept}),
+			intAttribute("targets", 1),
+		},
+	}
+	return newModel("linear", []Node{node}, len(coef), regressorOutput(1))
+}
+
+// Logistic returns a LinearClassifier of a binary logistic regression,
+// whose probability of class 1 is sigmoid(intercept + coef[0] * x0 + coef[1] * x1 + ...).
+// class 0 has the negated coefficients, so that the probabilities of the classes sum to 1.
+func Logistic(coef []float32, intercept float32) *Model {
+	coefficients := make([]float32, 2*len(coef))
+	for i, c := range coef {
+		coefficients[i] = -c
+		coefficients[len(coef)+i] = c
+	}
+	node := Node{
+		Name:    "LinearClassifier",
+		OpType:  "LinearClassifier",
+		Domain:  MLDomain,
+		Inputs:  []string{InputName},
+		Outputs: []string{LabelName, ProbabilitiesName},
+		Attributes: []Attribute{
+			intsAttribute("classlabels_ints", []int64{0, 1}),
+			floatsAttribute("coefficients", coefficients),
+			floatsAttribute("intercepts", []float32{-intercept, intercept}),
+			stringAttribute("post_transform", "LOGISTIC"),
+		},
+	}
+	return newModel("logistic", []Node{node}, len(coef), classifierOutputs(2))
+}
+
+// Forest returns a tree ensemble of f, whose outputs are those of f.Predict:
+//
+//   - Softmax is a TreeEnsembleClassifier of a class per group, with the probabilities of the classes;
+//   - MaxIndex is a TreeEnsembleClassifier whose label is the index;
+//   - Sigmoid and Hinge of a group are a TreeEnsembleClassifier of two classes, where the probability
+//     or label of class 1 is the output and class 0 has the negated margin;
+//   - the others are a TreeEnsembleRegressor of a target per group, followed by Exp for Exponential.
+//
+// missing values are NaN. the splits of forest.MissingZero and categorical splits become several nodes,
+// and categorical splits compare the values with the categories exactly.
+func Forest(f *forest.Forest) (*Model, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	// alpha scales the margins, and negated adds the negated margin as class 0 of a binary classifier
+	alpha, negated := 1.0, false
+	classifier, postTransform := false, "NONE"
+	switch f.PostTransform {
+	case forest.Identity, forest.Exponential:
+	case forest.Softmax:
+		classifier, postTransform = true, "SOFTMAX"
+	case forest.MaxIndex:
+		classifier = true
+	case forest.Sigmoid:
+		alpha, postTransform = f.SigmoidAlpha, "LOGISTIC"
+		classifier, negated = f.NumGroup == 1, f.NumGroup == 1
+	case forest.Hinge:
+		if f.NumGroup != 1 {
+			return nil, ErrUnsupportedModel
+		}
+		classifier, negated = true, true
+	default:
+		return nil, ErrUnsupportedModel
+	}
+
+	e := newEnsemble(f, alpha, negated)
+	base := make([]float32, 0, 2*f.NumGroup)
+	if negated {
+		base = append(base, float32(-alpha*f.BaseScore[0]))
+	}
+	for _, b := range f.BaseScore {
+		base = append(base, float32(alpha*b))
+	}
+	numOutput := len(base)
+
+	attributes := e.nodeAttributes()
+	attributes = append(attributes,
+		floatsAttribute("base_values", base),
+		stringAttribute("post_transform", postTransform),
+	)
+	if classifier {
+		labels := make([]int64, numOutput)
+		for i := range labels {
+			labels[i] = int64(i)
+		}
+		attributes = append(attributes,
+			intsAttribute("classlabels_int64s", labels),
+			intsAttribute("class_treeids", e.leafTreeIDs),
+			intsAttribute("class_nodeids", e.leafNodeIDs),
+			intsAttribute("class_ids", e.leafTargets),
+			floatsAttribute("class_weights", e.leafWeights),
+		)
+		node := Node{
+			Name:       "TreeEnsembleClassifier",
+			OpType:     "TreeEnsembleClassifier",
+			Domain:     MLDomain,
+			Inputs:     []string{InputName},
+			Outputs:    []string{LabelName, ProbabilitiesName},
+			Attributes: attributes,
+		}
+		return newModel("forest", []Node{node}, f.NumFeature, classifierOutputs(numOutput)), nil
+	}
+
+	attributes = append(attributes,
+		intAttribute("n_targets", int64(numOutput)),
+		intsAttribute("target_treeids", e.leafTreeIDs),
+		intsAttribute("target_nodeids", e.leafNodeIDs),
+		intsAttribute("target_ids", e.leafTargets),
+		floatsAttribute("target_weights", e.leafWeights),
+		stringAttribute("aggregate_function", "SUM"),
+	)
+	regressor := Node{
+		Name:       "TreeEnsembleRegressor",
+		OpType:     "TreeEnsembleRegressor",
+		Domain:     MLDomain,
+		Inputs:     []string{InputName},
+		Outputs:    []string{PredictionName},
+		Attributes: attributes,
+	}
+	nodes := []Node{regressor}
+	if f.PostTransform == forest.Exponential {
+		nodes[0].Outputs = []string{"margin"}
+		nodes = append(nodes, Node{Name: "Exp", OpType: "Exp", Inputs: []string{"margin"}, Outputs: []string{PredictionName}})
+	}
+	return newModel("forest", nodes, f.NumFeature, regressorOutput(numOutput)), nil
+}
+
+// ensemble is the attributes of the nodes and leaves of a tree ensemble.
+type ensemble struct {
+	treeIDs, nodeIDs, featureIDs []int64
+	modes                        [][]byte
+	values                       []float32
+	trueIDs, falseIDs            []int64
+	missingTracksTrue            []int64
+
+	leafTreeIDs, leafNodeIDs, leafTargets []int64
+	leafWeights                           []float32
+}
+
+func newEnsemble(f *forest.Forest, alpha float64, negated bool) *ensemble {
+	// the weights of the trees of a group, which average them for random forests
+	scale := make([]float64, f.NumGroup)
+	for i := range scale {
+		scale[i] = alpha
+	}
+	if f.AverageTreeOutput {
+		count := make([]int, f.NumGroup)
+		for _, t := range f.Trees {
+			count[t.Group]++
+		}
+		for g, n := range count {
+			if n > 0 {
+				scale[g] /= float64(n)
+			}
+		}
+	}
+
+	e := &ensemble{}
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		tree := int64(i)
+		// a node becomes several nodes, which are numbered from the entry of the node
+		entry := make([]int64, len(t.Nodes))
+		next := int64(0)
+		for j := range t.Nodes {
+			entry[j] = next
+			next += int64(nodeCount(&t.Nodes[j]))
+		}
+
+		for j := range t.Nodes {
+			n := &t.Nodes[j]
+			id := entry[j]
+			switch {
+			case n.IsLeaf():
+				e.add(tree, id, 0, "LEAF", 0, 0, 0, false)
+				weight := n.Value * scale[t.Group]
+				target := int64(t.Group)
+				if negated {
+					e.addLeaf(tree, id, 0, float32(-weight))
+					target++
+				}
+				e.addLeaf(tree, id, target, float32(weight))
+			case n.Categorical:
+				set, nanLeft := categorySet(n)
+				if len(set) == 0 {
+					// a comparison with NaN is false, so only the missing values go left
+					e.add(tree, id, int64(n.Feature), "BRANCH_EQ", float32(math.NaN()), entry[n.Left], entry[n.Right], nanLeft)
+					continue
+				}
+				for k, c := range set {
+					falseID := id + int64(k) + 1
+					if k == len(set)-1 {
+						falseID = entry[n.Right]
+					}
+					e.add(tree, id+int64(k), int64(n.Feature), "BRANCH_EQ", float32(c), entry[n.Left], falseID, k == 0 && nanLeft)
+				}
+			case n.Missing == forest.MissingZero:
+				// NaN and the values within the zero threshold go to the default child, and the others are compared
+				test := id + 2
+				e.add(tree, id, int64(n.Feature), "BRANCH_LEQ", floorFloat32(forest.ZeroThreshold), id+1, test, true)
+				e.add(tree, id+1, int64(n.Feature), "BRANCH_GTE", -floorFloat32(forest.ZeroThreshold), entry[n.DefaultChild()], test, true)
+				e.addSplit(tree, test, n, entry, false)
+			case n.Missing == forest.MissingNone:
+				// NaN is compared as zero
+				e.addSplit(tree, id, n, entry, n.ZeroGoesLeft())
+			default:
+				e.addSplit(tree, id, n, entry, n.DefaultLeft)
+			}
+		}
+	}
+	return e
+}
+
+// nodeCount returns the number of nodes of the tree ensemble of n.
+func nodeCount(n *forest.Node) int {
+	switch {
+	case n.IsLeaf():
+		return 1
+	case n.Categorical:
+		set, _ := categorySet(n)
+		return max(len(set), 1)
+	case n.Missing == forest.MissingZero:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// categorySet returns the values which go to the left child of a categorical split,
+// and whether NaN goes to the left child.
+func categorySet(n *forest.Node) ([]uint32, bool) {
+	hasZero := len(n.Categories) > 0 && n.Categories[0] == 0
+	switch n.Missing {
+	case forest.MissingNone:
+		return n.Categories, hasZero
+	case forest.MissingZero:
+		// zero goes to the default child
+		switch {
+		case n.DefaultLeft && !hasZero:
+			return append([]uint32{0}, n.Categories...), true
+		case !n.DefaultLeft && hasZero:
+			return n.Categories[1:], false
+		}
+		return n.Categories, n.DefaultLeft
+	default:
+		return n.Categories, n.DefaultLeft
+	}
+}
+
+// addSplit adds the numerical split of n as node id.
+func (e *ensemble) addSplit(tree int64, id int64, n *forest.Node, entry []int64, nanLeft bool) {
+	// the float32 threshold compares every float32 value as the threshold of n
+	if n.Comparison == forest.LessEqual {
+		e.add(tree, id, int64(n.Feature), "BRANCH_LEQ", floorFloat32(n.Threshold), entry[n.Left], entry[n.Right], nanLeft)
+	} else {
+		e.add(tree, id, int64(n.Feature), "BRANCH_LT", ceilFloat32(n.Threshold), entry[n.Left], entry[n.Right], nanLeft)
+	}
+}
+
+func (e *ensemble) add(tree, id, feature int64, mode string, value float32, trueID, falseID int64, missingTracksTrue bool) {
+	e.treeIDs = append(e.treeIDs, tree)
+	e.nodeIDs = append(e.nodeIDs, id)
+	e.featureIDs = append(e.featureIDs, feature)
+	e.modes = append(e.modes, []byte(mode))
+	e.values = append(e.values, value)
+	e.trueIDs = append(e.trueIDs, trueID)
+	e.falseIDs = append(e.falseIDs, falseID)
+	tracks := int64(0)
+	if missingTracksTrue {
+		tracks = 1
+	}
+	e.missingTracksTrue = append(e.missingTracksTrue, tracks)
+}
+
+func (e *ensemble) addLeaf(tree, id, target int64, weight float32) {
+	e.leafTreeIDs = append(e.leafTreeIDs, tree)
+	e.leafNodeIDs = append(e.leafNodeIDs, id)
+	e.leafTargets = append(e.leafTargets, target)
+	e.leafWeights = append(e.leafWeights, weight)
+}
+
+func (e *ensemble) nodeAttributes() []Attribute {
+	return []Attribute{
+		intsAttribute("nodes_treeids", e.treeIDs),
+		intsAttribute("nodes_nodeids", e.nodeIDs),
+		intsAttribute("nodes_featureids", e.featureIDs),
+		{Name: "nodes_modes", Type: AttributeStrings, Strings: e.modes},
+		floatsAttribute("nodes_values", e.values),
+		intsAttribute("nodes_truenodeids", e.trueIDs),
+		intsAttribute("nodes_falsenodeids", e.falseIDs),
+		intsAttribute("nodes_missing_value_tracks_true", e.missingTracksTrue),
+	}
+}
+
+// ceilFloat32 returns the smallest float32 which is not less than v,
+// so that x < v if and only if x < ceilFloat32(v) for every float32 x.
+func ceilFloat32(v float64) float32 {
+	f := float32(v)
+	if float64(f) < v {
+		f = math.Nextafter32(f, float32(math.Inf(1)))
+	}
+	return f
+}
+
+// floorFloat32 returns the largest float32 which is not greater than v,
+// so that x <= v if and only if x <= floorFloat32(v) for every float32 x.
+func floorFloat32(v float64) float32 {
+	f := float32(v)
+	if float64(f) > v {
+		f = math.Nextafter32(f, float32(math.Inf(-1)))
+	}
+	return f
+}
+
+func intAttribute(name string, v int64) Attribute {
+	return Attribute{Name: name, Type: AttributeInt, I: v}
+}
+
+func intsAttribute(name string, v []int64) Attribute {
+	return Attribute{Name: name, Type: AttributeInts, Ints: v}
+}
+
+func floatsAttribute(name string, v []float32) Attribute {
+	return Attribute{Name: name, Type: AttributeFloats, Floats: v}
+}
+
+func stringAttribute(name string, v string) Attribute {
+	return Attribute{Name: name, Type: AttributeString, S: []byte(v)}
+}
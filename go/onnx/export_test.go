@@ -0,0 +1,394 @@
+package onnx_test
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+	"github.com/getumen/cuml-bindings/go/onnx"
+)
+
+// roundTrip marshals and unmarshals m.
+func roundTrip(t *testing.T, m *onnx.Model) *onnx.Model {
+	decoded, err := onnx.Unmarshal(m.Marshal())
+	require.NoError(t, err)
+	require.Equal(t, m, decoded)
+	return decoded
+}
+
+func TestForest(t *testing.T) {
+	x := foresttest.SyntheticRows()
+	testCases := []struct {
+		name   string
+		modify func(f *forest.Forest)
+	}{
+		{name: "softmax", modify: func(f *forest.Forest) {}},
+		{name: "identity", modify: func(f *forest.Forest) { f.PostTransform = forest.Identity }},
+		{name: "sigmoid", modify: func(f *forest.Forest) { f.PostTransform = forest.Sigmoid; f.SigmoidAlpha = -2 }},
+		{name: "exponential", modify: func(f *forest.Forest) { f.PostTransform = forest.Exponential }},
+		{name: "max index", modify: func(f *forest.Forest) { f.PostTransform = forest.MaxIndex }},
+		{name: "average", modify: func(f *forest.Forest) { f.AverageTreeOutput = true }},
+		{name: "binary sigmoid", modify: func(f *forest.Forest) {
+			singleGroup(f)
+			f.PostTransform = forest.Sigmoid
+			f.SigmoidAlpha = 0.5
+		}},
+		{name: "hinge", modify: func(f *forest.Forest) {
+			singleGroup(f)
+			f.PostTransform = forest.Hinge
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := foresttest.Synthetic()
+			tc.modify(f)
+			numRow := len(x) / f.NumFeature
+			expected := make([]float32, numRow*f.OutputWidth())
+			require.NoError(t, f.Predict(expected, x, numRow))
+
+			m, err := onnx.Forest(f)
+			require.NoError(t, err)
+			outputs := evaluate(t, roundTrip(t, m), x, numRow)
+
+			switch f.PostTransform {
+			case forest.MaxIndex:
+				require.InDeltaSlice(t, expected, outputs[onnx.LabelName], 0)
+			case forest.Hinge:
+				require.InDeltaSlice(t, expected, outputs[onnx.LabelName], 0)
+			case forest.Sigmoid:
+				if f.NumGroup == 1 {
+					probabilities := outputs[onnx.ProbabilitiesName]
+					for r := 0; r < numRow; r++ {
+						require.InDelta(t, 1-expected[r], probabilities[2*r], 1e-6)
+						require.InDelta(t, expected[r], probabilities[2*r+1], 1e-6)
+					}
+					return
+				}
+				require.InDeltaSlice(t, expected, outputs[onnx.PredictionName], 1e-6)
+			case forest.Softmax:
+				require.InDeltaSlice(t, expected, outputs[onnx.ProbabilitiesName], 1e-6)
+			default:
+				require.InDeltaSlice(t, expected, outputs[onnx.PredictionName], 1e-6)
+			}
+		})
+	}
+}
+
+func singleGroup(f *forest.Forest) {
+	f.NumGroup = 1
+	f.BaseScore = f.BaseScore[:1]
+	for i := range f.Trees {
+		f.Trees[i].Group = 0
+	}
+}
+
+func TestForestXGBoost(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	f, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+	x := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	numRow := len(x) / f.NumFeature
+
+	m, err := onnx.Forest(f)
+	require.NoError(t, err)
+	require.Equal(t, "TreeEnsembleClassifier", m.Graph.Nodes[0].OpType)
+	outputs := evaluate(t, roundTrip(t, m), x, numRow)
+
+	expected := foresttest.ReadCSV(t, "../../testdata/score-xgboost.csv")
+	probabilities := outputs[onnx.ProbabilitiesName]
+	for r := 0; r < numRow; r++ {
+		require.InDelta(t, expected[r], probabilities[2*r+1], 1e-5)
+		label := float32(0)
+		if probabilities[2*r+1] > probabilities[2*r] {
+			label = 1
+		}
+		require.Equal(t, label, outputs[onnx.LabelName][r])
+	}
+}
+
+func TestForestThreshold(t *testing.T) {
+	// thresholds of float64, e.g. of LightGBM, which are not float32
+	leaf := func(v float64) forest.Node {
+		return forest.Node{Left: -1, Right: -1, Value: v}
+	}
+	f := &forest.Forest{
+		Trees: []forest.Tree{
+			{Nodes: []forest.Node{{Left: 1, Right: 2, Threshold: 0.1}, leaf(1), leaf(2)}},
+			{Nodes: []forest.Node{{Left: 1, Right: 2, Threshold: 0.1, Comparison: forest.LessEqual}, leaf(4), leaf(8)}},
+			{Nodes: []forest.Node{{Left: 1, Right: 2, Threshold: 0.7}, leaf(16), leaf(32)}},
+			{Nodes: []forest.Node{{Left: 1, Right: 2, Threshold: 0.7, Comparison: forest.LessEqual}, leaf(64), leaf(128)}},
+		},
+		NumFeature: 1,
+		NumGroup:   1,
+		BaseScore:  []float64{0},
+	}
+	var x []float32
+	for _, v := range []float32{0.1, 0.7} {
+		x = append(x, math.Nextafter32(v, 0), v, math.Nextafter32(v, 1))
+	}
+	expected := make([]float32, len(x))
+	require.NoError(t, f.Predict(expected, x, len(x)))
+
+	m, err := onnx.Forest(f)
+	require.NoError(t, err)
+	require.Equal(t, expected, evaluate(t, roundTrip(t, m), x, len(x))[onnx.PredictionName])
+}
+
+func TestForestUnsupported(t *testing.T) {
+	f := foresttest.Synthetic()
+	f.PostTransform = forest.Hinge
+	_, err := onnx.Forest(f)
+	require.ErrorIs(t, err, onnx.ErrUnsupportedModel)
+
+	f.Trees[0].Nodes[0].Left = 0
+	_, err = onnx.Forest(f)
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+}
+
+func TestLinear(t *testing.T) {
+	coef := []float32{0.5, -1.25, 3}
+	var intercept float32 = 0.1
+	x := []float32{1, 2, 3, -0.5, 0, 4.5, 0, 0, 0}
+
+	outputs := evaluate(t, roundTrip(t, onnx.Linear(coef, intercept)), x, 3)
+	for r := 0; r < 3; r++ {
+		expected := intercept
+		for c, w := range coef {
+			expected += w * x[r*3+c]
+		}
+		require.InDelta(t, expected, outputs[onnx.PredictionName][r], 1e-6)
+	}
+}
+
+func TestLogistic(t *testing.T) {
+	coef := []float32{0.5, -1.25, 3}
+	var intercept float32 = -2
+	x := []float32{1, 2, 3, -0.5, 0, 4.5, 0, 0, 0}
+
+	outputs := evaluate(t, roundTrip(t, onnx.Logistic(coef, intercept)), x, 3)
+	for r := 0; r < 3; r++ {
+		margin := float64(intercept)
+		for c, w := range coef {
+			margin += float64(w * x[r*3+c])
+		}
+		p := 1 / (1 + math.Exp(-margin))
+		require.InDelta(t, 1-p, outputs[onnx.ProbabilitiesName][2*r], 1e-6)
+		require.InDelta(t, p, outputs[onnx.ProbabilitiesName][2*r+1], 1e-6)
+		label := float32(0)
+		if p > 0.5 {
+			label = 1
+		}
+		require.Equal(t, label, outputs[onnx.LabelName][r])
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	_, err := onnx.Unmarshal([]byte{0x0a, 0x05, 0x01})
+	require.ErrorIs(t, err, onnx.ErrInvalidModel)
+}
+
+// evaluate runs the graph of m by the specification of its operators,
+// returning every tensor by name, with the labels as float32.
+func evaluate(t *testing.T, m *onnx.Model, x []float32, numRow int) map[string][]float32 {
+	tensors := map[string][]float32{onnx.InputName: x}
+	width := map[string]int{onnx.InputName: len(x) / numRow}
+	for i := range m.Graph.Nodes {
+		n := &m.Graph.Nodes[i]
+		input := tensors[n.Inputs[0]]
+		numCol := width[n.Inputs[0]]
+		switch n.OpType {
+		case "Exp":
+			out := make([]float32, len(input))
+			for j, v := range input {
+				out[j] = float32(math.Exp(float64(v)))
+			}
+			tensors[n.Outputs[0]], width[n.Outputs[0]] = out, numCol
+		case "LinearRegressor", "LinearClassifier":
+			coef := n.Attribute("coefficients").Floats
+			intercepts := n.Attribute("intercepts").Floats
+			numClass := len(intercepts)
+			scores := make([]float32, numRow*numClass)
+			for r := 0; r < numRow; r++ {
+				for c := 0; c < numClass; c++ {
+					s := float64(intercepts[c])
+					for j := 0; j < numCol; j++ {
+						s += float64(coef[c*numCol+j] * input[r*numCol+j])
+					}
+					scores[r*numClass+c] = float32(s)
+				}
+			}
+			postTransform(t, n, scores, numClass)
+			if n.OpType == "LinearRegressor" {
+				tensors[n.Outputs[0]], width[n.Outputs[0]] = scores, numClass
+				continue
+			}
+			tensors[n.Outputs[0]], width[n.Outputs[0]] = labels(scores, numClass), 1
+			tensors[n.Outputs[1]], width[n.Outputs[1]] = scores, numClass
+		case "TreeEnsembleRegressor", "TreeEnsembleClassifier":
+			scores, numClass := evaluateTrees(t, n, input, numRow, numCol)
+			postTransform(t, n, scores, numClass)
+			if n.OpType == "TreeEnsembleRegressor" {
+				tensors[n.Outputs[0]], width[n.Outputs[0]] = scores, numClass
+				continue
+			}
+			tensors[n.Outputs[0]], width[n.Outputs[0]] = labels(scores, numClass), 1
+			tensors[n.Outputs[1]], width[n.Outputs[1]] = scores, numClass
+		default:
+			t.Fatalf("unknown operator %s", n.OpType)
+		}
+	}
+	return tensors
+}
+
+// evaluateTrees returns the sum of the weights of the leaves every row reaches, plus the base values.
+func evaluateTrees(t *testing.T, n *onnx.Node, x []float32, numRow int, numCol int) ([]float32, int) {
+	prefix := "target_"
+	numClass := 0
+	if n.OpType == "TreeEnsembleClassifier" {
+		prefix = "class_"
+		numClass = len(n.Attribute("classlabels_int64s").Ints)
+	} else {
+		numClass = int(n.Attribute("n_targets").I)
+	}
+	require.Equal(t, "SUM", stringOr(n, "aggregate_function", "SUM"))
+
+	type key struct{ tree, node int64 }
+	treeIDs := n.Attribute("nodes_treeids").Ints
+	nodeIDs := n.Attribute("nodes_nodeids").Ints
+	index := make(map[key]int)
+	children := make(map[key]bool)
+	for i := range treeIDs {
+		index[key{treeIDs[i], nodeIDs[i]}] = i
+	}
+	modes := n.Attribute("nodes_modes").Strings
+	trueIDs := n.Attribute("nodes_truenodeids").Ints
+	falseIDs := n.Attribute("nodes_falsenodeids").Ints
+	for i := range treeIDs {
+		if string(modes[i]) != "LEAF" {
+			children[key{treeIDs[i], trueIDs[i]}] = true
+			children[key{treeIDs[i], falseIDs[i]}] = true
+		}
+	}
+	var roots []int
+	for i := range treeIDs {
+		if !children[key{treeIDs[i], nodeIDs[i]}] {
+			roots = append(roots, i)
+		}
+	}
+	featureIDs := n.Attribute("nodes_featureids").Ints
+	values := n.Attribute("nodes_values").Floats
+	tracks := n.Attribute("nodes_missing_value_tracks_true").Ints
+
+	weights := make(map[key][][2]float64)
+	leafTrees := n.Attribute(prefix + "treeids").Ints
+	leafNodes := n.Attribute(prefix + "nodeids").Ints
+	leafIDs := n.Attribute(prefix + "ids").Ints
+	leafWeights := n.Attribute(prefix + "weights").Floats
+	for i := range leafTrees {
+		k := key{leafTrees[i], leafNodes[i]}
+		weights[k] = append(weights[k], [2]float64{float64(leafIDs[i]), float64(leafWeights[i])})
+	}
+
+	base := n.Attribute("base_values").Floats
+	scores := make([]float32, numRow*numClass)
+	sum := make([]float64, numClass)
+	for r := 0; r < numRow; r++ {
+		row := x[r*numCol : (r+1)*numCol]
+		for c := range sum {
+			sum[c] = float64(base[c])
+		}
+		for _, i := range roots {
+			for string(modes[i]) != "LEAF" {
+				v, th := row[featureIDs[i]], values[i]
+				var cond bool
+				switch string(modes[i]) {
+				case "BRANCH_LEQ":
+					cond = v <= th
+				case "BRANCH_LT":
+					cond = v < th
+				case "BRANCH_GTE":
+					cond = v >= th
+				case "BRANCH_GT":
+					cond = v > th
+				case "BRANCH_EQ":
+					cond = v == th
+				case "BRANCH_NEQ":
+					cond = v != th
+				default:
+					t.Fatalf("unknown mode %s", modes[i])
+				}
+				if v != v && tracks[i] == 1 {
+					cond = true
+				}
+				next := falseIDs[i]
+				if cond {
+					next = trueIDs[i]
+				}
+				var ok bool
+				i, ok = index[key{treeIDs[i], next}]
+				require.True(t, ok)
+			}
+			for _, w := range weights[key{treeIDs[i], nodeIDs[i]}] {
+				sum[int(w[0])] += w[1]
+			}
+		}
+		for c, s := range sum {
+			scores[r*numClass+c] = float32(s)
+		}
+	}
+	return scores, numClass
+}
+
+func postTransform(t *testing.T, n *onnx.Node, scores []float32, numClass int) {
+	switch stringOr(n, "post_transform", "NONE") {
+	case "NONE":
+	case "LOGISTIC":
+		for i, s := range scores {
+			scores[i] = float32(1 / (1 + math.Exp(-float64(s))))
+		}
+	case "SOFTMAX":
+		for r := 0; r < len(scores)/numClass; r++ {
+			row := scores[r*numClass : (r+1)*numClass]
+			maxScore := math.Inf(-1)
+			for _, s := range row {
+				maxScore = math.Max(maxScore, float64(s))
+			}
+			sum := 0.0
+			for _, s := range row {
+				sum += math.Exp(float64(s) - maxScore)
+			}
+			for i, s := range row {
+				row[i] = float32(math.Exp(float64(s)-maxScore) / sum)
+			}
+		}
+	default:
+		t.Fatalf("unknown post transform %s", n.Attribute("post_transform").S)
+	}
+}
+
+// labels returns the index of the first largest score of every row.
+func labels(scores []float32, numClass int) []float32 {
+	result := make([]float32, len(scores)/numClass)
+	for r := range result {
+		best := 0
+		for c := 1; c < numClass; c++ {
+			if scores[r*numClass+c] > scores[r*numClass+best] {
+				best = c
+			}
+		}
+		result[r] = float32(best)
+	}
+	return result
+}
+
+func stringOr(n *onnx.Node, name string, defaultValue string) string {
+	if a := n.Attribute(name); a != nil {
+		return string(a.S)
+	}
+	return defaultValue
+}
@@ -0,0 +1,580 @@
+package onnx
+
+import (
+	"math"
+	"slices"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+const (
+	// maxImportedNodes bounds the nodes of a forest of a tree ensemble,
+	// whose nodes shared by several parents are copied.
+	maxImportedNodes = 1 << 26
+	// maxImportedDepth bounds the depth of a tree.
+	maxImportedDepth = 1 << 16
+)
+
+// ParseForest decodes an ONNX model of a tree ensemble into a forest. see Model.ToForest.
+func ParseForest(data []byte) (*forest.Forest, error) {
+	m, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return m.ToForest()
+}
+
+// ToForest converts the TreeEnsembleRegressor or TreeEnsembleClassifier of the graph into a forest,
+// whose Predict returns the prediction of a regressor or the probabilities of a classifier.
+//
+// the ensemble may be followed by Exp on the prediction, and ZipMap, which does not change the probabilities.
+// the aggregate function is SUM or AVERAGE, and the post transform NONE, LOGISTIC or SOFTMAX.
+// a leaf of several targets becomes a tree per target, and a binary classifier of one column of weights,
+// or whose class 0 has the negated weights of class 1 as Forest exports, becomes a forest of a group.
+// BRANCH_EQ and BRANCH_NEQ of integers become categorical splits, which compare the integer part of a value.
+// Identity and Cast nodes, which converters such as skl2onnx add around the ensemble, pass their input through,
+// but the input of the ensemble must not be cast to a type other than float or double.
+func (m *Model) ToForest() (*forest.Forest, error) {
+	var ensemble *Node
+	exponential := false
+	// sources maps the output of a pass-through node to the tensor it passes through,
+	// and rounded holds the outputs of a Cast to a type other than float or double.
+	sources := make(map[string]string)
+	rounded := make(map[string]bool)
+	source := func(name string) string {
+		if s, ok := sources[name]; ok {
+			return s
+		}
+		return name
+	}
+	for i := range m.Graph.Nodes {
+		n := &m.Graph.Nodes[i]
+		switch {
+		case n.Domain == MLDomain && (n.OpType == "TreeEnsembleRegressor" || n.OpType == "TreeEnsembleClassifier"):
+			if ensemble != nil || len(n.Inputs) != 1 || rounded[n.Inputs[0]] {
+				return nil, ErrUnsupportedModel
+			}
+			ensemble = n
+		case n.Domain == MLDomain && n.OpType == "ZipMap":
+		case n.Domain == "" && (n.OpType == "Identity" || n.OpType == "Cast") && len(n.Inputs) == 1 && len(n.Outputs) == 1:
+			if n.OpType == "Cast" && !isFloatCast(n) {
+				rounded[n.Outputs[0]] = true
+				continue
+			}
+			rounded[n.Outputs[0]] = rounded[n.Inputs[0]]
+			sources[n.Outputs[0]] = source(n.Inputs[0])
+		case n.Domain == "" && n.OpType == "Exp" && ensemble != nil && ensemble.OpType == "TreeEnsembleRegressor" &&
+			len(n.Inputs) == 1 && len(ensemble.Outputs) == 1 && source(n.Inputs[0]) == ensemble.Outputs[0]:
+			exponential = true
+		default:
+			return nil, ErrUnsupportedModel
+		}
+	}
+	if ensemble == nil {
+		return nil, ErrUnsupportedModel
+	}
+
+	e, err := newImportedEnsemble(ensemble)
+	if err != nil {
+		return nil, err
+	}
+	f := &forest.Forest{
+		NumGroup:     e.numTarget,
+		SigmoidAlpha: 1,
+	}
+	switch e.postTransform {
+	case "NONE":
+		f.PostTransform = forest.Identity
+		if exponential {
+			f.PostTransform = forest.Exponential
+		}
+	case "LOGISTIC":
+		f.PostTransform = forest.Sigmoid
+	case "SOFTMAX":
+		f.PostTransform = forest.Softmax
+	default:
+		return nil, ErrUnsupportedModel
+	}
+	if exponential && f.PostTransform != forest.Exponential {
+		return nil, ErrUnsupportedModel
+	}
+	switch e.aggregate {
+	case "SUM":
+	case "AVERAGE":
+		f.AverageTreeOutput = true
+	default:
+		return nil, ErrUnsupportedModel
+	}
+
+	// the number of features is that of the input, or of the split features if it is symbolic
+	for _, id := range e.featureIDs {
+		f.NumFeature = max(f.NumFeature, int(id)+1)
+	}
+	if inputs := m.Graph.Inputs; len(inputs) == 1 && len(inputs[0].Shape) == 2 && inputs[0].Shape[1].Value > 0 {
+		if int(inputs[0].Shape[1].Value) < f.NumFeature {
+			return nil, ErrInvalidModel
+		}
+		f.NumFeature = int(inputs[0].Shape[1].Value)
+	}
+
+	f.BaseScore = make([]float64, e.numTarget)
+	for i, b := range e.baseValues {
+		if i < len(f.BaseScore) {
+			f.BaseScore[i] = float64(b)
+		}
+	}
+	if e.singleColumnBinary && len(e.baseValues) == 2 {
+		// class 0 has no weights, so the base value of the column is that of class 1
+		f.BaseScore[0] = float64(e.baseValues[1])
+	}
+
+	if f.Trees, err = e.trees(f.AverageTreeOutput); err != nil {
+		return nil, err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// importedEnsemble is the attributes of a tree ensemble operator.
+type importedEnsemble struct {
+	treeIDs, nodeIDs, featureIDs []int64
+	modes                        [][]byte
+	values                       []float32
+	trueIDs, falseIDs            []int64
+	missingTracksTrue            []int64
+
+	// leaves are the weights of the targets of every leaf.
+	leaves        map[nodeKey][]targetWeight
+	numTarget     int
+	baseValues    []float32
+	postTransform string
+	aggregate     string
+	// singleColumnBinary is true for a binary classifier whose weights are of class 1 only.
+	singleColumnBinary bool
+}
+
+type nodeKey struct {
+	tree, node int64
+}
+
+type targetWeight struct {
+	target int
+	weight float64
+}
+
+// isFloatCast reports whether the Cast n is to float or double.
+// a Cast of a double to float rounds it to the precision of the forest.
+func isFloatCast(n *Node) bool {
+	to := n.Attribute("to")
+	return to != nil && to.Type == AttributeInt && (int32(to.I) == TypeFloat || int32(to.I) == TypeDouble)
+}
+
+func newImportedEnsemble(n *Node) (*importedEnsemble, error) {
+	e := &importedEnsemble{
+		treeIDs:           ints(n, "nodes_treeids"),
+		nodeIDs:           ints(n, "nodes_nodeids"),
+		featureIDs:        ints(n, "nodes_featureids"),
+		modes:             strs(n, "nodes_modes"),
+		values:            floats(n, "nodes_values"),
+		trueIDs:           ints(n, "nodes_truenodeids"),
+		falseIDs:          ints(n, "nodes_falsenodeids"),
+		missingTracksTrue: ints(n, "nodes_missing_value_tracks_true"),
+		baseValues:        floats(n, "base_values"),
+		postTransform:     str(n, "post_transform", "NONE"),
+		aggregate:         str(n, "aggregate_function", "SUM"),
+		leaves:            make(map[nodeKey][]targetWeight),
+	}
+	numNode := len(e.treeIDs)
+	if numNode == 0 || len(e.nodeIDs) != numNode || len(e.featureIDs) != numNode || len(e.modes) != numNode ||
+		len(e.trueIDs) != numNode || len(e.falseIDs) != numNode {
+		return nil, ErrInvalidModel
+	}
+	if len(e.values) != numNode {
+		if e.values == nil && n.Attribute("nodes_values_as_tensor") != nil {
+			// the double thresholds of opset 3
+			return nil, ErrUnsupportedModel
+		}
+		return nil, ErrInvalidModel
+	}
+	if e.missingTracksTrue == nil {
+		e.missingTracksTrue = make([]int64, numNode)
+	} else if len(e.missingTracksTrue) != numNode {
+		return nil, ErrInvalidModel
+	}
+
+	prefix := "target_"
+	if n.OpType == "TreeEnsembleClassifier" {
+		prefix = "class_"
+		labels := len(ints(n, "classlabels_int64s")) + len(strs(n, "classlabels_strings"))
+		if labels == 0 {
+			return nil, ErrInvalidModel
+		}
+		e.numTarget = labels
+	} else {
+		e.numTarget = 1
+		if a := n.Attribute("n_targets"); a != nil {
+			e.numTarget = int(a.I)
+		}
+	}
+	leafTrees, leafNodes := ints(n, prefix+"treeids"), ints(n, prefix+"nodeids")
+	leafTargets, leafWeights := ints(n, prefix+"ids"), floats(n, prefix+"weights")
+	if len(leafNodes) != len(leafTrees) || len(leafTargets) != len(leafTrees) || len(leafWeights) != len(leafTrees) {
+		if leafWeights == nil && n.Attribute(prefix+"weights_as_tensor") != nil {
+			return nil, ErrUnsupportedModel
+		}
+		return nil, ErrInvalidModel
+	}
+	if e.numTarget <= 0 || (len(e.baseValues) != 0 && len(e.baseValues) != e.numTarget) {
+		return nil, ErrInvalidModel
+	}
+
+	singleColumn := true
+	for i := range leafTrees {
+		target := int(leafTargets[i])
+		if target < 0 || target >= e.numTarget {
+			return nil, ErrInvalidModel
+		}
+		singleColumn = singleColumn && target == 0
+		k := nodeKey{leafTrees[i], leafNodes[i]}
+		e.leaves[k] = append(e.leaves[k], targetWeight{target: target, weight: float64(leafWeights[i])})
+	}
+	if prefix == "class_" && e.numTarget == 2 {
+		switch {
+		case singleColumn:
+			// the weights are the score of class 1, as a binary model of onnxmltools
+			e.singleColumnBinary = true
+			e.numTarget = 1
+		case e.postTransform == "LOGISTIC" && e.negatedBinary():
+			e.numTarget = 1
+			e.baseValues = e.baseValues[1:]
+			for k, weights := range e.leaves {
+				for _, w := range weights {
+					if w.target == 1 {
+						e.leaves[k] = []targetWeight{{target: 0, weight: w.weight}}
+					}
+				}
+			}
+		}
+	}
+	return e, nil
+}
+
+// negatedBinary returns true if the weights and base value of class 0 are those of class 1 negated.
+func (e *importedEnsemble) negatedBinary() bool {
+	if len(e.baseValues) == 2 && e.baseValues[0] != -e.baseValues[1] {
+		return false
+	}
+	for _, weights := range e.leaves {
+		sum := [2]float64{}
+		for _, w := range weights {
+			sum[w.target] += w.weight
+		}
+		if sum[0] != -sum[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// trees returns a tree of every target of every tree of the ensemble, in the order of the tree ids.
+// every target has a tree of every tree of the ensemble if all is true.
+func (e *importedEnsemble) trees(all bool) ([]forest.Tree, error) {
+	index := make(map[nodeKey]int, len(e.treeIDs))
+	var treeOrder []int64
+	seen := make(map[int64]bool)
+	for i := range e.treeIDs {
+		k := nodeKey{e.treeIDs[i], e.nodeIDs[i]}
+		if _, ok := index[k]; ok {
+			return nil, ErrInvalidModel
+		}
+		index[k] = i
+		if !seen[e.treeIDs[i]] {
+			seen[e.treeIDs[i]] = true
+			treeOrder = append(treeOrder, e.treeIDs[i])
+		}
+	}
+	// the root of a tree is its node which is not a child
+	isChild := make(map[nodeKey]bool)
+	for i := range e.treeIDs {
+		if string(e.modes[i]) != "LEAF" {
+			isChild[nodeKey{e.treeIDs[i], e.trueIDs[i]}] = true
+			isChild[nodeKey{e.treeIDs[i], e.falseIDs[i]}] = true
+		}
+	}
+	roots := make(map[int64]int)
+	for i := range e.treeIDs {
+		if isChild[nodeKey{e.treeIDs[i], e.nodeIDs[i]}] {
+			continue
+		}
+		if _, ok := roots[e.treeIDs[i]]; ok {
+			return nil, ErrInvalidModel
+		}
+		roots[e.treeIDs[i]] = i
+	}
+
+	c := &treeConverter{ensemble: e, index: index, onPath: make([]bool, len(e.treeIDs))}
+	var trees []forest.Tree
+	for _, id := range treeOrder {
+		root, ok := roots[id]
+		if !ok {
+			return nil, ErrInvalidModel
+		}
+		c.nodes = c.nodes[:0]
+		if _, err := c.convert(root, 0); err != nil {
+			return nil, err
+		}
+
+		for target := 0; target < e.numTarget; target++ {
+			t := forest.Tree{Group: target, Nodes: make([]forest.Node, len(c.nodes))}
+			used := all
+			for j, n := range c.nodes {
+				t.Nodes[j] = n.node
+				if n.node.IsLeaf() {
+					for _, w := range e.leaves[n.leaf] {
+						if w.target == target {
+							t.Nodes[j].Value += w.weight
+							used = true
+						}
+					}
+				}
+			}
+			if used {
+				trees = append(trees, t)
+			}
+		}
+	}
+	return trees, nil
+}
+
+// treeConverter converts the nodes of a tree, copying the nodes of several parents.
+type treeConverter struct {
+	ensemble *importedEnsemble
+	index    map[nodeKey]int
+	nodes    []convertedNode
+	total    int
+	// onPath is true for the nodes of the ensemble from the root to the current node, which detects cycles.
+	onPath []bool
+}
+
+type convertedNode struct {
+	node forest.Node
+	// leaf is the node of the ensemble of a leaf.
+	leaf nodeKey
+}
+
+// convert appends node i and its descendants in preorder, returning the index of i.
+func (c *treeConverter) convert(i int, depth int) (int32, error) {
+	e := c.ensemble
+	c.total++
+	if c.total > maxImportedNodes || depth > maxImportedDepth {
+		return 0, ErrUnsupportedModel
+	}
+	if c.onPath[i] {
+		return 0, ErrInvalidModel
+	}
+	c.onPath[i] = true
+	defer func() { c.onPath[i] = false }()
+	self := int32(len(c.nodes))
+	c.nodes = append(c.nodes, convertedNode{})
+	tree := e.treeIDs[i]
+	child := func(id int64) (int, error) {
+		j, ok := c.index[nodeKey{tree, id}]
+		if !ok {
+			return 0, ErrInvalidModel
+		}
+		return j, nil
+	}
+
+	mode := string(e.modes[i])
+	if mode == "LEAF" {
+		c.nodes[self] = convertedNode{node: forest.Node{Left: -1, Right: -1}, leaf: nodeKey{tree, e.nodeIDs[i]}}
+		return self, nil
+	}
+	trueChild, err := child(e.trueIDs[i])
+	if err != nil {
+		return 0, err
+	}
+	falseChild, err := child(e.falseIDs[i])
+	if err != nil {
+		return 0, err
+	}
+
+	n := forest.Node{Feature: int32(e.featureIDs[i])}
+	if n.Feature < 0 {
+		return 0, ErrInvalidModel
+	}
+	tracks := e.missingTracksTrue[i] != 0
+	// left and right are the nodes of the ensemble of the left and right child
+	left, right := trueChild, falseChild
+	threshold := float64(e.values[i])
+	switch mode {
+	case "BRANCH_LT", "BRANCH_LEQ", "BRANCH_GT", "BRANCH_GTE":
+		if test, defaultLeft, ok := e.zeroGuard(i, trueChild, falseChild, c.index); ok {
+			// the nodes of Forest of forest.MissingZero
+			return c.convertMissingZero(self, test, defaultLeft, depth)
+		}
+		switch mode {
+		case "BRANCH_LEQ":
+			n.Comparison = forest.LessEqual
+		case "BRANCH_GT":
+			// x > t is x <= t of the other child
+			n.Comparison = forest.LessEqual
+			left, right = falseChild, trueChild
+		case "BRANCH_GTE":
+			left, right = falseChild, trueChild
+		}
+		n.Threshold = threshold
+		// a comparison with NaN is false
+		n.DefaultLeft = (tracks && left == trueChild) || (!tracks && left == falseChild)
+	case "BRANCH_EQ":
+		// a chain of BRANCH_EQ of the same true child is a set of categories
+		var categories []uint32
+		j := i
+		for {
+			// NaN equals no value, as Forest exports a split of no category
+			if !math.IsNaN(float64(e.values[j])) {
+				v, ok := category(e.values[j])
+				if !ok {
+					return 0, ErrUnsupportedModel
+				}
+				categories = append(categories, v)
+			}
+			tracks = tracks || e.missingTracksTrue[j] != 0
+			next, err := child(e.falseIDs[j])
+			if err != nil {
+				return 0, err
+			}
+			if string(e.modes[next]) != "BRANCH_EQ" || e.featureIDs[next] != e.featureIDs[i] || e.trueIDs[next] != e.trueIDs[i] {
+				right = next
+				break
+			}
+			j = next
+		}
+		n.Categorical = true
+		n.Categories = sortedSet(categories)
+		n.DefaultLeft = tracks
+	case "BRANCH_NEQ":
+		v, ok := category(e.values[i])
+		if !ok {
+			return 0, ErrUnsupportedModel
+		}
+		n.Categorical = true
+		n.Categories = []uint32{v}
+		left, right = falseChild, trueChild
+		// NaN is not equal to any value
+		n.DefaultLeft = false
+	default:
+		return 0, ErrInvalidModel
+	}
+
+	if n.Left, err = c.convert(left, depth+1); err != nil {
+		return 0, err
+	}
+	if n.Right, err = c.convert(right, depth+1); err != nil {
+		return 0, err
+	}
+	c.nodes[self].node = n
+	return self, nil
+}
+
+// zeroGuard returns the split of node i if i is the first node of a split of forest.MissingZero as Forest exports it:
+// i is x <= forest.ZeroThreshold, whose true child is x >= -forest.ZeroThreshold, both of which send NaN to their true child,
+// and whose false children are the split.
+func (e *importedEnsemble) zeroGuard(i int, trueChild int, falseChild int, index map[nodeKey]int) (int, bool, bool) {
+	tree := e.treeIDs[i]
+	if string(e.modes[i]) != "BRANCH_LEQ" || e.values[i] != floorFloat32(forest.ZeroThreshold) || e.missingTracksTrue[i] == 0 {
+		return 0, false, false
+	}
+	g := trueChild
+	if string(e.modes[g]) != "BRANCH_GTE" || e.values[g] != -floorFloat32(forest.ZeroThreshold) || e.missingTracksTrue[g] == 0 ||
+		e.featureIDs[g] != e.featureIDs[i] || index[nodeKey{tree, e.falseIDs[g]}] != falseChild {
+		return 0, false, false
+	}
+	test := falseChild
+	if e.featureIDs[test] != e.featureIDs[i] || (string(e.modes[test]) != "BRANCH_LT" && string(e.modes[test]) != "BRANCH_LEQ") {
+		return 0, false, false
+	}
+	switch e.trueIDs[g] {
+	case e.trueIDs[test]:
+		return test, true, true
+	case e.falseIDs[test]:
+		return test, false, true
+	}
+	return 0, false, false
+}
+
+// convertMissingZero converts the split test of forest.MissingZero into node self.
+func (c *treeConverter) convertMissingZero(self int32, test int, defaultLeft bool, depth int) (int32, error) {
+	e := c.ensemble
+	tree := e.treeIDs[test]
+	n := forest.Node{
+		Feature:     int32(e.featureIDs[test]),
+		Threshold:   float64(e.values[test]),
+		Missing:     forest.MissingZero,
+		DefaultLeft: defaultLeft,
+	}
+	if string(e.modes[test]) == "BRANCH_LEQ" {
+		n.Comparison = forest.LessEqual
+	}
+	var err error
+	if n.Left, err = c.convert(c.index[nodeKey{tree, e.trueIDs[test]}], depth+1); err != nil {
+		return 0, err
+	}
+	if n.Right, err = c.convert(c.index[nodeKey{tree, e.falseIDs[test]}], depth+1); err != nil {
+		return 0, err
+	}
+	c.nodes[self].node = n
+	return self, nil
+}
+
+// category returns v as a category if it is an integer of uint32.
+func category(v float32) (uint32, bool) {
+	if !(v >= 0) || v > math.MaxUint32 || v != float32(math.Trunc(float64(v))) {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// sortedSet returns the sorted distinct values of s.
+func sortedSet(s []uint32) []uint32 {
+	set := make(map[uint32]bool, len(s))
+	result := make([]uint32, 0, len(s))
+	for _, v := range s {
+		if !set[v] {
+			set[v] = true
+			result = append(result, v)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+func ints(n *Node, name string) []int64 {
+	if a := n.Attribute(name); a != nil {
+		return a.Ints
+	}
+	return nil
+}
+
+func floats(n *Node, name string) []float32 {
+	if a := n.Attribute(name); a != nil {
+		return a.Floats
+	}
+	return nil
+}
+
+func strs(n *Node, name string) [][]byte {
+	if a := n.Attribute(name); a != nil {
+		return a.Strings
+	}
+	return nil
+}
+
+func str(n *Node, name string, defaultValue string) string {
+	if a := n.Attribute(name); a != nil {
+		return string(a.S)
+	}
+	return defaultValue
+}
@@ -0,0 +1,357 @@
+package onnx_test
+
+import (
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+	"github.com/getumen/cuml-bindings/go/onnx"
+)
+
+func TestToForest(t *testing.T) {
+	x := foresttest.SyntheticRows()
+	testCases := []struct {
+		name     string
+		modify   func(f *forest.Forest)
+		numGroup int
+	}{
+		{name: "softmax", modify: func(f *forest.Forest) {}, numGroup: 2},
+		{name: "identity", modify: func(f *forest.Forest) { f.PostTransform = forest.Identity }, numGroup: 2},
+		{name: "sigmoid", modify: func(f *forest.Forest) { f.PostTransform = forest.Sigmoid; f.SigmoidAlpha = -2 }, numGroup: 2},
+		{name: "exponential", modify: func(f *forest.Forest) { f.PostTransform = forest.Exponential }, numGroup: 2},
+		{name: "max index", modify: func(f *forest.Forest) { f.PostTransform = forest.MaxIndex }, numGroup: 2},
+		{name: "average", modify: func(f *forest.Forest) { f.AverageTreeOutput = true }, numGroup: 2},
+		{name: "binary sigmoid", modify: func(f *forest.Forest) {
+			singleGroup(f)
+			f.PostTransform = forest.Sigmoid
+			f.SigmoidAlpha = 0.5
+		}, numGroup: 1},
+		{name: "hinge", modify: func(f *forest.Forest) {
+			singleGroup(f)
+			f.PostTransform = forest.Hinge
+		}, numGroup: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := foresttest.Synthetic()
+			tc.modify(f)
+			numRow := len(x) / f.NumFeature
+			m, err := onnx.Forest(f)
+			require.NoError(t, err)
+			outputs := evaluate(t, m, x, numRow)
+
+			imported, err := onnx.ParseForest(m.Marshal())
+			require.NoError(t, err)
+			require.Equal(t, f.NumFeature, imported.NumFeature)
+			require.Equal(t, tc.numGroup, imported.NumGroup)
+			// the splits of forest.MissingZero are restored
+			require.Equal(t, forest.MissingZero, imported.Trees[0].Nodes[2].Missing)
+			actual := make([]float32, numRow*imported.OutputWidth())
+			require.NoError(t, imported.Predict(actual, x, numRow))
+
+			expected := outputs[onnx.ProbabilitiesName]
+			if m.Graph.Nodes[0].OpType == "TreeEnsembleRegressor" {
+				expected = outputs[onnx.PredictionName]
+			}
+			if imported.NumGroup == 1 {
+				// the probabilities of class 1
+				for r := 0; r < numRow; r++ {
+					require.InDelta(t, expected[2*r+1], actual[r], 1e-6)
+				}
+				return
+			}
+			require.InDeltaSlice(t, expected, actual, 1e-6)
+		})
+	}
+}
+
+func TestToForestXGBoost(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	f, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+	x := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	numRow := len(x) / f.NumFeature
+
+	m, err := onnx.Forest(f)
+	require.NoError(t, err)
+	imported, err := m.ToForest()
+	require.NoError(t, err)
+	require.Equal(t, f.NumGroup, imported.NumGroup)
+	require.Equal(t, len(f.Trees), len(imported.Trees))
+	require.Equal(t, forest.Sigmoid, imported.PostTransform)
+
+	expected := make([]float32, numRow)
+	require.NoError(t, f.Predict(expected, x, numRow))
+	actual := make([]float32, numRow)
+	require.NoError(t, imported.Predict(actual, x, numRow))
+	require.InDeltaSlice(t, expected, actual, 1e-6)
+}
+
+// ensembleModel returns a model of a tree ensemble of opType on numFeature features.
+func ensembleModel(opType string, numFeature int, attributes ...onnx.Attribute) *onnx.Model {
+	outputs := []string{onnx.PredictionName}
+	if opType == "TreeEnsembleClassifier" {
+		outputs = []string{onnx.LabelName, onnx.ProbabilitiesName}
+	}
+	return &onnx.Model{
+		IRVersion:    7,
+		OpsetImports: []onnx.OperatorSetID{{Domain: onnx.MLDomain, Version: 1}},
+		Graph: onnx.Graph{
+			Nodes: []onnx.Node{{OpType: opType, Domain: onnx.MLDomain, Inputs: []string{onnx.InputName}, Outputs: outputs, Attributes: attributes}},
+			Inputs: []onnx.ValueInfo{
+				{Name: onnx.InputName, ElemType: onnx.TypeFloat, Shape: []onnx.Dimension{{Param: "N"}, {Value: int64(numFeature)}}},
+			},
+		},
+	}
+}
+
+func ints(name string, v ...int64) onnx.Attribute {
+	return onnx.Attribute{Name: name, Type: onnx.AttributeInts, Ints: v}
+}
+
+func floats(name string, v ...float32) onnx.Attribute {
+	return onnx.Attribute{Name: name, Type: onnx.AttributeFloats, Floats: v}
+}
+
+func strs(name string, v ...string) onnx.Attribute {
+	a := onnx.Attribute{Name: name, Type: onnx.AttributeStrings}
+	for _, s := range v {
+		a.Strings = append(a.Strings, []byte(s))
+	}
+	return a
+}
+
+func str(name string, v string) onnx.Attribute {
+	return onnx.Attribute{Name: name, Type: onnx.AttributeString, S: []byte(v)}
+}
+
+// operatorModel is a regressor of every mode of split, whose tree 1 is a chain of BRANCH_EQ
+// and whose tree 2 has a node of two parents.
+func operatorModel() *onnx.Model {
+	return ensembleModel("TreeEnsembleRegressor", 3,
+		ints("nodes_treeids", 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2),
+		ints("nodes_nodeids", 0, 1, 2, 3, 4, 0, 1, 2, 3, 4, 5, 0, 1, 2, 3),
+		ints("nodes_featureids", 0, 0, 1, 0, 0, 2, 2, 2, 0, 0, 0, 0, 1, 0, 0),
+		strs("nodes_modes", "BRANCH_GT", "LEAF", "BRANCH_GTE", "LEAF", "LEAF",
+			"BRANCH_EQ", "BRANCH_EQ", "BRANCH_NEQ", "LEAF", "LEAF", "LEAF",
+			"BRANCH_LT", "BRANCH_LEQ", "LEAF", "LEAF"),
+		floats("nodes_values", 0.5, 0, 1, 0, 0, 1, 3, 0, 0, 0, 0, 0, 2, 0, 0),
+		ints("nodes_truenodeids", 1, 0, 3, 0, 0, 3, 3, 4, 0, 0, 0, 1, 2, 0, 0),
+		ints("nodes_falsenodeids", 2, 0, 4, 0, 0, 1, 2, 5, 0, 0, 0, 2, 3, 0, 0),
+		ints("nodes_missing_value_tracks_true", 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0),
+		ints("target_treeids", 0, 0, 0, 1, 1, 1, 2, 2),
+		ints("target_nodeids", 1, 3, 4, 3, 4, 5, 2, 3),
+		ints("target_ids", 0, 0, 0, 0, 0, 0, 0, 0),
+		floats("target_weights", 1, 2, 4, 8, 16, 32, 64, 128),
+		onnx.Attribute{Name: "n_targets", Type: onnx.AttributeInt, I: 1},
+		floats("base_values", 0.5),
+	)
+}
+
+func TestToForestOperators(t *testing.T) {
+	m := operatorModel()
+	x := foresttest.SyntheticRows()
+	numRow := len(x) / 3
+	expected := evaluate(t, m, x, numRow)[onnx.PredictionName]
+
+	f, err := m.ToForest()
+	require.NoError(t, err)
+	require.Len(t, f.Trees, 3)
+	// the chain of BRANCH_EQ is a categorical split
+	require.Equal(t, []uint32{1, 3}, f.Trees[1].Nodes[0].Categories)
+	// the shared leaf is copied
+	require.Len(t, f.Trees[2].Nodes, 5)
+	actual := make([]float32, numRow)
+	require.NoError(t, f.Predict(actual, x, numRow))
+	require.InDeltaSlice(t, expected, actual, 1e-6)
+}
+
+func TestToForestBinary(t *testing.T) {
+	// a binary classifier of the weights of class 1 only
+	m := ensembleModel("TreeEnsembleClassifier", 1,
+		ints("nodes_treeids", 0, 0, 0),
+		ints("nodes_nodeids", 0, 1, 2),
+		ints("nodes_featureids", 0, 0, 0),
+		strs("nodes_modes", "BRANCH_LT", "LEAF", "LEAF"),
+		floats("nodes_values", 0.5, 0, 0),
+		ints("nodes_truenodeids", 1, 0, 0),
+		ints("nodes_falsenodeids", 2, 0, 0),
+		ints("class_treeids", 0, 0),
+		ints("class_nodeids", 1, 2),
+		ints("class_ids", 0, 0),
+		floats("class_weights", -1, 1),
+		ints("classlabels_int64s", 0, 1),
+		str("post_transform", "LOGISTIC"),
+	)
+	f, err := m.ToForest()
+	require.NoError(t, err)
+	require.Equal(t, 1, f.NumGroup)
+	require.Equal(t, forest.Sigmoid, f.PostTransform)
+
+	actual := make([]float32, 2)
+	require.NoError(t, f.Predict(actual, []float32{0, 1}, 2))
+	require.InDeltaSlice(t, []float32{float32(1 / (1 + math.E)), float32(1 / (1 + 1/math.E))}, actual, 1e-6)
+}
+
+func TestToForestAverage(t *testing.T) {
+	// trees of a leaf, whose weights are averaged over both trees for every target
+	m := ensembleModel("TreeEnsembleRegressor", 1,
+		ints("nodes_treeids", 0, 1),
+		ints("nodes_nodeids", 0, 0),
+		ints("nodes_featureids", 0, 0),
+		strs("nodes_modes", "LEAF", "LEAF"),
+		floats("nodes_values", 0, 0),
+		ints("nodes_truenodeids", 0, 0),
+		ints("nodes_falsenodeids", 0, 0),
+		ints("target_treeids", 0, 0, 1),
+		ints("target_nodeids", 0, 0, 0),
+		ints("target_ids", 0, 1, 0),
+		floats("target_weights", 2, 4, 6),
+		onnx.Attribute{Name: "n_targets", Type: onnx.AttributeInt, I: 2},
+		floats("base_values", 1, -1),
+		str("aggregate_function", "AVERAGE"),
+	)
+	f, err := m.ToForest()
+	require.NoError(t, err)
+	require.Len(t, f.Trees, 4)
+
+	actual := make([]float32, 2)
+	require.NoError(t, f.Predict(actual, []float32{0}, 1))
+	require.Equal(t, []float32{5, 1}, actual)
+}
+
+func TestToForestUnsupported(t *testing.T) {
+	attribute := func(m *onnx.Model, name string) *onnx.Attribute {
+		return m.Graph.Nodes[0].Attribute(name)
+	}
+	testCases := []struct {
+		name   string
+		modify func(m *onnx.Model)
+		err    error
+	}{
+		{name: "aggregate", modify: func(m *onnx.Model) {
+			m.Graph.Nodes[0].Attributes = append(m.Graph.Nodes[0].Attributes, str("aggregate_function", "MAX"))
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "post transform", modify: func(m *onnx.Model) {
+			m.Graph.Nodes[0].Attributes = append(m.Graph.Nodes[0].Attributes, str("post_transform", "PROBIT"))
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "operator", modify: func(m *onnx.Model) {
+			m.Graph.Nodes = append(m.Graph.Nodes, onnx.Node{OpType: "Abs", Inputs: []string{onnx.PredictionName}, Outputs: []string{"abs"}})
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "ensembles", modify: func(m *onnx.Model) {
+			m.Graph.Nodes = append(m.Graph.Nodes, m.Graph.Nodes[0])
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "category", modify: func(m *onnx.Model) {
+			attribute(m, "nodes_values").Floats[5] = 1.5
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "double thresholds", modify: func(m *onnx.Model) {
+			attribute(m, "nodes_values").Floats = nil
+			m.Graph.Nodes[0].Attributes = append(m.Graph.Nodes[0].Attributes, onnx.Attribute{Name: "nodes_values_as_tensor"})
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "two roots", modify: func(m *onnx.Model) {
+			attribute(m, "nodes_truenodeids").Ints[0] = 3
+		}, err: onnx.ErrInvalidModel},
+		{name: "cycle", modify: func(m *onnx.Model) {
+			attribute(m, "nodes_truenodeids").Ints[2] = 2
+		}, err: onnx.ErrInvalidModel},
+		{name: "missing child", modify: func(m *onnx.Model) {
+			attribute(m, "nodes_falsenodeids").Ints[0] = 9
+		}, err: onnx.ErrInvalidModel},
+		{name: "target", modify: func(m *onnx.Model) {
+			attribute(m, "target_ids").Ints[0] = 1
+		}, err: onnx.ErrInvalidModel},
+		{name: "integer input", modify: func(m *onnx.Model) {
+			m.Graph.Nodes[0].Inputs = []string{"rounded"}
+			m.Graph.Nodes = append([]onnx.Node{castNode(onnx.InputName, "rounded", onnx.TypeInt64)}, m.Graph.Nodes...)
+		}, err: onnx.ErrUnsupportedModel},
+		{name: "exponential of integers", modify: func(m *onnx.Model) {
+			m.Graph.Nodes = append(m.Graph.Nodes,
+				castNode(onnx.PredictionName, "rounded", onnx.TypeInt64),
+				onnx.Node{OpType: "Exp", Inputs: []string{"rounded"}, Outputs: []string{"exp"}},
+			)
+		}, err: onnx.ErrUnsupportedModel},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := operatorModel()
+			tc.modify(m)
+			_, err := m.ToForest()
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	_, err := onnx.ParseForest(onnx.Linear([]float32{1}, 0).Marshal())
+	require.ErrorIs(t, err, onnx.ErrUnsupportedModel)
+}
+
+func castNode(input string, output string, to int32) onnx.Node {
+	return onnx.Node{
+		OpType:     "Cast",
+		Inputs:     []string{input},
+		Outputs:    []string{output},
+		Attributes: []onnx.Attribute{{Name: "to", Type: onnx.AttributeInt, I: int64(to)}},
+	}
+}
+
+func TestToForestPassThrough(t *testing.T) {
+	m := operatorModel()
+	// small weights, whose exponential does not overflow
+	weights := m.Graph.Nodes[0].Attribute("target_weights").Floats
+	for i := range weights {
+		weights[i] /= 64
+	}
+	x := foresttest.SyntheticRows()
+	numRow := len(x) / 3
+	expected := evaluate(t, m, x, numRow)[onnx.PredictionName]
+	for i := range expected {
+		expected[i] = float32(math.Exp(float64(expected[i])))
+	}
+
+	// the input is cast to float, and the prediction passes through Identity and Cast before Exp
+	ensemble := m.Graph.Nodes[0]
+	ensemble.Inputs = []string{"features"}
+	m.Graph.Inputs[0].ElemType = onnx.TypeDouble
+	m.Graph.Nodes = []onnx.Node{
+		castNode(onnx.InputName, "features", onnx.TypeFloat),
+		ensemble,
+		{OpType: "Identity", Inputs: []string{onnx.PredictionName}, Outputs: []string{"identity"}},
+		castNode("identity", "double", onnx.TypeDouble),
+		{OpType: "Exp", Inputs: []string{"double"}, Outputs: []string{"exp"}},
+		// the integer prediction is another output, which the forest does not predict
+		castNode("double", "rounded", onnx.TypeInt64),
+	}
+
+	f, err := onnx.ParseForest(m.Marshal())
+	require.NoError(t, err)
+	require.Equal(t, forest.Exponential, f.PostTransform)
+	actual := make([]float32, numRow)
+	require.NoError(t, f.Predict(actual, x, numRow))
+	require.InEpsilonSlice(t, expected, actual, 1e-6)
+}
+
+func TestToForestSklearn(t *testing.T) {
+	// sklearn-gbdt.onnx is converted by skl2onnx in testdata/main.py
+	data, err := os.ReadFile("../../testdata/sklearn-gbdt.onnx")
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("run testdata/main.py to convert the model")
+	}
+	require.NoError(t, err)
+	f, err := onnx.ParseForest(data)
+	require.NoError(t, err)
+	require.Equal(t, 1, f.NumGroup)
+	require.Equal(t, forest.Sigmoid, f.PostTransform)
+
+	x := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	numRow := len(x) / f.NumFeature
+	expected := foresttest.ReadCSV(t, "../../testdata/score-sklearn-gbdt.csv")
+	actual := make([]float32, numRow)
+	require.NoError(t, f.Predict(actual, x, numRow))
+	require.InDeltaSlice(t, expected, actual, 1e-5)
+}
@@ -0,0 +1,463 @@
+// Package onnx exports models as ONNX, so that other runtimes can serve them.
+// it encodes the protobuf messages of onnx.proto directly, without the generated code of the ONNX schema.
+package onnx
+
+import (
+	"errors"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrInvalidModel is returned when the data is not an ONNX model.
+var ErrInvalidModel = errors.New("invalid onnx model")
+
+// AttributeType is the type of the value of an attribute.
+type AttributeType int32
+
+const (
+	// AttributeUndefined unset attribute type
+	AttributeUndefined AttributeType = 0
+	// AttributeFloat float attribute
+	AttributeFloat AttributeType = 1
+	// AttributeInt int attribute
+	AttributeInt AttributeType = 2
+	// AttributeString string attribute
+	AttributeString AttributeType = 3
+	// AttributeFloats list of floats attribute
+	AttributeFloats AttributeType = 6
+	// AttributeInts list of ints attribute
+	AttributeInts AttributeType = 7
+	// AttributeStrings list of strings attribute
+	AttributeStrings AttributeType = 8
+)
+
+// element types of tensors, as TensorProto.DataType
+const (
+	// TypeFloat float32 tensor
+	TypeFloat int32 = 1
+	// TypeInt64 int64 tensor
+	TypeInt64 int32 = 7
+	// TypeDouble float64 tensor
+	TypeDouble int32 = 11
+)
+
+// Model is the subset of ModelProto of onnx.proto which the classical ML operators need.
+// the fields of the other messages, e.g. tensors and sub-graphs, are dropped when a model is unmarshaled.
+type Model struct {
+	IRVersion       int64
+	OpsetImports    []OperatorSetID
+	ProducerName    string
+	ProducerVersion string
+	Domain          string
+	ModelVersion    int64
+	DocString       string
+	Graph           Graph
+}
+
+// OperatorSetID is an OperatorSetIdProto, the version of the operators of a domain.
+type OperatorSetID struct {
+	Domain  string
+	Version int64
+}
+
+// Graph is a GraphProto.
+type Graph struct {
+	Name    string
+	Nodes   []Node
+	Inputs  []ValueInfo
+	Outputs []ValueInfo
+}
+
+// Node is a NodeProto, a call of an operator.
+type Node struct {
+	Name       string
+	OpType     string
+	Domain     string
+	Inputs     []string
+	Outputs    []string
+	Attributes []Attribute
+}
+
+// Attribute is an AttributeProto. the field of Type holds the value.
+type Attribute struct {
+	Name    string
+	Type    AttributeType
+	F       float32
+	I       int64
+	S       []byte
+	Floats  []float32
+	Ints    []int64
+	Strings [][]byte
+}
+
+// ValueInfo is a ValueInfoProto of a tensor.
+type ValueInfo struct {
+	Name     string
+	ElemType int32
+	// Shape are the dimensions of the tensor, where a dimension of a non-empty Param is symbolic.
+	Shape []Dimension
+}
+
+// Dimension is a dimension of a TensorShapeProto.
+type Dimension struct {
+	Value int64
+	Param string
+}
+
+// Attribute returns the attribute of n named name, or nil if n has none.
+func (n *Node) Attribute(name string) *Attribute {
+	for i := range n.Attributes {
+		if n.Attributes[i].Name == name {
+			return &n.Attributes[i]
+		}
+	}
+	return nil
+}
+
+// Marshal returns the protobuf encoding of m.
+func (m *Model) Marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, m.IRVersion)
+	b = appendString(b, 2, m.ProducerName)
+	b = appendString(b, 3, m.ProducerVersion)
+	b = appendString(b, 4, m.Domain)
+	b = appendInt(b, 5, m.ModelVersion)
+	b = appendString(b, 6, m.DocString)
+	b = appendMessage(b, 7, m.Graph.marshal(nil))
+	for _, o := range m.OpsetImports {
+		var ob []byte
+		ob = appendString(ob, 1, o.Domain)
+		ob = appendInt(ob, 2, o.Version)
+		b = appendMessage(b, 8, ob)
+	}
+	return b
+}
+
+func (g *Graph) marshal(b []byte) []byte {
+	for i := range g.Nodes {
+		b = appendMessage(b, 1, g.Nodes[i].marshal(nil))
+	}
+	b = appendString(b, 2, g.Name)
+	for i := range g.Inputs {
+		b = appendMessage(b, 11, g.Inputs[i].marshal(nil))
+	}
+	for i := range g.Outputs {
+		b = appendMessage(b, 12, g.Outputs[i].marshal(nil))
+	}
+	return b
+}
+
+func (n *Node) marshal(b []byte) []byte {
+	for _, s := range n.Inputs {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	for _, s := range n.Outputs {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	b = appendString(b, 3, n.Name)
+	b = appendString(b, 4, n.OpType)
+	for i := range n.Attributes {
+		b = appendMessage(b, 5, n.Attributes[i].marshal(nil))
+	}
+	b = appendString(b, 7, n.Domain)
+	return b
+}
+
+func (a *Attribute) marshal(b []byte) []byte {
+	b = appendString(b, 1, a.Name)
+	switch a.Type {
+	case AttributeFloat:
+		b = protowire.AppendTag(b, 2, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, math.Float32bits(a.F))
+	case AttributeInt:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(a.I))
+	case AttributeString:
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, a.S)
+	case AttributeFloats:
+		var packed []byte
+		for _, v := range a.Floats {
+			packed = protowire.AppendFixed32(packed, math.Float32bits(v))
+		}
+		b = appendMessage(b, 7, packed)
+	case AttributeInts:
+		var packed []byte
+		for _, v := range a.Ints {
+			packed = protowire.AppendVarint(packed, uint64(v))
+		}
+		b = appendMessage(b, 8, packed)
+	case AttributeStrings:
+		for _, s := range a.Strings {
+			b = protowire.AppendTag(b, 9, protowire.BytesType)
+			b = protowire.AppendBytes(b, s)
+		}
+	}
+	b = protowire.AppendTag(b, 20, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(a.Type))
+}
+
+func (v *ValueInfo) marshal(b []byte) []byte {
+	b = appendString(b, 1, v.Name)
+	var shape []byte
+	for _, d := range v.Shape {
+		var db []byte
+		if d.Param != "" {
+			db = appendString(db, 2, d.Param)
+		} else {
+			db = protowire.AppendTag(db, 1, protowire.VarintType)
+			db = protowire.AppendVarint(db, uint64(d.Value))
+		}
+		shape = appendMessage(shape, 1, db)
+	}
+	var tensor []byte
+	tensor = appendInt(tensor, 1, int64(v.ElemType))
+	tensor = appendMessage(tensor, 2, shape)
+	return appendMessage(b, 2, appendMessage(nil, 1, tensor))
+}
+
+// appendString appends a string field unless it is empty, which is the default.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendInt appends an int field unless it is zero, which is the default.
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+// Unmarshal decodes an ONNX model.
+func Unmarshal(data []byte) (*Model, error) {
+	m := &Model{}
+	err := forEachField(data, func(num protowire.Number, f field) error {
+		var err error
+		switch num {
+		case 1:
+			m.IRVersion = int64(f.varint)
+		case 2:
+			m.ProducerName = string(f.bytes)
+		case 3:
+			m.ProducerVersion = string(f.bytes)
+		case 4:
+			m.Domain = string(f.bytes)
+		case 5:
+			m.ModelVersion = int64(f.varint)
+		case 6:
+			m.DocString = string(f.bytes)
+		case 7:
+			err = m.Graph.unmarshal(f.bytes)
+		case 8:
+			var o OperatorSetID
+			err = forEachField(f.bytes, func(num protowire.Number, f field) error {
+				switch num {
+				case 1:
+					o.Domain = string(f.bytes)
+				case 2:
+					o.Version = int64(f.varint)
+				}
+				return nil
+			})
+			m.OpsetImports = append(m.OpsetImports, o)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (g *Graph) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			var n Node
+			g.Nodes = append(g.Nodes, n)
+			return g.Nodes[len(g.Nodes)-1].unmarshal(f.bytes)
+		case 2:
+			g.Name = string(f.bytes)
+		case 11, 12:
+			var v ValueInfo
+			if err := v.unmarshal(f.bytes); err != nil {
+				return err
+			}
+			if num == 11 {
+				g.Inputs = append(g.Inputs, v)
+			} else {
+				g.Outputs = append(g.Outputs, v)
+			}
+		}
+		return nil
+	})
+}
+
+func (n *Node) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			n.Inputs = append(n.Inputs, string(f.bytes))
+		case 2:
+			n.Outputs = append(n.Outputs, string(f.bytes))
+		case 3:
+			n.Name = string(f.bytes)
+		case 4:
+			n.OpType = string(f.bytes)
+		case 5:
+			var a Attribute
+			if err := a.unmarshal(f.bytes); err != nil {
+				return err
+			}
+			n.Attributes = append(n.Attributes, a)
+		case 7:
+			n.Domain = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (a *Attribute) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			a.Name = string(f.bytes)
+		case 2:
+			a.F = math.Float32frombits(uint32(f.varint))
+		case 3:
+			a.I = int64(f.varint)
+		case 4:
+			a.S = append([]byte(nil), f.bytes...)
+		case 7:
+			// repeated scalars are either packed or a field per element
+			if f.typ != protowire.BytesType {
+				a.Floats = append(a.Floats, math.Float32frombits(uint32(f.varint)))
+				return nil
+			}
+			for b := f.bytes; len(b) > 0; {
+				v, n := protowire.ConsumeFixed32(b)
+				if n < 0 {
+					return ErrInvalidModel
+				}
+				a.Floats = append(a.Floats, math.Float32frombits(v))
+				b = b[n:]
+			}
+		case 8:
+			if f.typ != protowire.BytesType {
+				a.Ints = append(a.Ints, int64(f.varint))
+				return nil
+			}
+			for b := f.bytes; len(b) > 0; {
+				v, n := protowire.ConsumeVarint(b)
+				if n < 0 {
+					return ErrInvalidModel
+				}
+				a.Ints = append(a.Ints, int64(v))
+				b = b[n:]
+			}
+		case 9:
+			a.Strings = append(a.Strings, append([]byte(nil), f.bytes...))
+		case 20:
+			a.Type = AttributeType(f.varint)
+		}
+		return nil
+	})
+}
+
+func (v *ValueInfo) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			v.Name = string(f.bytes)
+		case 2:
+			// TypeProto.tensor_type
+			return forEachField(f.bytes, func(num protowire.Number, f field) error {
+				if num != 1 {
+					return nil
+				}
+				return forEachField(f.bytes, func(num protowire.Number, f field) error {
+					switch num {
+					case 1:
+						v.ElemType = int32(f.varint)
+					case 2:
+						return forEachField(f.bytes, func(num protowire.Number, f field) error {
+							if num != 1 {
+								return nil
+							}
+							var d Dimension
+							err := forEachField(f.bytes, func(num protowire.Number, f field) error {
+								switch num {
+								case 1:
+									d.Value = int64(f.varint)
+								case 2:
+									d.Param = string(f.bytes)
+								}
+								return nil
+							})
+							v.Shape = append(v.Shape, d)
+							return err
+						})
+					}
+					return nil
+				})
+			})
+		}
+		return nil
+	})
+}
+
+// field is the value of a field: bytes of a length-delimited field, or varint of the others.
+type field struct {
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+// forEachField calls fn with every field of a message.
+func forEachField(data []byte, fn func(num protowire.Number, f field) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return ErrInvalidModel
+		}
+		data = data[n:]
+
+		f := field{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			f.varint = uint64(v)
+		case protowire.Fixed64Type:
+			f.varint, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return ErrInvalidModel
+		}
+		data = data[n:]
+		if err := fn(num, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
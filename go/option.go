@@ -0,0 +1,168 @@
+package cuml4go
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+// Option configures an estimator.
+// options which do not apply to an estimator are ignored by its constructor.
+type Option func(*config)
+
+type config struct {
+	resources *Resources
+	chunking  chunking
+	backend   Backend
+	// featureNames overrides the feature names of a model.
+	featureNames []string
+	// iterationRange is the boosting rounds [begin, end) of a forest, or nil for all of them.
+	iterationRange *[2]int
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// slice returns the trees of f within the iteration range.
+func (c *config) slice(f *forest.Forest) (*forest.Forest, error) {
+	if c.iterationRange == nil {
+		return f, nil
+	}
+	return f.Slice(c.iterationRange[0], c.iterationRange[1])
+}
+
+// WithResources makes the estimator borrow device resource handles from resources.
+// the estimator does not close resources, which must outlive it.
+// without this option, every estimator creates and owns a single handle.
+func WithResources(resources *Resources) Option {
+	return func(c *config) {
+		c.resources = resources
+	}
+}
+
+// Backend is where a model is evaluated.
+type Backend int
+
+const (
+	// GPUBackend evaluates the model on the device with the native library
+	GPUBackend Backend = iota
+	// CPUBackend evaluates the model on the host in Go, without the device
+	CPUBackend
+)
+
+// AutoBackend returns GPUBackend if a CUDA device is available, and CPUBackend otherwise.
+func AutoBackend() Backend {
+	if DeviceCount() > 0 {
+		return GPUBackend
+	}
+	return CPUBackend
+}
+
+// ParseBackend returns the backend of name: gpu, cpu, or auto for AutoBackend.
+func ParseBackend(name string) (Backend, error) {
+	switch name {
+	case "gpu":
+		return GPUBackend, nil
+	case "cpu":
+		return CPUBackend, nil
+	case "auto":
+		return AutoBackend(), nil
+	default:
+		return 0, fmt.Errorf("unknown backend %q: expected gpu, cpu or auto", name)
+	}
+}
+
+// WithBackend selects where the model is evaluated. it defaults to GPUBackend.
+// it is used by FILModel.
+func WithBackend(backend Backend) Option {
+	return func(c *config) {
+		c.backend = backend
+	}
+}
+
+// WithFeatureNames sets the names of the features of a model, in the order of its columns.
+// they override the names stored in the model, and name the features of a model which stores none,
+// like an XGBoost legacy binary model. it is used by FILModel.
+func WithFeatureNames(names []string) Option {
+	return func(c *config) {
+		c.featureNames = append([]string(nil), names...)
+	}
+}
+
+// WithIterationRange makes a forest use the trees of the boosting rounds [begin, end) only,
+// like XGBoost's iteration_range. end == 0 means the last round.
+// it applies to every prediction of a FILModel. on the device, the trees of the rounds are built
+// by the model builder of treelite, which returns ErrUnsupportedOnDevice for a forest it cannot build.
+func WithIterationRange(begin int, end int) Option {
+	return func(c *config) {
+		c.iterationRange = &[2]int{begin, end}
+	}
+}
+
+// WithMaxRowsPerCall limits the number of rows passed to a single native predict call.
+// larger inputs are split into chunks whose results are written into a single output.
+// rows <= 0 means no limit.
+func WithMaxRowsPerCall(rows int) Option {
+	return func(c *config) {
+		c.chunking.maxRows = rows
+	}
+}
+
+// WithMaxBytesPerCall limits the size in bytes of the input of a single native predict call.
+// a chunk has at least one row. bytes <= 0 means no limit.
+func WithMaxBytesPerCall(bytes int) Option {
+	return func(c *config) {
+		c.chunking.maxBytes = bytes
+	}
+}
+
+// WithOutOfMemoryRetry makes predict calls which fail with ErrOutOfMemory
+// retry with half the rows per call until a single row fails.
+func WithOutOfMemoryRetry() Option {
+	return func(c *config) {
+		c.chunking.retryOutOfMemory = true
+	}
+}
+
+// deviceResources returns the shared resources,
+// or new resources owned by the estimator if none is given.
+func (c *config) deviceResources() (resources *Resources, owned bool, err error) {
+	if c.resources != nil {
+		return c.resources, false, nil
+	}
+
+	resources, err = NewResources(1)
+	if err != nil {
+		return nil, false, err
+	}
+	return resources, true, nil
+}
+
+// hostResources returns the shared resources,
+// or new resources owned by the estimator whose handles are never passed to the native library,
+// one for each CPU.
+func (c *config) hostResources() (resources *Resources, owned bool, err error) {
+	if c.resources != nil {
+		return c.resources, false, nil
+	}
+
+	resources, err = newHostResources(runtime.GOMAXPROCS(0))
+	if err != nil {
+		return nil, false, err
+	}
+	return resources, true, nil
+}
+
+// closeResources frees the device resources if the estimator owns them.
+func closeResources(resources *Resources, owned bool) error {
+	if !owned {
+		return nil
+	}
+	return resources.Close()
+}
@@ -0,0 +1,90 @@
+package cuml4go_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestFILModelPredictInto(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := []float32{0.1, 0.2, 0.3, 0.4}
+
+	dst := make([]float32, 2)
+	require.NoError(t, target.PredictInto(dst, x, 2, false))
+	require.InDeltaSlice(t, []float32{0.3, 0.7}, dst, 1e-6)
+
+	dst = make([]float32, 4)
+	require.NoError(t, target.PredictInto(dst, x, 2, true))
+	require.InDeltaSlice(t, []float32{0.7, 0.3, 0.3, 0.7}, dst, 1e-6)
+
+	score := make([]float32, 2)
+	require.NoError(t, target.PredictSingleClassScoreInto(score, x, 2))
+	require.InDeltaSlice(t, []float32{0.3, 0.7}, score, 1e-6)
+
+	actual, err := target.PredictSingleClassScore(x, 2)
+	require.NoError(t, err)
+	require.Equal(t, score, actual)
+
+	csr := cuml4go.NewCSRMatrixFromDense(x, 2, 2)
+	require.NoError(t, target.PredictCSRInto(dst, csr, true))
+	require.InDeltaSlice(t, []float32{0.7, 0.3, 0.3, 0.7}, dst, 1e-6)
+
+	require.ErrorIs(t, target.PredictInto(make([]float32, 3), x, 2, false), cuml4go.ErrInvalidOutputLength)
+	require.ErrorIs(t, target.PredictInto(make([]float32, 2), x, 2, true), cuml4go.ErrInvalidOutputLength)
+	require.ErrorIs(t, target.PredictInto(make([]float32, 3), x, 3, false), cuml4go.ErrDimensionMismatch)
+	require.ErrorIs(t, target.PredictSingleClassScoreInto(make([]float32, 1), x, 2), cuml4go.ErrInvalidOutputLength)
+	require.ErrorIs(t, target.PredictCSRInto(make([]float32, 2), csr, true), cuml4go.ErrInvalidOutputLength)
+	// a column beyond the features of the model
+	wide := cuml4go.NewCSRMatrixFromDense([]float32{0.1, 0.2, 1, 0.3, 0.4, 1}, 2, 3)
+	require.ErrorIs(t, target.PredictCSRInto(make([]float32, 2), wide, false), cuml4go.ErrDimensionMismatch)
+}
+
+func TestFILModelPredictSingleClassScoreIntoAllocs(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+
+	target, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := make([]float32, 2*64)
+	dst := make([]float32, 64)
+
+	// warm up the buffer pool
+	require.NoError(t, target.PredictSingleClassScoreInto(dst, x, 64))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = target.PredictSingleClassScoreInto(dst, x, 64)
+	})
+	// the fake forest records every call
+	require.LessOrEqual(t, allocs, 2.0)
+}
+
+func TestBatcherPredictInto(t *testing.T) {
+	target, err := cuml4go.NewBatcher(&sumPredictor{numFeatures: 2}, cuml4go.BatcherConfig{
+		MaxBatchSize: 4,
+		MaxWait:      time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer target.Close()
+
+	dst := make([]float32, 2)
+	require.NoError(t, target.PredictInto(context.Background(), dst, []float32{1, 2, 3, 4}, 2))
+	require.Equal(t, []float32{3, 7}, dst)
+
+	err = target.PredictInto(context.Background(), make([]float32, 1), []float32{1, 2, 3, 4}, 2)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidOutputLength)
+}
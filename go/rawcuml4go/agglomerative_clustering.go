@@ -1,14 +1,11 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/agglomerative_clustering.h"
 import "C"
-import "errors"
-
-var (
-	ErrAgglomerativeClustering = errors.New("raw api: fail to agglomerative clustering")
-)
 
 // AgglomerativeClustering is raw api for agglomerative clustering
 func AgglomerativeClustering(
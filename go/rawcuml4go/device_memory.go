@@ -1,15 +1,11 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/memory_resource.h"
 import "C"
-import "errors"
-
-var (
-	ErrGetDeviceMemoryResource   = errors.New("raw api: fail to get device memory resource")
-	ErrResetDeviceMemoryResource = errors.New("raw api: fail to reset device memory resource")
-)
 
 type MemoryResource struct {
 	pointer      C.DeviceMemoryResource
@@ -25,6 +21,25 @@ func (m *MemoryResource) Close() error {
 	return nil
 }
 
+// Statistics returns the bytes currently allocated through the resource,
+// the peak of them, and the size of the pool if the resource is a pool.
+func (m *MemoryResource) Statistics() (
+	allocatedBytes uint64,
+	peakBytes uint64,
+	poolSize uint64,
+	hasPoolSize bool,
+	err error,
+) {
+	var allocated, peak, pool C.size_t
+	var hasPool C.bool
+	ret := C.GetMemoryResourceStatistics(m.pointer, &allocated, &peak, &pool, &hasPool)
+	if ret != 0 {
+		return 0, 0, 0, false, ErrGetMemoryStatistics
+	}
+
+	return uint64(allocated), uint64(peak), uint64(pool), bool(hasPool), nil
+}
+
 func UsePoolMemoryResource(
 	initialPoolSize uint64,
 	maximumPoolSize uint64,
@@ -52,7 +67,7 @@ func UseBinningMemoryResource(
 	var pointer C.DeviceMemoryResource
 	ret := C.UseBinningMemoryResource(
 		(C.schar)(minSizeExponent),
-		(C.schar)(minSizeExponent),
+		(C.schar)(maxSizeExponent),
 		&pointer,
 	)
 	if ret != 0 {
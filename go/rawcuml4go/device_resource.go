@@ -1,15 +1,14 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/device_resource_handle.h"
 import "C"
-import "errors"
 
-var (
-	ErrCreateDeviceResource = errors.New("raw api: fail to create device resource")
-	ErrCloseDeviceResource  = errors.New("raw api: fail to close device resource")
-)
+// HasNativeLibrary reports whether the package calls the native libraries.
+const HasNativeLibrary = true
 
 type DeviceResource struct {
 	pointer C.DeviceResourceHandle
@@ -33,3 +32,13 @@ func (d *DeviceResource) Close() error {
 	}
 	return nil
 }
+
+// DeviceCount returns the number of CUDA devices.
+func DeviceCount() (int, error) {
+	var count C.int
+	ret := C.GetDeviceCount(&count)
+	if ret != 0 {
+		return 0, ErrGetDeviceCount
+	}
+	return int(count), nil
+}
@@ -0,0 +1,54 @@
+package rawcuml4go
+
+import "errors"
+
+var (
+	// ErrNoNativeLibrary is returned by every call when the package is built without the native libraries,
+	// i.e. with CGO_ENABLED=0 or the nocuml build tag.
+	ErrNoNativeLibrary = errors.New("raw api: built without the native libraries")
+)
+
+var (
+	ErrCreateDeviceResource = errors.New("raw api: fail to create device resource")
+	ErrCloseDeviceResource  = errors.New("raw api: fail to close device resource")
+	ErrGetDeviceCount       = errors.New("raw api: fail to get device count")
+	// ErrOutOfMemory is returned when a call fails to allocate device memory.
+	// the call may succeed with a smaller input.
+	ErrOutOfMemory = errors.New("raw api: out of device memory")
+)
+
+var (
+	ErrGetDeviceMemoryResource   = errors.New("raw api: fail to get device memory resource")
+	ErrResetDeviceMemoryResource = errors.New("raw api: fail to reset device memory resource")
+	ErrGetMemoryStatistics       = errors.New("raw api: fail to get memory resource statistics")
+)
+
+var (
+	// ErrFILModelLoad is returned when fail to load model.
+	ErrFILModelLoad = errors.New("raw api: fail to load model")
+	// ErrFILModelFree is returned when fail to free model.
+	ErrFILModelFree = errors.New("raw api: fail to free model")
+	// ErrFILModelPredict is returned when fail to predict.
+	ErrFILModelPredict = errors.New("raw api: fail to predict")
+	// ErrFILModelNumFeatures is returned when fail to get the number of features.
+	ErrFILModelNumFeatures = errors.New("raw api: fail to get number of features")
+)
+
+var (
+	ErrKmeans = errors.New("raw api: fail to kmeans")
+)
+
+var (
+	ErrDBScan = errors.New("raw api: fail to dbscan")
+)
+
+var (
+	ErrAgglomerativeClustering = errors.New("raw api: fail to agglomerative clustering")
+)
+
+var (
+	ErrLinearRegressionFit     = errors.New("raw api: fail to linear regression fit")
+	ErrLinearRegressionPredict = errors.New("raw api: fail to linear regression predict")
+	ErrRidgeRegressionFit      = errors.New("raw api: fail to ridge regression fit")
+	ErrRidgeRegressionPredict  = errors.New("raw api: fail to ridge regression predict")
+)
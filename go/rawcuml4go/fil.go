@@ -1,24 +1,33 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -ltreelite -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/fil.h"
 import "C"
-import "errors"
-
-var (
-	// ErrFILModelLoad is returned when fail to load model.
-	ErrFILModelLoad = errors.New("raw api: fail to load model")
-	// ErrFILModelFree is returned when fail to free model.
-	ErrFILModelFree = errors.New("raw api: fail to free model")
-	// ErrFILModelPredict is returned when fail to predict.
-	ErrFILModelPredict = errors.New("raw api: fail to predict")
-)
+import "unsafe"
 
 // FILModel is a Forest Inference Library model.
 type FILModel struct {
 	deviceResource *DeviceResource
 	pointer        C.FILModelHandle
+	numClasses     int
+}
+
+// newFILModel wraps a loaded model. the model is freed if its number of classes is not available.
+func newFILModel(deviceResource *DeviceResource, handle C.FILModelHandle) (*FILModel, error) {
+	var numClasses C.size_t
+	if ret := C.FILGetNumClasses(handle, &numClasses); ret != 0 {
+		C.FILFreeModel(deviceResource.pointer, handle)
+		return nil, ErrFILModelLoad
+	}
+
+	return &FILModel{
+		deviceResource: deviceResource,
+		pointer:        handle,
+		numClasses:     int(numClasses),
+	}, nil
 }
 
 // NewFILModel
@@ -46,11 +55,14 @@ func NewFILModel(
 	threadsPerTree int,
 	nItems int,
 ) (*FILModel, error) {
+	cFilePath := C.CString(filePath)
+	defer C.free(unsafe.Pointer(cFilePath))
+
 	var handle C.FILModelHandle
 	ret := C.FILLoadModel(
 		deviceResource.pointer,
 		C.int(modelType),
-		C.CString(filePath),
+		cFilePath,
 		C.int(algo),
 		C.bool(classification),
 		C.float(threshold),
@@ -64,11 +76,103 @@ func NewFILModel(
 		return nil, ErrFILModelLoad
 	}
 
-	return &FILModel{
-		deviceResource: deviceResource,
-		pointer:        handle,
-	}, nil
+	return newFILModel(deviceResource, handle)
+}
+
+// NewFILModelFromBytes is the same as NewFILModel but loads the model from data
+// instead of a file.
+func NewFILModelFromBytes(
+	deviceResource *DeviceResource,
+	modelType int,
+	data []byte,
+	algo int,
+	classification bool,
+	threshold float32,
+	storageType int,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	if len(data) == 0 {
+		return nil, ErrFILModelLoad
+	}
+
+	var handle C.FILModelHandle
+	ret := C.FILLoadModelFromBuffer(
+		deviceResource.pointer,
+		C.int(modelType),
+		(*C.char)(unsafe.Pointer(&data[0])),
+		C.size_t(len(data)),
+		C.int(algo),
+		C.bool(classification),
+		C.float(threshold),
+		C.int(storageType),
+		C.int(blocksPerSm),
+		C.int(threadsPerTree),
+		C.int(nItems),
+		&handle,
+	)
+	if ret != 0 {
+		return nil, ErrFILModelLoad
+	}
+
+	return newFILModel(deviceResource, handle)
+}
 
+// NewFILModelFromTrees is the same as NewFILModel but builds the model from trees,
+// e.g. of a format treelite does not load.
+func NewFILModelFromTrees(
+	deviceResource *DeviceResource,
+	trees *FILTrees,
+	algo int,
+	classification bool,
+	threshold float32,
+	storageType int,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	numNode := len(trees.Kinds)
+	if len(trees.TreeOffsets) < 2 || numNode == 0 || len(trees.LeftChildren) != numNode || len(trees.RightChildren) != numNode ||
+		len(trees.Features) != numNode || len(trees.Values) != numNode || len(trees.DefaultLeft) != numNode ||
+		len(trees.CategoryOffsets) != numNode+1 || int(trees.CategoryOffsets[numNode]) != len(trees.Categories) {
+		return nil, ErrFILModelLoad
+	}
+	cMetadata := C.CString(trees.Metadata)
+	defer C.free(unsafe.Pointer(cMetadata))
+	var categories *C.uint32_t
+	if len(trees.Categories) > 0 {
+		categories = (*C.uint32_t)(&trees.Categories[0])
+	}
+
+	var handle C.FILModelHandle
+	ret := C.FILLoadModelFromTrees(
+		deviceResource.pointer,
+		cMetadata,
+		C.int(len(trees.TreeOffsets)-1),
+		(*C.int)(&trees.TreeOffsets[0]),
+		(*C.int)(unsafe.Pointer(&trees.Kinds[0])),
+		(*C.int)(&trees.LeftChildren[0]),
+		(*C.int)(&trees.RightChildren[0]),
+		(*C.int)(&trees.Features[0]),
+		(*C.double)(&trees.Values[0]),
+		(*C.bool)(&trees.DefaultLeft[0]),
+		(*C.int)(&trees.CategoryOffsets[0]),
+		categories,
+		C.int(algo),
+		C.bool(classification),
+		C.float(threshold),
+		C.int(storageType),
+		C.int(blocksPerSm),
+		C.int(threadsPerTree),
+		C.int(nItems),
+		&handle,
+	)
+	if ret != 0 {
+		return nil, ErrFILModelLoad
+	}
+
+	return newFILModel(deviceResource, handle)
 }
 
 // Predict returns the prediction result in device.
@@ -78,6 +182,25 @@ func (m *FILModel) Predict(
 	outputClassProbability bool,
 	preds []float32,
 ) ([]float32, error) {
+	return m.PredictOn(m.deviceResource, x, numRow, outputClassProbability, preds)
+}
+
+// PredictOn is the same as Predict but runs on the given device resource
+// instead of the one the model is loaded with.
+// it may be called concurrently with distinct device resources,
+// but a device resource must not be used by two calls at once.
+// it fails with ErrFILModelPredict for the class probabilities of more than 2 classes,
+// which do not fit the 2 outputs per row of preds.
+func (m *FILModel) PredictOn(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	preds []float32,
+) ([]float32, error) {
+	if outputClassProbability && m.numClasses > 2 {
+		return nil, ErrFILModelPredict
+	}
 
 	if preds == nil {
 		var predsLen int
@@ -90,7 +213,7 @@ func (m *FILModel) Predict(
 	}
 
 	ret := C.FILPredict(
-		m.deviceResource.pointer,
+		deviceResource.pointer,
 		m.pointer,
 		(*C.float)(&x[0]),
 		(C.size_t)(numRow),
@@ -98,6 +221,9 @@ func (m *FILModel) Predict(
 		(*C.float)(&preds[0]),
 	)
 
+	if ret == C.FIL_OUT_OF_MEMORY {
+		return nil, ErrOutOfMemory
+	}
 	if ret != 0 {
 		return nil, ErrFILModelPredict
 	}
@@ -107,9 +233,31 @@ func (m *FILModel) Predict(
 
 // Close frees the model.
 func (m *FILModel) Close() error {
-	ret := C.FILFreeModel(m.deviceResource.pointer, m.pointer)
+	return m.CloseOn(m.deviceResource)
+}
+
+// CloseOn is the same as Close but runs on the given device resource
+// instead of the one the model is loaded with.
+func (m *FILModel) CloseOn(deviceResource *DeviceResource) error {
+	ret := C.FILFreeModel(deviceResource.pointer, m.pointer)
 	if ret != 0 {
 		return ErrFILModelFree
 	}
 	return nil
 }
+
+// NumFeatures returns the number of features the model expects.
+func (m *FILModel) NumFeatures() (int, error) {
+	var numFeatures C.size_t
+	ret := C.FILGetNumFeatures(m.pointer, &numFeatures)
+	if ret != 0 {
+		return 0, ErrFILModelNumFeatures
+	}
+	return int(numFeatures), nil
+}
+
+// NumClasses returns the number of classes of the model,
+// which is 1 for a regressor or a binary classifier.
+func (m *FILModel) NumClasses() int {
+	return m.numClasses
+}
@@ -9,14 +9,14 @@ import (
 )
 
 func TestFIL(t *testing.T) {
-
+	skipWithoutNativeLibrary(t)
 	deviceResource, err := rawcuml4go.NewDeviceResource()
 	require.NoError(t, err)
 	defer deviceResource.Close()
 
 	target, err := rawcuml4go.NewFILModel(
 		deviceResource,
-		int(cuml4go.XGBoost),
+		int(cuml4go.XGBoostUBJSON),
 		"../../testdata/xgboost.model",
 		int(cuml4go.AlgoAuto),
 		true,
@@ -26,6 +26,8 @@ func TestFIL(t *testing.T) {
 		1,
 		0)
 	require.NoError(t, err)
+	// a binary classifier stores a single class
+	require.Equal(t, 1, target.NumClasses())
 
 	nRow := 114
 	numClass := 2
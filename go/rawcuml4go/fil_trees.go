@@ -0,0 +1,35 @@
+package rawcuml4go
+
+// FILNodeKind is the kind of a node of FILTrees, whose values are those of fil.h.
+type FILNodeKind int32
+
+const (
+	// FILNodeLeaf is a leaf whose output is the value.
+	FILNodeLeaf FILNodeKind = 0
+	// FILNodeLessThan is a split which sends a row to the left child if its feature is less than the value.
+	FILNodeLessThan FILNodeKind = 1
+	// FILNodeLessEqual is a split which sends a row to the left child if its feature is less than or equal to the value.
+	FILNodeLessEqual FILNodeKind = 2
+	// FILNodeCategorical is a split which sends a row to the left child if its feature is one of the categories.
+	FILNodeCategorical FILNodeKind = 3
+)
+
+// FILTrees is a forest for the model builder of treelite, whose nodes are stored in parallel slices.
+type FILTrees struct {
+	// Metadata is the model builder metadata as JSON.
+	Metadata string
+	// TreeOffsets are the offsets of the nodes of the trees, whose length is the number of trees plus 1.
+	// the first node of a tree is the root.
+	TreeOffsets []int32
+	Kinds       []FILNodeKind
+	// LeftChildren and RightChildren are the indices of the children within the tree.
+	LeftChildren  []int32
+	RightChildren []int32
+	Features      []int32
+	// Values are the thresholds of the splits and the outputs of the leaves.
+	Values      []float64
+	DefaultLeft []bool
+	// CategoryOffsets are the offsets of the categories of the nodes, whose length is the number of nodes plus 1.
+	CategoryOffsets []int32
+	Categories      []uint32
+}
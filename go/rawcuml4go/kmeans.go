@@ -1,15 +1,14 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/kmeans.h"
 import "C"
-import "errors"
-
-var (
-	ErrKmeans = errors.New("raw api: fail to kmeans")
-)
 
+// Kmeans is raw api for kmeans.
+// centroids are used as the initial centroids if init is the Array method.
 func Kmeans(
 	deviceResource *DeviceResource,
 	x []float32,
@@ -67,3 +66,61 @@ func Kmeans(
 
 	return labels, centroids, inertia, nIter, nil
 }
+
+// Kmeans64 is the double precision variant of Kmeans.
+func Kmeans64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	k int,
+	maxIter int,
+	tol float64,
+	init int,
+	metric int,
+	seed int,
+	verbosity int,
+	labels []int32,
+	centroids []float64,
+) (
+	[]int32,
+	[]float64,
+	float64,
+	int32,
+	error,
+) {
+	if labels == nil {
+		labels = make([]int32, numRow)
+	}
+
+	if centroids == nil {
+		centroids = make([]float64, k*numCol)
+	}
+
+	var inertia float64
+	var nIter int32
+
+	ret := C.KmeansFit64(
+		deviceResource.pointer,
+		(*C.double)(&x[0]),
+		(C.int)(numRow),
+		(C.int)(numCol),
+		(C.int)(k),
+		(C.int)(maxIter),
+		(C.double)(tol),
+		C.int(init),
+		C.int(metric),
+		(C.int)(seed),
+		(C.int)(verbosity),
+		(*C.int)(&labels[0]),
+		(*C.double)(&centroids[0]),
+		(*C.double)(&inertia),
+		(*C.int)(&nIter),
+	)
+
+	if ret != 0 {
+		return nil, nil, 0, 0, ErrKmeans
+	}
+
+	return labels, centroids, inertia, nIter, nil
+}
@@ -1,39 +1,11 @@
+//go:build cgo && !nocuml
+
 package rawcuml4go
 
 // #cgo LDFLAGS: -lcuml4c -lcuml++ -lcuml
 // #include <stdlib.h>
 // #include "cuml4c/linear_regression.h"
 import "C"
-import (
-	"errors"
-)
-
-var (
-	ErrLinearRegressionFit     = errors.New("raw api: fail to linear regression fit")
-	ErrLinearRegressionPredict = errors.New("raw api: fail to linear regression predict")
-	ErrRidgeRegressionFit      = errors.New("raw api: fail to ridge regression fit")
-	ErrRidgeRegressionPredict  = errors.New("raw api: fail to ridge regression predict")
-)
-
-type LinearRegression struct {
-	coef         []float32
-	intercept    float32
-	fitIntercept bool
-	normalize    bool
-	algo         int
-}
-
-func NewLinearRegression(
-	fitIntercept bool,
-	normalize bool,
-	algo int,
-) *LinearRegression {
-	return &LinearRegression{
-		fitIntercept: fitIntercept,
-		normalize:    normalize,
-		algo:         algo,
-	}
-}
 
 func (m *LinearRegression) Fit(
 	deviceResource *DeviceResource,
@@ -85,6 +57,9 @@ func (m *LinearRegression) Predict(
 		(*C.float)(&result[0]),
 	)
 
+	if ret == C.LINEAR_REGRESSION_OUT_OF_MEMORY {
+		return nil, ErrOutOfMemory
+	}
 	if ret != 0 {
 		return nil, ErrLinearRegressionPredict
 	}
@@ -92,35 +67,67 @@ func (m *LinearRegression) Predict(
 	return result, nil
 }
 
-func (m *LinearRegression) GetParams() []float32 {
-	return m.coef
-}
+// Fit64 is the double precision variant of Fit.
+// The fitted parameters are kept apart from the ones fitted by Fit.
+func (m *LinearRegression) Fit64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	m.coef64 = make([]float64, numCol)
 
-func (m *LinearRegression) SetParams(coef []float32) {
-	m.coef = coef
-}
+	ret := C.OlsFit64(
+		deviceResource.pointer,
+		(*C.double)(&x[0]),
+		(C.ulong)(numRow),
+		(C.ulong)(numCol),
+		(*C.double)(&labels[0]),
+		(C.bool)(m.fitIntercept),
+		(C.bool)(m.normalize),
+		(C.int)(m.algo),
+		(*C.double)(&m.coef64[0]),
+		(*C.double)(&m.intercept64),
+	)
 
-type RidgeRegression struct {
-	coef         []float32
-	intercept    float32
-	alpha        float32
-	fitIntercept bool
-	normalize    bool
-	algo         int
+	if ret != 0 {
+		return ErrLinearRegressionFit
+	}
+
+	return nil
 }
 
-func NewRidgeRegression(
-	alpha float32,
-	fitIntercept bool,
-	normalize bool,
-	algo int,
-) *RidgeRegression {
-	return &RidgeRegression{
-		alpha:        alpha,
-		fitIntercept: fitIntercept,
-		normalize:    normalize,
-		algo:         algo,
+// Predict64 is the double precision variant of Predict.
+func (m *LinearRegression) Predict64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	if result == nil {
+		result = make([]float64, numRow)
+	}
+
+	ret := C.GemmPredict64(
+		deviceResource.pointer,
+		(*C.double)(&x[0]),
+		(C.ulong)(numRow),
+		(C.ulong)(numCol),
+		(*C.double)(&m.coef64[0]),
+		(C.double)(m.intercept64),
+		(*C.double)(&result[0]),
+	)
+
+	if ret == C.LINEAR_REGRESSION_OUT_OF_MEMORY {
+		return nil, ErrOutOfMemory
+	}
+	if ret != 0 {
+		return nil, ErrLinearRegressionPredict
 	}
+
+	return result, nil
 }
 
 func (m *RidgeRegression) Fit(
@@ -177,6 +184,9 @@ func (m *RidgeRegression) Predict(
 		(*C.float)(&result[0]),
 	)
 
+	if ret == C.LINEAR_REGRESSION_OUT_OF_MEMORY {
+		return nil, ErrOutOfMemory
+	}
 	if ret != 0 {
 		return nil, ErrLinearRegressionPredict
 	}
@@ -184,10 +194,69 @@ func (m *RidgeRegression) Predict(
 	return result, nil
 }
 
-func (m *RidgeRegression) GetParams() []float32 {
-	return m.coef
+// Fit64 is the double precision variant of Fit.
+// The fitted parameters are kept apart from the ones fitted by Fit.
+func (m *RidgeRegression) Fit64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	m.coef64 = make([]float64, numCol)
+
+	alpha := []float64{float64(m.alpha)}
+
+	ret := C.RidgeFit64(
+		deviceResource.pointer,
+		(*C.double)(&x[0]),
+		(C.ulong)(numRow),
+		(C.ulong)(numCol),
+		(*C.double)(&labels[0]),
+		(*C.double)(&alpha[0]),
+		(C.ulong)(len(alpha)),
+		(C.bool)(m.fitIntercept),
+		(C.bool)(m.normalize),
+		(C.int)(m.algo),
+		(*C.double)(&m.coef64[0]),
+		(*C.double)(&m.intercept64),
+	)
+
+	if ret != 0 {
+		return ErrRidgeRegressionFit
+	}
+
+	return nil
 }
 
-func (m *RidgeRegression) SetParams(coef []float32) {
-	m.coef = coef
+// Predict64 is the double precision variant of Predict.
+func (m *RidgeRegression) Predict64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	if result == nil {
+		result = make([]float64, numRow)
+	}
+
+	ret := C.GemmPredict64(
+		deviceResource.pointer,
+		(*C.double)(&x[0]),
+		(C.ulong)(numRow),
+		(C.ulong)(numCol),
+		(*C.double)(&m.coef64[0]),
+		(C.double)(m.intercept64),
+		(*C.double)(&result[0]),
+	)
+
+	if ret == C.LINEAR_REGRESSION_OUT_OF_MEMORY {
+		return nil, ErrOutOfMemory
+	}
+	if ret != 0 {
+		return nil, ErrRidgeRegressionPredict
+	}
+
+	return result, nil
 }
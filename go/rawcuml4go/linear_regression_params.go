@@ -0,0 +1,96 @@
+package rawcuml4go
+
+type LinearRegression struct {
+	coef         []float32
+	intercept    float32
+	coef64       []float64
+	intercept64  float64
+	fitIntercept bool
+	normalize    bool
+	algo         int
+}
+
+func NewLinearRegression(
+	fitIntercept bool,
+	normalize bool,
+	algo int,
+) *LinearRegression {
+	return &LinearRegression{
+		fitIntercept: fitIntercept,
+		normalize:    normalize,
+		algo:         algo,
+	}
+}
+
+func (m *LinearRegression) GetParams() []float32 {
+	return m.coef
+}
+
+func (m *LinearRegression) SetParams(coef []float32) {
+	m.coef = coef
+}
+
+func (m *LinearRegression) GetIntercept() float32 {
+	return m.intercept
+}
+
+func (m *LinearRegression) SetIntercept(intercept float32) {
+	m.intercept = intercept
+}
+
+func (m *LinearRegression) GetParams64() []float64 {
+	return m.coef64
+}
+
+func (m *LinearRegression) SetParams64(coef []float64) {
+	m.coef64 = coef
+}
+
+type RidgeRegression struct {
+	coef         []float32
+	intercept    float32
+	coef64       []float64
+	intercept64  float64
+	alpha        float32
+	fitIntercept bool
+	normalize    bool
+	algo         int
+}
+
+func NewRidgeRegression(
+	alpha float32,
+	fitIntercept bool,
+	normalize bool,
+	algo int,
+) *RidgeRegression {
+	return &RidgeRegression{
+		alpha:        alpha,
+		fitIntercept: fitIntercept,
+		normalize:    normalize,
+		algo:         algo,
+	}
+}
+
+func (m *RidgeRegression) GetParams() []float32 {
+	return m.coef
+}
+
+func (m *RidgeRegression) SetParams(coef []float32) {
+	m.coef = coef
+}
+
+func (m *RidgeRegression) GetIntercept() float32 {
+	return m.intercept
+}
+
+func (m *RidgeRegression) SetIntercept(intercept float32) {
+	m.intercept = intercept
+}
+
+func (m *RidgeRegression) GetParams64() []float64 {
+	return m.coef64
+}
+
+func (m *RidgeRegression) SetParams64(coef []float64) {
+	m.coef64 = coef
+}
@@ -9,6 +9,7 @@ import (
 )
 
 func TestLinearRegression(t *testing.T) {
+	skipWithoutNativeLibrary(t)
 	deviceResource, err := rawcuml4go.NewDeviceResource()
 	require.NoError(t, err)
 	defer deviceResource.Close()
@@ -35,6 +36,7 @@ func TestLinearRegression(t *testing.T) {
 }
 
 func TestRidgeRegression(t *testing.T) {
+	skipWithoutNativeLibrary(t)
 	deviceResource, err := rawcuml4go.NewDeviceResource()
 	require.NoError(t, err)
 	defer deviceResource.Close()
@@ -60,3 +62,30 @@ func TestRidgeRegression(t *testing.T) {
 
 	require.Equal(t, len(labels), len(preds))
 }
+
+func TestLinearRegression64(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	deviceResource, err := rawcuml4go.NewDeviceResource()
+	require.NoError(t, err)
+	defer deviceResource.Close()
+
+	target := rawcuml4go.NewLinearRegression(
+		true,
+		false,
+		cuml4go.Svd,
+	)
+
+	features := csvToFloat64Array(t, "../../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	labels := csvToFloat64Array(t, "../../testdata/label.csv")
+
+	err = target.Fit64(deviceResource, features, featureRow, featureCol, labels)
+	require.NoError(t, err)
+
+	preds, err := target.Predict64(deviceResource, features, featureRow, featureCol, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, len(labels), len(preds))
+}
@@ -0,0 +1,329 @@
+//go:build !cgo || nocuml
+
+package rawcuml4go
+
+// this file replaces the native calls when the package is built without the native libraries,
+// so that the CPU backend of package cuml4go builds with CGO_ENABLED=0 or the nocuml build tag.
+// DeviceCount reports no device, and every other call returns ErrNoNativeLibrary.
+
+// HasNativeLibrary reports whether the package calls the native libraries.
+const HasNativeLibrary = false
+
+// DeviceResource is not empty, since distinct pointers to zero-size values may be equal,
+// and the handles of a pool are told apart by their pointers.
+type DeviceResource struct {
+	_ byte
+}
+
+func NewDeviceResource() (*DeviceResource, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (d *DeviceResource) Close() error {
+	return ErrNoNativeLibrary
+}
+
+// DeviceCount returns 0 since no device is usable without the native libraries.
+func DeviceCount() (int, error) {
+	return 0, nil
+}
+
+type MemoryResource struct {
+	_ byte
+}
+
+func (m *MemoryResource) Close() error {
+	return ErrNoNativeLibrary
+}
+
+func (m *MemoryResource) Statistics() (
+	allocatedBytes uint64,
+	peakBytes uint64,
+	poolSize uint64,
+	hasPoolSize bool,
+	err error,
+) {
+	return 0, 0, 0, false, ErrNoNativeLibrary
+}
+
+func UsePoolMemoryResource(
+	initialPoolSize uint64,
+	maximumPoolSize uint64,
+) (*MemoryResource, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func UseBinningMemoryResource(
+	minSizeExponent uint8,
+	maxSizeExponent uint8,
+) (*MemoryResource, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func UseArenaMemoryResource(arena_size uint64) (
+	*MemoryResource,
+	error,
+) {
+	return nil, ErrNoNativeLibrary
+}
+
+type FILModel struct {
+	_ byte
+}
+
+func NewFILModel(
+	deviceResource *DeviceResource,
+	modelType int,
+	filePath string,
+	algo int,
+	classification bool,
+	threshold float32,
+	storageType int,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func NewFILModelFromBytes(
+	deviceResource *DeviceResource,
+	modelType int,
+	data []byte,
+	algo int,
+	classification bool,
+	threshold float32,
+	storageType int,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func NewFILModelFromTrees(
+	deviceResource *DeviceResource,
+	trees *FILTrees,
+	algo int,
+	classification bool,
+	threshold float32,
+	storageType int,
+	blocksPerSm int,
+	threadsPerTree int,
+	nItems int,
+) (*FILModel, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *FILModel) Predict(
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	preds []float32,
+) ([]float32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *FILModel) PredictOn(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+	preds []float32,
+) ([]float32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *FILModel) Close() error {
+	return ErrNoNativeLibrary
+}
+
+func (m *FILModel) CloseOn(deviceResource *DeviceResource) error {
+	return ErrNoNativeLibrary
+}
+
+func (m *FILModel) NumFeatures() (int, error) {
+	return 0, ErrNoNativeLibrary
+}
+
+func (m *FILModel) NumClasses() int {
+	return 0
+}
+
+func Kmeans(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	k int,
+	maxIter int,
+	tol float64,
+	init int,
+	metric int,
+	seed int,
+	verbosity int,
+	labels []int32,
+	centroids []float32,
+) (
+	[]int32,
+	[]float32,
+	float32,
+	int32,
+	error,
+) {
+	return nil, nil, 0, 0, ErrNoNativeLibrary
+}
+
+func Kmeans64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	k int,
+	maxIter int,
+	tol float64,
+	init int,
+	metric int,
+	seed int,
+	verbosity int,
+	labels []int32,
+	centroids []float64,
+) (
+	[]int32,
+	[]float64,
+	float64,
+	int32,
+	error,
+) {
+	return nil, nil, 0, 0, ErrNoNativeLibrary
+}
+
+func DBScan(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	minPts int,
+	eps float64,
+	metric int,
+	maxBytesPerBatch int,
+	verbosity int,
+	labels []int32,
+) ([]int32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func DBScan64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	minPts int,
+	eps float64,
+	metric int,
+	maxBytesPerBatch int,
+	verbosity int,
+	labels []int32,
+) ([]int32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func AgglomerativeClustering(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	pairwiseConn bool,
+	metric int,
+	initNumCluster int,
+	numNeighbor int,
+	labels []int32,
+	children []int32,
+) (
+	[]int32,
+	[]int32,
+	int32,
+	error,
+) {
+	return nil, nil, 0, ErrNoNativeLibrary
+}
+
+func (m *LinearRegression) Fit(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	labels []float32,
+) error {
+	return ErrNoNativeLibrary
+}
+
+func (m *LinearRegression) Predict(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	result []float32,
+) ([]float32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *LinearRegression) Fit64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	return ErrNoNativeLibrary
+}
+
+func (m *LinearRegression) Predict64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *RidgeRegression) Fit(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	labels []float32,
+) error {
+	return ErrNoNativeLibrary
+}
+
+func (m *RidgeRegression) Predict(
+	deviceResource *DeviceResource,
+	x []float32,
+	numRow int,
+	numCol int,
+	result []float32,
+) ([]float32, error) {
+	return nil, ErrNoNativeLibrary
+}
+
+func (m *RidgeRegression) Fit64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	labels []float64,
+) error {
+	return ErrNoNativeLibrary
+}
+
+func (m *RidgeRegression) Predict64(
+	deviceResource *DeviceResource,
+	x []float64,
+	numRow int,
+	numCol int,
+	result []float64,
+) ([]float64, error) {
+	return nil, ErrNoNativeLibrary
+}
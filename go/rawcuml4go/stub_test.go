@@ -0,0 +1,23 @@
+//go:build !cgo || nocuml
+
+package rawcuml4go_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+)
+
+func TestStub(t *testing.T) {
+	count, err := rawcuml4go.DeviceCount()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	_, err = rawcuml4go.NewDeviceResource()
+	require.ErrorIs(t, err, rawcuml4go.ErrNoNativeLibrary)
+
+	_, err = rawcuml4go.NewFILModelFromTrees(nil, &rawcuml4go.FILTrees{}, 0, false, 0, 0, 0, 1, 0)
+	require.ErrorIs(t, err, rawcuml4go.ErrNoNativeLibrary)
+}
@@ -0,0 +1,387 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+var (
+	// ErrInvalidReloadConfig is returned when a reload config is invalid.
+	ErrInvalidReloadConfig = errors.New("invalid reload config")
+	// ErrModelValidation is returned when a new model fails the validation before a swap.
+	ErrModelValidation = errors.New("model failed validation")
+	// ErrNoPreviousModel is returned by Rollback when there is no model to roll back to.
+	ErrNoPreviousModel = errors.New("no previous model")
+	// ErrReloadableModelClosed is returned when a reloadable model is used after Close.
+	ErrReloadableModelClosed = errors.New("reloadable model is closed")
+)
+
+// ClosablePredictor is a Predictor which owns resources, e.g. FILModel.
+type ClosablePredictor interface {
+	Predictor
+	Close() error
+}
+
+// ModelLoader loads a new version of a model, e.g. from a file on a shared volume.
+type ModelLoader func(ctx context.Context) (ClosablePredictor, error)
+
+// ReloadConfig is the configuration of ReloadableModel.
+type ReloadConfig struct {
+	// SampleX are SampleNumRow rows which a new model must predict before it is swapped in.
+	// the predictions must be finite.
+	SampleX      []float32
+	SampleNumRow int
+	// OutputClassProbability is passed to the predictions of SampleX.
+	OutputClassProbability bool
+	// Expected are the predictions of SampleX which a new model must reproduce within Tolerance.
+	// nil skips the comparison.
+	Expected  []float32
+	Tolerance float32
+	// Check is an additional check of a new model, called after the sample predictions.
+	Check func(ctx context.Context, model Predictor) error
+	// WatchPath is a file polled every PollInterval. a change of its modification time or size
+	// reloads the model in the background with the loader.
+	WatchPath    string
+	PollInterval time.Duration
+	// OnReload is called with the result of every background reload.
+	OnReload func(err error)
+}
+
+// Validate checks the configuration.
+func (c ReloadConfig) Validate() error {
+	if c.SampleNumRow < 0 || c.Tolerance < 0 || c.WatchPath != "" && c.PollInterval <= 0 {
+		return ErrInvalidReloadConfig
+	}
+	return nil
+}
+
+// modelVersion is a model with the predictions in flight on it.
+type modelVersion struct {
+	model   ClosablePredictor
+	version int
+
+	// mu guards closed; predictions hold the read lock.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// close waits for in-flight predictions and closes the model.
+func (v *modelVersion) close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.closed {
+		return nil
+	}
+	v.closed = true
+	return v.model.Close()
+}
+
+// ReloadableModel is a Predictor whose model can be replaced while it serves predictions.
+// a new model is loaded and validated while the current one keeps serving,
+// then it is swapped in atomically. the replaced model is kept for Rollback,
+// and the one before it is closed after its in-flight predictions drain.
+// ReloadableModel is safe for concurrent use.
+type ReloadableModel struct {
+	load   ModelLoader
+	config ReloadConfig
+
+	current atomic.Pointer[modelVersion]
+
+	// mu serializes swaps and guards previous, nextVersion and closed.
+	mu          sync.Mutex
+	previous    *modelVersion
+	nextVersion int
+	closed      bool
+
+	done     chan struct{}
+	watchers sync.WaitGroup
+}
+
+// NewReloadableModel loads the first model with load, which is also used by Reload and the file watcher.
+func NewReloadableModel(ctx context.Context, load ModelLoader, config ReloadConfig) (*ReloadableModel, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	model, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ReloadableModel{
+		load:   load,
+		config: config,
+		done:   make(chan struct{}),
+	}
+	if err := m.validate(ctx, model, model.NumFeatures()); err != nil {
+		return nil, multierr.Append(err, model.Close())
+	}
+	m.current.Store(&modelVersion{model: model, version: m.nextVersion})
+	m.nextVersion++
+
+	if config.WatchPath != "" {
+		stat, err := os.Stat(config.WatchPath)
+		if err != nil {
+			return nil, multierr.Append(err, m.Close())
+		}
+		m.watchers.Add(1)
+		go m.watch(stat)
+	}
+	return m, nil
+}
+
+// Version returns the version of the current model. the first model is version 0,
+// and every swap assigns the next version.
+func (m *ReloadableModel) Version() int {
+	return m.current.Load().version
+}
+
+// NumFeatures returns the number of features of the current model.
+// every version has the same number of features.
+func (m *ReloadableModel) NumFeatures() int {
+	return m.current.Load().model.NumFeatures()
+}
+
+// PredictContext predicts with the current model.
+func (m *ReloadableModel) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	var preds []float32
+	err := m.withCurrent(func(model Predictor) error {
+		var err error
+		preds, err = model.PredictContext(ctx, x, numRow, outputClassProbability)
+		return err
+	})
+	return preds, err
+}
+
+// PredictInto is the same as PredictContext but writes the result of the current model into dst,
+// whose length must be the length of the result.
+func (m *ReloadableModel) PredictInto(
+	dst []float32,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) error {
+	return m.PredictIntoContext(context.Background(), dst, x, numRow, outputClassProbability)
+}
+
+// PredictIntoContext is the context-aware variant of PredictInto.
+// the result is written without a copy if the current model has a PredictIntoContext method, e.g. FILModel.
+func (m *ReloadableModel) PredictIntoContext(
+	ctx context.Context,
+	dst []float32,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) error {
+	if len(dst) != numRow*filOutputWidth(outputClassProbability) {
+		return ErrInvalidOutputLength
+	}
+	return m.withCurrent(func(model Predictor) error {
+		if into, ok := model.(intoPredictor); ok {
+			return into.PredictIntoContext(ctx, dst, x, numRow, outputClassProbability)
+		}
+		preds, err := model.PredictContext(ctx, x, numRow, outputClassProbability)
+		if err != nil {
+			return err
+		}
+		if len(preds) != len(dst) {
+			return ErrInvalidOutputLength
+		}
+		copy(dst, preds)
+		return nil
+	})
+}
+
+// intoPredictor is a Predictor which writes its result into a buffer of the caller.
+type intoPredictor interface {
+	PredictIntoContext(
+		ctx context.Context,
+		dst []float32,
+		x []float32,
+		numRow int,
+		outputClassProbability bool,
+	) error
+}
+
+// withCurrent calls fn with the current model, which is not closed until fn returns.
+func (m *ReloadableModel) withCurrent(fn func(model Predictor) error) error {
+	for {
+		v := m.current.Load()
+		v.mu.RLock()
+		if v.closed {
+			v.mu.RUnlock()
+			if m.isClosed() {
+				return ErrReloadableModelClosed
+			}
+			// swapped and closed since the load; retry with the new model
+			continue
+		}
+		err := fn(v.model)
+		v.mu.RUnlock()
+		return err
+	}
+}
+
+// Reload loads a new model with the loader and swaps it in.
+func (m *ReloadableModel) Reload(ctx context.Context) error {
+	model, err := m.load(ctx)
+	if err != nil {
+		return err
+	}
+	return m.Swap(ctx, model)
+}
+
+// Swap validates model and swaps it in. the model is owned by m from now on,
+// and is closed if the validation fails.
+func (m *ReloadableModel) Swap(ctx context.Context, model ClosablePredictor) error {
+	if err := m.validate(ctx, model, m.NumFeatures()); err != nil {
+		return multierr.Append(err, model.Close())
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return multierr.Append(ErrReloadableModelClosed, model.Close())
+	}
+	retired := m.previous
+	m.previous = m.current.Swap(&modelVersion{model: model, version: m.nextVersion})
+	m.nextVersion++
+	m.mu.Unlock()
+
+	if retired != nil {
+		return retired.close()
+	}
+	return nil
+}
+
+// Rollback swaps the previous model back in and closes the current one
+// after its in-flight predictions drain.
+func (m *ReloadableModel) Rollback() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrReloadableModelClosed
+	}
+	if m.previous == nil {
+		m.mu.Unlock()
+		return ErrNoPreviousModel
+	}
+	retired := m.current.Swap(m.previous)
+	m.previous = nil
+	m.mu.Unlock()
+
+	return retired.close()
+}
+
+// Close stops the file watcher and closes the current and the previous models
+// after their in-flight predictions drain.
+func (m *ReloadableModel) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.done)
+	m.mu.Unlock()
+
+	m.watchers.Wait()
+
+	var err error
+	if m.previous != nil {
+		err = m.previous.close()
+	}
+	return multierr.Append(err, m.current.Load().close())
+}
+
+func (m *ReloadableModel) isClosed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// validate checks the number of features and the sample predictions of model.
+func (m *ReloadableModel) validate(ctx context.Context, model Predictor, numFeatures int) error {
+	if model.NumFeatures() != numFeatures {
+		return multierr.Append(ErrModelValidation, ErrDimensionMismatch)
+	}
+
+	c := &m.config
+	if c.SampleNumRow > 0 {
+		preds, err := model.PredictContext(ctx, c.SampleX, c.SampleNumRow, c.OutputClassProbability)
+		if err != nil {
+			return multierr.Append(ErrModelValidation, err)
+		}
+		if c.Expected != nil && len(c.Expected) != len(preds) {
+			return ErrModelValidation
+		}
+		for i, p := range preds {
+			if math.IsNaN(float64(p)) || math.IsInf(float64(p), 0) {
+				return ErrModelValidation
+			}
+			if c.Expected != nil && math.Abs(float64(p-c.Expected[i])) > float64(c.Tolerance) {
+				return ErrModelValidation
+			}
+		}
+	}
+
+	if c.Check != nil {
+		if err := c.Check(ctx, model); err != nil {
+			return multierr.Append(ErrModelValidation, err)
+		}
+	}
+	return nil
+}
+
+// watch polls the watched file and reloads the model when it changes.
+func (m *ReloadableModel) watch(last os.FileInfo) {
+	defer m.watchers.Done()
+
+	ticker := time.NewTicker(m.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		stat, err := os.Stat(m.config.WatchPath)
+		if err != nil {
+			// the file may be replaced by a rename; try again on the next tick
+			continue
+		}
+		if stat.ModTime().Equal(last.ModTime()) && stat.Size() == last.Size() {
+			continue
+		}
+		last = stat
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-m.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err = m.Reload(ctx)
+		cancel()
+
+		if m.config.OnReload != nil {
+			m.config.OnReload(err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package cuml4go_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+// constPredictor predicts value for every row, and blocks while block is open.
+type constPredictor struct {
+	value       float32
+	numFeatures int
+	block       chan struct{}
+	started     chan struct{}
+	closed      atomic.Bool
+}
+
+func newConstPredictor(value float32) *constPredictor {
+	return &constPredictor{value: value, numFeatures: 2}
+}
+
+func (p *constPredictor) PredictContext(
+	ctx context.Context,
+	x []float32,
+	numRow int,
+	outputClassProbability bool,
+) ([]float32, error) {
+	if p.closed.Load() {
+		return nil, cuml4go.ErrFILModelClosed
+	}
+	if p.started != nil {
+		p.started <- struct{}{}
+	}
+	if p.block != nil {
+		<-p.block
+	}
+	preds := make([]float32, numRow)
+	for i := range preds {
+		preds[i] = p.value
+	}
+	return preds, nil
+}
+
+func (p *constPredictor) NumFeatures() int {
+	return p.numFeatures
+}
+
+func (p *constPredictor) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func predictOne(t *testing.T, m *cuml4go.ReloadableModel) float32 {
+	t.Helper()
+	preds, err := m.PredictContext(context.Background(), []float32{0, 0}, 1, false)
+	require.NoError(t, err)
+	return preds[0]
+}
+
+func TestReloadableModelSwapAndRollback(t *testing.T) {
+	models := []*constPredictor{newConstPredictor(0), newConstPredictor(1), newConstPredictor(2)}
+	next := 0
+	load := func(ctx context.Context) (cuml4go.ClosablePredictor, error) {
+		model := models[next]
+		next++
+		return model, nil
+	}
+
+	target, err := cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 0, target.Version())
+	require.Equal(t, float32(0), predictOne(t, target))
+	require.ErrorIs(t, target.Rollback(), cuml4go.ErrNoPreviousModel)
+
+	require.NoError(t, target.Reload(context.Background()))
+	require.Equal(t, 1, target.Version())
+	require.Equal(t, float32(1), predictOne(t, target))
+	require.False(t, models[0].closed.Load())
+
+	require.NoError(t, target.Swap(context.Background(), models[2]))
+	require.Equal(t, 2, target.Version())
+	require.Equal(t, float32(2), predictOne(t, target))
+	require.True(t, models[0].closed.Load())
+	require.False(t, models[1].closed.Load())
+
+	require.NoError(t, target.Rollback())
+	require.Equal(t, 1, target.Version())
+	require.Equal(t, float32(1), predictOne(t, target))
+	require.True(t, models[2].closed.Load())
+	require.ErrorIs(t, target.Rollback(), cuml4go.ErrNoPreviousModel)
+
+	require.NoError(t, target.Close())
+	require.True(t, models[1].closed.Load())
+	_, err = target.PredictContext(context.Background(), []float32{0, 0}, 1, false)
+	require.ErrorIs(t, err, cuml4go.ErrReloadableModelClosed)
+	require.ErrorIs(t, target.Swap(context.Background(), newConstPredictor(3)), cuml4go.ErrReloadableModelClosed)
+	require.NoError(t, target.Close())
+}
+
+func TestReloadableModelPredictInto(t *testing.T) {
+	resources, err := cuml4go.NewFakeResources(1)
+	require.NoError(t, err)
+	defer resources.Close()
+	fil, err := cuml4go.NewFILModelFromForest(newFakeForest(2), resources)
+	require.NoError(t, err)
+
+	load := func(ctx context.Context) (cuml4go.ClosablePredictor, error) {
+		return newConstPredictor(0.5), nil
+	}
+	target, err := cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{})
+	require.NoError(t, err)
+	defer target.Close()
+
+	x := []float32{0.1, 0.2, 0.3, 0.4}
+	dst := make([]float32, 2)
+	require.NoError(t, target.PredictInto(dst, x, 2, false))
+	require.Equal(t, []float32{0.5, 0.5}, dst)
+	require.ErrorIs(t, target.PredictInto(dst, x, 2, true), cuml4go.ErrInvalidOutputLength)
+
+	// a FILModel writes into dst itself
+	require.NoError(t, target.Swap(context.Background(), fil))
+	dst = make([]float32, 4)
+	require.NoError(t, target.PredictIntoContext(context.Background(), dst, x, 2, true))
+	require.InDeltaSlice(t, []float32{0.7, 0.3, 0.3, 0.7}, dst, 1e-6)
+	require.ErrorIs(t, target.PredictInto(dst, x[:3], 2, true), cuml4go.ErrDimensionMismatch)
+}
+
+func TestReloadableModelValidation(t *testing.T) {
+	current := newConstPredictor(1)
+	load := func(ctx context.Context) (cuml4go.ClosablePredictor, error) {
+		return current, nil
+	}
+
+	target, err := cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{
+		SampleX:      []float32{0, 0, 1, 1},
+		SampleNumRow: 2,
+		Expected:     []float32{1, 1},
+		Tolerance:    0.1,
+	})
+	require.NoError(t, err)
+	defer target.Close()
+
+	wrongFeatures := newConstPredictor(1)
+	wrongFeatures.numFeatures = 3
+	require.ErrorIs(t, target.Swap(context.Background(), wrongFeatures), cuml4go.ErrModelValidation)
+	require.True(t, wrongFeatures.closed.Load())
+
+	wrongPredictions := newConstPredictor(2)
+	require.ErrorIs(t, target.Swap(context.Background(), wrongPredictions), cuml4go.ErrModelValidation)
+	require.True(t, wrongPredictions.closed.Load())
+
+	require.NoError(t, target.Swap(context.Background(), newConstPredictor(1.05)))
+	require.Equal(t, 1, target.Version())
+	require.False(t, current.closed.Load())
+
+	_, err = cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{WatchPath: "model.json"})
+	require.ErrorIs(t, err, cuml4go.ErrInvalidReloadConfig)
+}
+
+func TestReloadableModelDrainsInFlight(t *testing.T) {
+	old := newConstPredictor(0)
+	old.block = make(chan struct{})
+	old.started = make(chan struct{})
+	load := func(ctx context.Context) (cuml4go.ClosablePredictor, error) {
+		return old, nil
+	}
+
+	target, err := cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{})
+	require.NoError(t, err)
+	defer target.Close()
+
+	predicted := make(chan []float32, 1)
+	go func() {
+		preds, _ := target.PredictContext(context.Background(), []float32{0, 0}, 1, false)
+		predicted <- preds
+	}()
+	<-old.started
+
+	// the second swap retires the old model, which must wait for the prediction
+	require.NoError(t, target.Swap(context.Background(), newConstPredictor(1)))
+	swapped := make(chan error)
+	go func() {
+		swapped <- target.Swap(context.Background(), newConstPredictor(2))
+	}()
+
+	require.Eventually(t, func() bool { return target.Version() == 2 }, time.Second, time.Millisecond)
+	require.Equal(t, float32(2), predictOne(t, target))
+	select {
+	case <-swapped:
+		t.Fatal("old model closed during a prediction")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.False(t, old.closed.Load())
+
+	close(old.block)
+	require.NoError(t, <-swapped)
+	require.Equal(t, []float32{0}, <-predicted)
+	require.True(t, old.closed.Load())
+}
+
+func TestReloadableModelWatchFile(t *testing.T) {
+	data, err := os.ReadFile("../testdata/xgboost.json")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "model.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	load := func(ctx context.Context) (cuml4go.ClosablePredictor, error) {
+		return cuml4go.NewFILModel(
+			cuml4go.XGBoostJSON,
+			path,
+			cuml4go.AlgoAuto,
+			true,
+			0.5,
+			cuml4go.Auto,
+			0,
+			1,
+			0,
+			cuml4go.WithBackend(cuml4go.CPUBackend))
+	}
+
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	expectedScores := csvToFloat32Array(t, "../testdata/score-xgboost.csv")
+
+	reloaded := make(chan error, 1)
+	target, err := cuml4go.NewReloadableModel(context.Background(), load, cuml4go.ReloadConfig{
+		SampleX:                features[:30*4],
+		SampleNumRow:           4,
+		OutputClassProbability: true,
+		WatchPath:              path,
+		PollInterval:           10 * time.Millisecond,
+		OnReload: func(err error) {
+			reloaded <- err
+		},
+	})
+	require.NoError(t, err)
+	defer target.Close()
+	require.Equal(t, 30, target.NumFeatures())
+
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, later, later))
+	require.NoError(t, <-reloaded)
+	require.Equal(t, 1, target.Version())
+
+	preds, err := target.PredictContext(context.Background(), features, 114, true)
+	require.NoError(t, err)
+	for i, score := range expectedScores {
+		require.InDelta(t, score, preds[2*i+1], 1e-5)
+	}
+
+	// a broken file keeps the current model
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0o600))
+	require.ErrorIs(t, <-reloaded, cuml4go.ErrFILModelLoad)
+	require.Equal(t, 1, target.Version())
+}
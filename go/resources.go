@@ -0,0 +1,152 @@
+package cuml4go
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
+	"go.uber.org/multierr"
+)
+
+var (
+	// ErrInvalidResourcesSize is returned when the size of resources is not positive.
+	ErrInvalidResourcesSize = errors.New("resources size must be positive")
+	// ErrResourcesClosed is returned when resources are used after Close.
+	ErrResourcesClosed = errors.New("resources are closed")
+)
+
+// Resources is a bounded pool of device resource handles.
+// a handle owns a CUDA stream and the library handles bound to it;
+// creating one is expensive, so create Resources once and share them
+// between estimators with WithResources.
+// every native call borrows one handle exclusively for its duration,
+// so a handle is never used by two calls at once;
+// at most Size calls run concurrently and the others wait for a free handle.
+// Resources are safe for concurrent use.
+type Resources struct {
+	handles     chan *rawcuml4go.DeviceResource
+	all         []*rawcuml4go.DeviceResource
+	done        chan struct{}
+	closeHandle func(*rawcuml4go.DeviceResource) error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// DeviceCount returns the number of CUDA devices, or 0 if no device or driver is available.
+// a process without a device can still use FILModel with WithBackend(CPUBackend).
+func DeviceCount() int {
+	count, err := rawcuml4go.DeviceCount()
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// NewResources creates size device resource handles.
+func NewResources(size int) (*Resources, error) {
+	return newResources(size, rawcuml4go.NewDeviceResource, (*rawcuml4go.DeviceResource).Close)
+}
+
+// newHostResources creates resources for host computations,
+// whose handles are placeholders which are never passed to the native library.
+// they only bound the concurrency.
+func newHostResources(size int) (*Resources, error) {
+	return newResources(
+		size,
+		func() (*rawcuml4go.DeviceResource, error) {
+			return new(rawcuml4go.DeviceResource), nil
+		},
+		func(*rawcuml4go.DeviceResource) error {
+			return nil
+		},
+	)
+}
+
+// newResources creates size handles with newHandle, which are freed with closeHandle.
+func newResources(
+	size int,
+	newHandle func() (*rawcuml4go.DeviceResource, error),
+	closeHandle func(*rawcuml4go.DeviceResource) error,
+) (*Resources, error) {
+	if size <= 0 {
+		return nil, ErrInvalidResourcesSize
+	}
+
+	r := &Resources{
+		handles:     make(chan *rawcuml4go.DeviceResource, size),
+		all:         make([]*rawcuml4go.DeviceResource, 0, size),
+		done:        make(chan struct{}),
+		closeHandle: closeHandle,
+	}
+
+	for i := 0; i < size; i++ {
+		handle, err := newHandle()
+		if err != nil {
+			for _, h := range r.all {
+				err = multierr.Append(err, closeHandle(h))
+			}
+			return nil, err
+		}
+		r.all = append(r.all, handle)
+		r.handles <- handle
+	}
+
+	return r, nil
+}
+
+// Size returns the number of handles.
+func (r *Resources) Size() int {
+	return len(r.all)
+}
+
+// acquire borrows a handle, waiting until one is free or ctx is done.
+func (r *Resources) acquire(ctx context.Context) (*rawcuml4go.DeviceResource, error) {
+	select {
+	case <-r.done:
+		return nil, ErrResourcesClosed
+	default:
+	}
+
+	select {
+	case handle := <-r.handles:
+		return handle, nil
+	case <-r.done:
+		return nil, ErrResourcesClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release returns a handle borrowed by acquire.
+func (r *Resources) release(handle *rawcuml4go.DeviceResource) {
+	r.handles <- handle
+}
+
+// Close waits until every borrowed handle is returned and frees the handles.
+// estimators using the resources must not be used after Close.
+func (r *Resources) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		for range r.all {
+			handle := <-r.handles
+			r.closeErr = multierr.Append(r.closeErr, r.closeHandle(handle))
+		}
+	})
+	return r.closeErr
+}
+
+// with runs fn with a borrowed handle.
+func (r *Resources) with(
+	ctx context.Context,
+	fn func(deviceResource *rawcuml4go.DeviceResource) error,
+) error {
+	handle, err := r.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.release(handle)
+
+	return fn(handle)
+}
@@ -0,0 +1,64 @@
+package cuml4go_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cuml4go "github.com/getumen/cuml-bindings/go"
+)
+
+func TestNewResourcesInvalidSize(t *testing.T) {
+	_, err := cuml4go.NewResources(0)
+	require.ErrorIs(t, err, cuml4go.ErrInvalidResourcesSize)
+}
+
+func TestSharedResources(t *testing.T) {
+	skipWithoutNativeLibrary(t)
+	features := csvToFloat32Array(t, "../testdata/feature.csv")
+	featureCol := 30
+	featureRow := 114
+
+	resources, err := cuml4go.NewResources(2)
+	require.NoError(t, err)
+	require.Equal(t, 2, resources.Size())
+
+	kmeans, err := cuml4go.NewKmeans(
+		3, 10, 0.0, cuml4go.KMeansPlusPlus, cuml4go.L2Expanded, 42, cuml4go.Info,
+		cuml4go.WithResources(resources),
+	)
+	require.NoError(t, err)
+
+	dbscan, err := cuml4go.NewDBScan(
+		5, 3.0, cuml4go.L2SqrtUnexpanded, 0, cuml4go.Info,
+		cuml4go.WithResources(resources),
+	)
+	require.NoError(t, err)
+
+	labels, _, _, _, err := kmeans.Fit(features, featureRow, featureCol, nil)
+	require.NoError(t, err)
+	require.Len(t, labels, featureRow)
+
+	labels, err = dbscan.Fit(features, featureRow, featureCol)
+	require.NoError(t, err)
+	require.Len(t, labels, featureRow)
+
+	// closing estimators does not close shared resources
+	require.NoError(t, kmeans.Close())
+	require.NoError(t, dbscan.Close())
+
+	kmeans, err = cuml4go.NewKmeans(
+		3, 10, 0.0, cuml4go.KMeansPlusPlus, cuml4go.L2Expanded, 42, cuml4go.Info,
+		cuml4go.WithResources(resources),
+	)
+	require.NoError(t, err)
+
+	_, _, _, _, err = kmeans.Fit(features, featureRow, featureCol, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, resources.Close())
+	require.NoError(t, resources.Close())
+
+	_, _, _, _, err = kmeans.Fit(features, featureRow, featureCol, nil)
+	require.ErrorIs(t, err, cuml4go.ErrResourcesClosed)
+}
@@ -0,0 +1,332 @@
+// Package sqlgen exports models as SQL, so that a table can be scored in a database
+// with the same results as the Go CPU predictions.
+//
+// a forest becomes a query of nested CASE WHEN expressions, one per tree,
+// and a linear model becomes an expression of its coefficients.
+// missing values are NULL, which a split treats as a NaN of the Go predictions.
+package sqlgen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+)
+
+var (
+	// ErrInvalidDialect is returned when the dialect is unknown.
+	ErrInvalidDialect = errors.New("invalid sql dialect")
+	// ErrInvalidColumns is returned when the columns do not match the features of a model.
+	ErrInvalidColumns = errors.New("columns do not match the features")
+)
+
+// Dialect is the SQL dialect of the generated code.
+type Dialect int
+
+const (
+	// ANSI standard SQL, which SQLite also accepts
+	ANSI Dialect = iota
+	// BigQuery GoogleSQL of BigQuery
+	BigQuery
+	// PostgreSQL PostgreSQL
+	PostgreSQL
+)
+
+// Config configures the generated SQL.
+type Config struct {
+	// Dialect is the SQL dialect.
+	Dialect Dialect
+	// Columns are the column names of the features in order.
+	// they default to the FeatureNames of a forest, or f0, f1, ... if it has none.
+	Columns []string
+	// Output is the name of the output column of Forest, which is suffixed by _0, _1, ... for several outputs.
+	// it defaults to prediction.
+	Output string
+}
+
+// Forest returns a query which selects the columns of table and the predictions of f,
+// the same as f.Predict, and the margins of every group as margin_0, margin_1, ...
+// table is inserted as is, so it may be a qualified name or a parenthesized subquery.
+func Forest(f *forest.Forest, table string, config Config) (string, error) {
+	margins, err := ForestMargins(f, config)
+	if err != nil {
+		return "", err
+	}
+	q := config.Dialect.quote
+
+	var b strings.Builder
+	b.WriteString("SELECT\n\tsrc.*")
+	for g, m := range margins {
+		fmt.Fprintf(&b, ",\n\t%s AS %s", m, q(marginColumn(g)))
+	}
+	fmt.Fprintf(&b, "\nFROM %s AS src", table)
+
+	margin := make([]string, f.NumGroup)
+	for g := range margin {
+		margin[g] = "m." + q(marginColumn(g))
+	}
+	maxMargin := ""
+	if f.PostTransform == forest.Softmax {
+		// the largest margin keeps the exponentials in range
+		inner := b.String()
+		b.Reset()
+		fmt.Fprintf(&b, "SELECT\n\tm.*,\n\t%s AS %s\nFROM (\n%s\n) AS m", maxCase(margin, margin), q("max_margin"), indent(inner))
+		maxMargin = "m." + q("max_margin")
+	}
+	inner := b.String()
+
+	outputs := transform(f, margin, maxMargin)
+	name := config.Output
+	if name == "" {
+		name = "prediction"
+	}
+	b.Reset()
+	b.WriteString("SELECT\n\tm.*")
+	for i, o := range outputs {
+		column := name
+		if len(outputs) > 1 {
+			column += "_" + strconv.Itoa(i)
+		}
+		fmt.Fprintf(&b, ",\n\t%s AS %s", o, q(column))
+	}
+	fmt.Fprintf(&b, "\nFROM (\n%s\n) AS m", indent(inner))
+	return b.String(), nil
+}
+
+// ForestMargins returns an expression of the margin of every group of f, the same as f.PredictMargin.
+func ForestMargins(f *forest.Forest, config Config) ([]string, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	if !config.Dialect.valid() {
+		return nil, ErrInvalidDialect
+	}
+	columns, err := columnNames(config, f.FeatureNames, f.NumFeature)
+	if err != nil {
+		return nil, err
+	}
+	g := generator{dialect: config.Dialect, columns: columns}
+
+	trees := make([][]string, f.NumGroup)
+	for i := range f.Trees {
+		t := &f.Trees[i]
+		trees[t.Group] = append(trees[t.Group], g.node(t, 0))
+	}
+	margins := make([]string, f.NumGroup)
+	for group, t := range trees {
+		base := literal(f.BaseScore[group])
+		switch {
+		case len(t) == 0:
+			margins[group] = base
+		case f.AverageTreeOutput:
+			margins[group] = fmt.Sprintf("(%s) / %d.0 + %s", strings.Join(t, "\n\t+ "), len(t), base)
+		default:
+			margins[group] = fmt.Sprintf("%s\n\t+ %s", strings.Join(t, "\n\t+ "), base)
+		}
+		margins[group] = fmt.Sprintf("CAST(%s AS %s)", margins[group], config.Dialect.doubleType())
+	}
+	return margins, nil
+}
+
+// Linear returns the expression intercept + coef[0] * x0 + coef[1] * x1 + ...
+// of a linear model, e.g. the parameters of cuml4go.LinearRegression.
+// Columns default to f0, f1, ...; a NULL feature makes the prediction NULL.
+func Linear(coef []float32, intercept float32, config Config) (string, error) {
+	if !config.Dialect.valid() {
+		return "", ErrInvalidDialect
+	}
+	columns, err := columnNames(config, nil, len(coef))
+	if err != nil {
+		return "", err
+	}
+	terms := []string{literal(float64(intercept))}
+	for i, c := range coef {
+		terms = append(terms, fmt.Sprintf("%s * %s", literal(float64(c)), config.Dialect.quote(columns[i])))
+	}
+	return strings.Join(terms, " + "), nil
+}
+
+// columnNames returns the columns of numFeature features.
+func columnNames(config Config, featureNames []string, numFeature int) ([]string, error) {
+	columns := config.Columns
+	if columns == nil {
+		columns = featureNames
+	}
+	if columns == nil {
+		columns = make([]string, numFeature)
+		for i := range columns {
+			columns[i] = "f" + strconv.Itoa(i)
+		}
+	}
+	if len(columns) != numFeature {
+		return nil, ErrInvalidColumns
+	}
+	return columns, nil
+}
+
+func marginColumn(group int) string {
+	return "margin_" + strconv.Itoa(group)
+}
+
+type generator struct {
+	dialect Dialect
+	columns []string
+}
+
+// node returns the CASE expression of the value of the leaf a row reaches from node i.
+func (g *generator) node(t *forest.Tree, i int32) string {
+	n := &t.Nodes[i]
+	if n.IsLeaf() {
+		return literal(n.Value)
+	}
+	return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", g.condition(n), g.node(t, n.Left), g.node(t, n.Right))
+}
+
+// condition returns the condition of the left child of n, with the semantics of the missing values of forest.Node.
+// a comparison with NULL is unknown, which CASE treats as false.
+func (g *generator) condition(n *forest.Node) string {
+	x := g.dialect.quote(g.columns[n.Feature])
+	var test string
+	if n.Categorical {
+		categories := make([]string, len(n.Categories))
+		for i, c := range n.Categories {
+			categories[i] = strconv.FormatUint(uint64(c), 10)
+		}
+		// the integer part of a negative value is never a category
+		test = fmt.Sprintf("FLOOR(%s) IN (%s)", x, strings.Join(categories, ", "))
+		if len(categories) == 0 {
+			test = "1 = 0"
+		}
+	} else {
+		op := "<"
+		if n.Comparison == forest.LessEqual {
+			op = "<="
+		}
+		test = fmt.Sprintf("%s %s %s", x, op, literal(n.Threshold))
+	}
+
+	switch n.Missing {
+	case forest.MissingNone:
+		// NULL is compared as zero
+		if n.ZeroGoesLeft() {
+			return fmt.Sprintf("(%s IS NULL OR %s)", x, test)
+		}
+		return test
+	case forest.MissingZero:
+		if n.DefaultLeft {
+			return fmt.Sprintf("(%s IS NULL OR ABS(%s) <= %g OR %s)", x, x, forest.ZeroThreshold, test)
+		}
+		return fmt.Sprintf("ABS(%s) > %g AND %s", x, forest.ZeroThreshold, test)
+	default:
+		if n.DefaultLeft {
+			return fmt.Sprintf("(%s IS NULL OR %s)", x, test)
+		}
+		return test
+	}
+}
+
+// transform returns the expressions of the outputs of the margins of a row.
+// maxMargin is the largest margin, which only Softmax uses.
+func transform(f *forest.Forest, margin []string, maxMargin string) []string {
+	outputs := make([]string, len(margin))
+	switch f.PostTransform {
+	case forest.Sigmoid:
+		alpha := literal(f.SigmoidAlpha)
+		for g, m := range margin {
+			// the exponential of a non-positive value never overflows
+			z := fmt.Sprintf("(%s * %s)", alpha, m)
+			outputs[g] = fmt.Sprintf("CASE WHEN %s >= 0 THEN 1.0 / (1.0 + EXP(-%s)) ELSE EXP(%s) / (1.0 + EXP(%s)) END", z, z, z, z)
+		}
+	case forest.Exponential:
+		for g, m := range margin {
+			outputs[g] = fmt.Sprintf("EXP(%s)", m)
+		}
+	case forest.Softmax:
+		exps := make([]string, len(margin))
+		for g, m := range margin {
+			exps[g] = fmt.Sprintf("EXP(%s - %s)", m, maxMargin)
+		}
+		sum := strings.Join(exps, " + ")
+		for g := range margin {
+			outputs[g] = fmt.Sprintf("%s / (%s)", exps[g], sum)
+		}
+	case forest.MaxIndex:
+		indices := make([]string, len(margin))
+		for g := range indices {
+			indices[g] = strconv.Itoa(g)
+		}
+		return []string{maxCase(margin, indices)}
+	case forest.Hinge:
+		for g, m := range margin {
+			outputs[g] = fmt.Sprintf("CASE WHEN %s > 0 THEN 1.0 ELSE 0.0 END", m)
+		}
+	default:
+		copy(outputs, margin)
+	}
+	return outputs
+}
+
+// maxCase returns the CASE expression of the result of the first largest margin.
+func maxCase(margin []string, results []string) string {
+	if len(margin) == 1 {
+		return results[0]
+	}
+	var b strings.Builder
+	b.WriteString("CASE")
+	for g := 0; g < len(margin)-1; g++ {
+		// the later margins are enough, since an earlier one is smaller than some margin
+		conditions := make([]string, 0, len(margin)-g-1)
+		for h := g + 1; h < len(margin); h++ {
+			conditions = append(conditions, fmt.Sprintf("%s >= %s", margin[g], margin[h]))
+		}
+		fmt.Fprintf(&b, " WHEN %s THEN %s", strings.Join(conditions, " AND "), results[g])
+	}
+	fmt.Fprintf(&b, " ELSE %s END", results[len(results)-1])
+	return b.String()
+}
+
+// literal returns v as an approximate numeric literal which evaluates to v exactly.
+// an infinity, which SQL has no literal of, is the largest finite value.
+func literal(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		v = math.MaxFloat64
+	case math.IsInf(v, -1):
+		v = -math.MaxFloat64
+	case v == 0:
+		return "0.0"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// indent indents every line of s by a tab.
+func indent(s string) string {
+	return "\t" + strings.ReplaceAll(s, "\n", "\n\t")
+}
+
+func (d Dialect) valid() bool {
+	return d == ANSI || d == BigQuery || d == PostgreSQL
+}
+
+// quote returns name as a quoted identifier.
+func (d Dialect) quote(name string) string {
+	if d == BigQuery {
+		return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// doubleType returns the name of the double precision type.
+func (d Dialect) doubleType() string {
+	if d == BigQuery {
+		return "FLOAT64"
+	}
+	return "DOUBLE PRECISION"
+}
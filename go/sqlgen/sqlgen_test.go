@@ -0,0 +1,219 @@
+package sqlgen_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/getumen/cuml-bindings/go/forest"
+	"github.com/getumen/cuml-bindings/go/forest/foresttest"
+	"github.com/getumen/cuml-bindings/go/sqlgen"
+)
+
+// openTable returns an in-memory database whose table features has the rows of x, with NaN as NULL,
+// and the column row_id of their index.
+func openTable(t *testing.T, columns []string, x []float32) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	// every connection has its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	quoted := []string{`"row_id"`}
+	for _, c := range columns {
+		quoted = append(quoted, `"`+strings.ReplaceAll(c, `"`, `""`)+`"`)
+	}
+	_, err = db.Exec(fmt.Sprintf("CREATE TABLE features (%s)", strings.Join(quoted, ", ")))
+	require.NoError(t, err)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(quoted)), ", ")
+	insert, err := db.Prepare(fmt.Sprintf("INSERT INTO features VALUES (%s)", placeholders))
+	require.NoError(t, err)
+	defer insert.Close()
+	for r := 0; r < len(x)/len(columns); r++ {
+		args := []any{r}
+		for _, v := range x[r*len(columns) : (r+1)*len(columns)] {
+			if math.IsNaN(float64(v)) {
+				args = append(args, nil)
+			} else {
+				args = append(args, float64(v))
+			}
+		}
+		_, err := insert.Exec(args...)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+// queryColumns returns the values of columns of every row of query in the order of row_id.
+func queryColumns(t *testing.T, db *sql.DB, query string, columns []string) []float32 {
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM (\n%s\n) AS q ORDER BY q.\"row_id\"", strings.Join(columns, ", "), query))
+	require.NoError(t, err, query)
+	defer rows.Close()
+
+	var result []float32
+	values := make([]float64, len(columns))
+	dest := make([]any, len(columns))
+	for i := range dest {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		require.NoError(t, rows.Scan(dest...))
+		for _, v := range values {
+			result = append(result, float32(v))
+		}
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func outputColumns(name string, n int) []string {
+	if n == 1 {
+		return []string{`"` + name + `"`}
+	}
+	columns := make([]string, n)
+	for i := range columns {
+		columns[i] = fmt.Sprintf(`"%s_%d"`, name, i)
+	}
+	return columns
+}
+
+func TestForestXGBoost(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/xgboost.json")
+	require.NoError(t, err)
+	f, err := forest.Parse(forest.XGBoostJSON, data)
+	require.NoError(t, err)
+	x := foresttest.ReadCSV(t, "../../testdata/feature.csv")
+	numRow := len(x) / f.NumFeature
+
+	// the feature names have spaces, which the quoted identifiers keep
+	db := openTable(t, f.FeatureNames, x)
+	query, err := sqlgen.Forest(f, "features", sqlgen.Config{})
+	require.NoError(t, err)
+
+	expected := make([]float32, numRow)
+	require.NoError(t, f.Predict(expected, x, numRow))
+	require.InDeltaSlice(t, expected, queryColumns(t, db, query, []string{`"prediction"`}), 1e-6)
+
+	margin := make([]float32, numRow)
+	require.NoError(t, f.PredictMargin(margin, x, numRow))
+	require.InDeltaSlice(t, margin, queryColumns(t, db, query, []string{`"margin_0"`}), 1e-5)
+
+	expectedScore := foresttest.ReadCSV(t, "../../testdata/score-xgboost.csv")
+	require.InDeltaSlice(t, expectedScore, queryColumns(t, db, query, []string{`"prediction"`}), 1e-5)
+}
+
+func TestForestPostTransform(t *testing.T) {
+	x := foresttest.SyntheticRows()
+	columns := []string{"a", "b", "c"}
+	db := openTable(t, columns, x)
+
+	testCases := []struct {
+		name   string
+		modify func(f *forest.Forest)
+	}{
+		{name: "softmax", modify: func(f *forest.Forest) {}},
+		{name: "identity", modify: func(f *forest.Forest) { f.PostTransform = forest.Identity }},
+		{name: "sigmoid", modify: func(f *forest.Forest) { f.PostTransform = forest.Sigmoid; f.SigmoidAlpha = -2 }},
+		{name: "exponential", modify: func(f *forest.Forest) { f.PostTransform = forest.Exponential }},
+		{name: "max index", modify: func(f *forest.Forest) { f.PostTransform = forest.MaxIndex }},
+		{name: "hinge", modify: func(f *forest.Forest) { f.PostTransform = forest.Hinge }},
+		{name: "average", modify: func(f *forest.Forest) { f.AverageTreeOutput = true }},
+		{name: "single group", modify: func(f *forest.Forest) {
+			f.NumGroup = 1
+			f.BaseScore = f.BaseScore[:1]
+			for i := range f.Trees {
+				f.Trees[i].Group = 0
+			}
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := foresttest.Synthetic()
+			tc.modify(f)
+			numRow := len(x) / f.NumFeature
+			expected := make([]float32, numRow*f.OutputWidth())
+			require.NoError(t, f.Predict(expected, x, numRow))
+
+			query, err := sqlgen.Forest(f, "features", sqlgen.Config{Columns: columns, Output: "score"})
+			require.NoError(t, err)
+			require.InDeltaSlice(t, expected, queryColumns(t, db, query, outputColumns("score", f.OutputWidth())), 1e-6)
+		})
+	}
+}
+
+func TestForestMargins(t *testing.T) {
+	x := foresttest.SyntheticRows()
+	db := openTable(t, []string{"f0", "f1", "f2"}, x)
+	f := foresttest.Synthetic()
+	numRow := len(x) / f.NumFeature
+
+	margins, err := sqlgen.ForestMargins(f, sqlgen.Config{})
+	require.NoError(t, err)
+	require.Len(t, margins, f.NumGroup)
+	query := fmt.Sprintf("SELECT \"row_id\", %s AS m0, %s AS m1 FROM features", margins[0], margins[1])
+
+	expected := make([]float32, numRow*f.NumGroup)
+	require.NoError(t, f.PredictMargin(expected, x, numRow))
+	require.InDeltaSlice(t, expected, queryColumns(t, db, query, []string{"m0", "m1"}), 1e-6)
+}
+
+func TestLinear(t *testing.T) {
+	coef := []float32{0.5, -1.25, 3}
+	var intercept float32 = 0.1
+	x := []float32{1, 2, 3, -0.5, 0, 4.5, 0, 0, 0}
+	db := openTable(t, []string{"x", "y", "z"}, x)
+
+	expr, err := sqlgen.Linear(coef, intercept, sqlgen.Config{Columns: []string{"x", "y", "z"}})
+	require.NoError(t, err)
+	actual := queryColumns(t, db, fmt.Sprintf("SELECT \"row_id\", %s AS p FROM features", expr), []string{"p"})
+
+	for r := 0; r < 3; r++ {
+		expected := intercept
+		for c, w := range coef {
+			expected += w * x[r*3+c]
+		}
+		require.InDelta(t, expected, actual[r], 1e-6)
+	}
+}
+
+func TestDialect(t *testing.T) {
+	f := foresttest.Synthetic()
+	columns := []string{"a`b", `c"d`, "e"}
+
+	query, err := sqlgen.Forest(f, "dataset.features", sqlgen.Config{Dialect: sqlgen.BigQuery, Columns: columns})
+	require.NoError(t, err)
+	require.Contains(t, query, "`a\\`b` < 0.5")
+	require.Contains(t, query, "AS FLOAT64)")
+	require.Contains(t, query, "FROM dataset.features AS src")
+	require.NotContains(t, query, `"margin_0"`)
+
+	query, err = sqlgen.Forest(f, "features", sqlgen.Config{Dialect: sqlgen.PostgreSQL, Columns: columns})
+	require.NoError(t, err)
+	require.Contains(t, query, `"c""d" <= 1.5`)
+	require.Contains(t, query, "AS DOUBLE PRECISION)")
+
+	expr, err := sqlgen.Linear([]float32{2, -1}, 0, sqlgen.Config{Dialect: sqlgen.BigQuery})
+	require.NoError(t, err)
+	require.Equal(t, "0.0 + 2.0 * `f0` + -1.0 * `f1`", expr)
+}
+
+func TestInvalid(t *testing.T) {
+	f := foresttest.Synthetic()
+	_, err := sqlgen.Forest(f, "features", sqlgen.Config{Dialect: sqlgen.Dialect(-1)})
+	require.ErrorIs(t, err, sqlgen.ErrInvalidDialect)
+	_, err = sqlgen.Forest(f, "features", sqlgen.Config{Columns: []string{"a"}})
+	require.ErrorIs(t, err, sqlgen.ErrInvalidColumns)
+	_, err = sqlgen.Linear([]float32{1, 2}, 0, sqlgen.Config{Columns: []string{"a"}})
+	require.ErrorIs(t, err, sqlgen.ErrInvalidColumns)
+
+	f.Trees[0].Nodes[0].Left = 0
+	_, err = sqlgen.ForestMargins(f, sqlgen.Config{})
+	require.ErrorIs(t, err, forest.ErrInvalidModel)
+}
@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/getumen/cuml-bindings/go/rawcuml4go"
 )
 
 func csvToFloat32Array(t *testing.T, csvPath string) []float32 {
@@ -29,3 +31,35 @@ func csvToFloat32Array(t *testing.T, csvPath string) []float32 {
 
 	return data
 }
+
+func csvToFloat64Array(t *testing.T, csvPath string) []float64 {
+	data := make([]float64, 0)
+
+	f, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		values := strings.Split(scanner.Text(), ",")
+		for _, valueString := range values {
+			value, err := strconv.ParseFloat(valueString, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, value)
+		}
+	}
+
+	return data
+}
+
+// skipWithoutNativeLibrary skips a test which needs the native libraries
+// when the package is built with the nocuml build tag.
+func skipWithoutNativeLibrary(t *testing.T) {
+	t.Helper()
+	if !rawcuml4go.HasNativeLibrary {
+		t.Skip("built without the native libraries")
+	}
+}
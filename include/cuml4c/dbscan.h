@@ -20,3 +20,15 @@ EXTERN_C int DbscanFit(
     size_t max_bytes_per_batch,
     int verbosity,
     int *labels);
+
+EXTERN_C int DbscanFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    int min_pts,
+    double eps,
+    int metric,
+    size_t max_bytes_per_batch,
+    int verbosity,
+    int *labels);
@@ -12,3 +12,5 @@ typedef void *DeviceResourceHandle;
 EXTERN_C int CreateDeviceResourceHandle(DeviceResourceHandle *handle);
 
 EXTERN_C int FreeDeviceResourceHandle(DeviceResourceHandle handle);
+
+EXTERN_C int GetDeviceCount(int *count);
@@ -1,9 +1,11 @@
 #ifdef __cplusplus
 #define EXTERN_C extern "C"
 #include <cstddef>
+#include <cstdint>
 #else
 #define EXTERN_C
 #include <stdbool.h>
+#include <stdint.h>
 #include <stdio.h>
 #endif
 
@@ -19,6 +21,17 @@ enum FILStatus
     FIL_FAIL_TO_GET_NUM_FEATURE = 3,
     FIL_INVALID_ARGUMENT = 4,
     FIL_FAIL_TO_FREE_MODEL = 5,
+    FIL_FAIL_TO_PREDICT = 6,
+    FIL_OUT_OF_MEMORY = 7,
+};
+
+// FILNodeKind is the kind of a node of FILLoadModelFromTrees.
+enum FILNodeKind
+{
+    FIL_NODE_LEAF = 0,
+    FIL_NODE_LESS_THAN = 1,
+    FIL_NODE_LESS_EQUAL = 2,
+    FIL_NODE_CATEGORICAL = 3,
 };
 
 EXTERN_C int FILLoadModel(
@@ -34,6 +47,48 @@ EXTERN_C int FILLoadModel(
     int n_items,
     FILModelHandle *out);
 
+EXTERN_C int FILLoadModelFromBuffer(
+    const DeviceResourceHandle handle,
+    int model_type,
+    const char *buffer,
+    size_t length,
+    int algo,
+    bool classification,
+    float threshold,
+    int storage_type,
+    int blocks_per_sm,
+    int threads_per_tree,
+    int n_items,
+    FILModelHandle *out);
+
+// FILLoadModelFromTrees builds a forest with the model builder of treelite.
+// metadata is the model builder metadata as JSON.
+// the nodes of tree t are [tree_offsets[t], tree_offsets[t + 1]), whose first node is the root,
+// and the children are the indices within the tree.
+// values are the thresholds of the splits and the outputs of the leaves.
+// the categories of node i, which go to the left child, are [category_offsets[i], category_offsets[i + 1]).
+EXTERN_C int FILLoadModelFromTrees(
+    const DeviceResourceHandle handle,
+    const char *metadata,
+    int num_tree,
+    const int *tree_offsets,
+    const int *kinds,
+    const int *left_children,
+    const int *right_children,
+    const int *features,
+    const double *values,
+    const bool *default_left,
+    const int *category_offsets,
+    const uint32_t *categories,
+    int algo,
+    bool classification,
+    float threshold,
+    int storage_type,
+    int blocks_per_sm,
+    int threads_per_tree,
+    int n_items,
+    FILModelHandle *out);
+
 EXTERN_C int FILFreeModel(
     const DeviceResourceHandle handle,
     FILModelHandle model);
@@ -45,3 +100,13 @@ EXTERN_C int FILPredict(
     size_t num_row,
     bool output_class_probabilities,
     float *preds);
+
+EXTERN_C int FILGetNumFeatures(
+    FILModelHandle model,
+    size_t *out);
+
+// FILGetNumClasses writes the number of classes of the model, which is 1 for a regressor
+// or a binary classifier. FILPredict writes max(2, num_classes) probabilities per row.
+EXTERN_C int FILGetNumClasses(
+    FILModelHandle model,
+    size_t *out);
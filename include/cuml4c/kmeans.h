@@ -25,3 +25,20 @@ EXTERN_C int KmeansFit(
     float *centroids,
     float *inertia,
     int *n_iter);
+
+EXTERN_C int KmeansFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    int num_row,
+    int num_col,
+    int k,
+    int max_iters,
+    double tol,
+    int init_method,
+    int metric,
+    int seed,
+    int verbosity,
+    int *labels,
+    double *centroids,
+    double *inertia,
+    int *n_iter);
@@ -9,6 +9,13 @@
 
 #include "cuml4c/device_resource_handle.h"
 
+enum LinearRegressionStatus
+{
+    LINEAR_REGRESSION_SUCCESS = 0,
+    LINEAR_REGRESSION_FAIL_TO_PREDICT = 1,
+    LINEAR_REGRESSION_OUT_OF_MEMORY = 2,
+};
+
 EXTERN_C int OlsFit(
     const DeviceResourceHandle handle,
     const float *x,
@@ -43,3 +50,38 @@ EXTERN_C int GemmPredict(
     const float *coef,
     float intercept,
     float *preds);
+
+EXTERN_C int OlsFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *labels,
+    bool fit_intercept,
+    bool normalize,
+    int algo,
+    double *coef,
+    double *intercept);
+
+EXTERN_C int RidgeFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *labels,
+    double *alpha,
+    size_t n_alpha,
+    bool fit_intercept,
+    bool normalize,
+    int algo,
+    double *coef,
+    double *intercept);
+
+EXTERN_C int GemmPredict64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *coef,
+    double intercept,
+    double *preds);
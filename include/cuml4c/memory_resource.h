@@ -27,3 +27,10 @@ EXTERN_C int UseArenaMemoryResource(
 EXTERN_C int ResetMemoryResource(
     DeviceMemoryResource resource,
     int resource_type);
+
+EXTERN_C int GetMemoryResourceStatistics(
+    DeviceMemoryResource resource,
+    size_t *allocated_bytes,
+    size_t *peak_bytes,
+    size_t *pool_size,
+    bool *has_pool_size);
@@ -18,6 +18,8 @@ pub enum ModelType {
     XGBoostJSON = 1,
     // LightGBM lighgbm model (binary model file)
     LightGBM = 2,
+    // XGBoostUBJSON xgboost model (ubjson model file), the default of xgboost 2
+    XGBoostUBJSON = 4,
 }
 
 pub enum Algo {
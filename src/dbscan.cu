@@ -8,6 +8,64 @@
 
 #include <memory>
 
+namespace
+{
+
+    template <typename T>
+    __host__ int dbscanFit(
+        const DeviceResourceHandle handle,
+        const T *x,
+        size_t num_row,
+        size_t num_col,
+        int min_pts,
+        double eps,
+        int metric,
+        size_t max_bytes_per_batch,
+        int verbosity,
+        int *labels)
+    {
+        auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+        auto d_x = rmm::device_uvector<T>(
+            num_col * num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_x.data(),
+                            x,
+                            num_col * num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_labels = rmm::device_uvector<int>(
+            num_row,
+            handle_p->handle->get_stream());
+
+        ML::Dbscan::fit(*handle_p->handle,
+                        /*input=*/d_x.begin(),
+                        /*n_rows=*/num_row,
+                        /*n_cols=*/num_col,
+                        /*eps=*/static_cast<T>(eps),
+                        min_pts,
+                        /*metric=*/static_cast<raft::distance::DistanceType>(metric),
+                        /*labels=*/d_labels.begin(),
+                        /*core_sample_indices=*/nullptr,
+                        /*sample_weight=*/nullptr,
+                        max_bytes_per_batch,
+                        /*ops_nn_method=*/ML::Dbscan::BRUTE_FORCE,
+                        /*verbosity=*/verbosity,
+                        /*opg=*/false);
+
+        raft::update_host(labels,
+                          d_labels.begin(),
+                          d_labels.size(),
+                          handle_p->handle->get_stream());
+
+        handle_p->handle->sync_stream();
+
+        return 0;
+    }
+
+} // namespace
+
 __host__ int DbscanFit(
     const DeviceResourceHandle handle,
     const float *x,
@@ -20,42 +78,22 @@ __host__ int DbscanFit(
     int verbosity,
     int *labels)
 {
-    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
-
-    auto d_x = rmm::device_uvector<float>(
-        num_col * num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_x.data(),
-                        x,
-                        num_col * num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_labels = rmm::device_uvector<int>(
-        num_row,
-        handle_p->handle->get_stream());
-
-    ML::Dbscan::fit(*handle_p->handle,
-                    /*input=*/d_x.begin(),
-                    /*n_rows=*/num_row,
-                    /*n_cols=*/num_col,
-                    eps,
-                    min_pts,
-                    /*metric=*/static_cast<raft::distance::DistanceType>(metric),
-                    /*labels=*/d_labels.begin(),
-                    /*core_sample_indices=*/nullptr,
-                    /*sample_weight=*/nullptr,
-                    max_bytes_per_batch,
-                    /*ops_nn_method=*/ML::Dbscan::BRUTE_FORCE,
-                    /*verbosity=*/verbosity,
-                    /*opg=*/false);
-
-    raft::update_host(labels,
-                      d_labels.begin(),
-                      d_labels.size(),
-                      handle_p->handle->get_stream());
-
-    handle_p->handle->sync_stream();
-
-    return 0;
+    return dbscanFit(handle, x, num_row, num_col, min_pts, eps, metric,
+                     max_bytes_per_batch, verbosity, labels);
+}
+
+__host__ int DbscanFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    int min_pts,
+    double eps,
+    int metric,
+    size_t max_bytes_per_batch,
+    int verbosity,
+    int *labels)
+{
+    return dbscanFit(handle, x, num_row, num_col, min_pts, eps, metric,
+                     max_bytes_per_batch, verbosity, labels);
 }
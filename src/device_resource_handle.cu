@@ -3,15 +3,25 @@
 
 #include <raft/core/handle.hpp>
 
+#include <cuda_runtime.h>
+
 #include <memory>
 
 __host__ int CreateDeviceResourceHandle(DeviceResourceHandle *handle)
 {
-    auto raft_handle = std::make_unique<raft::handle_t>();
+    try
+    {
+        auto raft_handle = std::make_unique<raft::handle_t>();
 
-    auto p = std::make_unique<cuml4c::DeviceResource>(std::move(raft_handle));
+        auto p = std::make_unique<cuml4c::DeviceResource>(std::move(raft_handle));
 
-    *handle = static_cast<DeviceResourceHandle>(p.release());
+        *handle = static_cast<DeviceResourceHandle>(p.release());
+    }
+    catch (const std::exception &)
+    {
+        // e.g. no device is available
+        return 1;
+    }
 
     return 0;
 }
@@ -21,3 +31,13 @@ __host__ int FreeDeviceResourceHandle(DeviceResourceHandle handle)
     delete static_cast<cuml4c::DeviceResource *>(handle);
     return 0;
 }
+
+__host__ int GetDeviceCount(int *count)
+{
+    if (cudaGetDeviceCount(count) != cudaSuccess)
+    {
+        *count = 0;
+        return 1;
+    }
+    return 0;
+}
@@ -8,6 +8,7 @@
 #include <cuml/fil/fil.h>
 
 #include <memory>
+#include <stdexcept>
 #include <string>
 #include <fstream>
 #include <iterator>
@@ -19,18 +20,31 @@ namespace
   {
     XGBoost,
     XGBoostJSON,
-    LightGBM
+    LightGBM,
+    // ONNX models are built with FILLoadModelFromTrees since treelite has no ONNX frontend
+    ONNX,
+    XGBoostUBJSON
   };
 
   struct FILModel
   {
     __host__ FILModel(std::unique_ptr<ML::fil::forest32_t> forest,
-                      int const num_features)
+                      int const num_features,
+                      size_t const num_classes)
         : forest_(std::move(forest)),
-          numFeatures_(num_features) {}
+          numFeatures_(num_features),
+          numClasses_(num_classes) {}
+
+    // numOutputs returns the number of class probabilities per row,
+    // which is 2 for a binary classifier whose model stores a single class.
+    __host__ size_t numOutputs() const
+    {
+      return numClasses_ > 2 ? numClasses_ : 2;
+    }
 
     std::unique_ptr<ML::fil::forest32_t> forest_;
     int const numFeatures_;
+    size_t const numClasses_;
   };
 
   __host__ int treeliteLoadModel(ModelType const model_type,
@@ -47,12 +61,179 @@ namespace
     }
     case ModelType::LightGBM:
       return TreeliteLoadLightGBMModel(filename, json_config.c_str(), model_handle);
+    case ModelType::ONNX:
+      return -1;
+    case ModelType::XGBoostUBJSON:
+      return TreeliteLoadXGBoostModelUBJSON(filename, json_config.c_str(), model_handle);
     }
 
     // unreachable
     return -1;
   }
 
+  __host__ int treeliteLoadModelFromBuffer(ModelType const model_type,
+                                           char const *buffer,
+                                           size_t length,
+                                           TreeliteModelHandle *model_handle)
+  {
+    std::string json_config = "{}";
+    switch (model_type)
+    {
+    case ModelType::XGBoost:
+      return TreeliteLoadXGBoostModelLegacyBinaryFromMemoryBuffer(buffer, length, model_handle);
+    case ModelType::XGBoostJSON:
+      return TreeliteLoadXGBoostModelFromString(buffer, length, json_config.c_str(), model_handle);
+    case ModelType::LightGBM:
+    {
+      // the LightGBM loader expects a null-terminated string
+      std::string model_str(buffer, length);
+      return TreeliteLoadLightGBMModelFromString(model_str.c_str(), json_config.c_str(), model_handle);
+    }
+    case ModelType::ONNX:
+      return -1;
+    case ModelType::XGBoostUBJSON:
+      return TreeliteLoadXGBoostModelFromUBJSONString(
+          reinterpret_cast<uint8_t const *>(buffer), length, json_config.c_str(), model_handle);
+    }
+
+    // unreachable
+    return -1;
+  }
+
+  __host__ int buildFILModel(
+      cuml4c::DeviceResource *handle_p,
+      TreeliteModelHandle model_handle,
+      int algo,
+      bool classification,
+      float threshold,
+      int storage_type,
+      int blocks_per_sm,
+      int threads_per_tree,
+      int n_items,
+      FILModelHandle *out)
+  {
+    int num_features = 0;
+    {
+      auto res = TreeliteQueryNumFeature(model_handle, &num_features);
+      if (res < 0)
+      {
+        TreeliteFreeModel(model_handle);
+        return FIL_FAIL_TO_GET_NUM_FEATURE;
+      }
+    }
+
+    size_t num_classes = 0;
+    {
+      auto res = TreeliteQueryNumClass(model_handle, &num_classes);
+      if (res < 0)
+      {
+        TreeliteFreeModel(model_handle);
+        return FIL_FAIL_TO_GET_NUM_CLASS;
+      }
+    }
+
+    ML::fil::treelite_params_t params;
+    params.algo = static_cast<ML::fil::algo_t>(algo);
+    params.output_class = classification;
+    params.threshold = threshold;
+    params.storage_type = static_cast<ML::fil::storage_type_t>(storage_type);
+    params.blocks_per_sm = blocks_per_sm;
+    params.output_class = classification;
+    params.threads_per_tree = threads_per_tree;
+    params.n_items = n_items;
+    params.pforest_shape_str = nullptr;
+    params.precision = ML::fil::precision_t::PRECISION_FLOAT32;
+
+    ML::fil::forest_variant f;
+
+    ML::fil::from_treelite(
+        /*handle=*/*handle_p->handle,
+        /*pforest=*/&f,
+        /*model=*/model_handle,
+        /*tl_params=*/&params);
+
+    auto forest = std::make_unique<ML::fil::forest32_t>(std::move(std::get<ML::fil::forest32_t>(f)));
+
+    auto model = std::make_unique<FILModel>(
+        std::move(forest),
+        num_features,
+        num_classes);
+
+    // the forest may be used on the streams of other handles
+    handle_p->handle->sync_stream();
+
+    *out = static_cast<FILModelHandle>(model.release());
+
+    {
+      auto res = TreeliteFreeModel(model_handle);
+      if (res < 0)
+      {
+        return FIL_FAIL_TO_FREE_MODEL;
+      }
+    }
+
+    return FIL_SUCCESS;
+  }
+
+  __host__ int buildTree(
+      TreeliteModelBuilderHandle builder,
+      int begin,
+      int end,
+      const int *kinds,
+      const int *left_children,
+      const int *right_children,
+      const int *features,
+      const double *values,
+      const bool *default_left,
+      const int *category_offsets,
+      const uint32_t *categories)
+  {
+    if (TreeliteModelBuilderStartTree(builder) < 0)
+    {
+      return -1;
+    }
+    for (int i = begin; i < end; ++i)
+    {
+      if (TreeliteModelBuilderStartNode(builder, i - begin) < 0)
+      {
+        return -1;
+      }
+      int res = 0;
+      switch (kinds[i])
+      {
+      case FIL_NODE_LEAF:
+        res = TreeliteModelBuilderLeafScalar(builder, values[i]);
+        break;
+      case FIL_NODE_LESS_THAN:
+        res = TreeliteModelBuilderNumericalTest(
+            builder, features[i], values[i], default_left[i], "<", left_children[i], right_children[i]);
+        break;
+      case FIL_NODE_LESS_EQUAL:
+        res = TreeliteModelBuilderNumericalTest(
+            builder, features[i], values[i], default_left[i], "<=", left_children[i], right_children[i]);
+        break;
+      case FIL_NODE_CATEGORICAL:
+        res = TreeliteModelBuilderCategoricalTest(
+            builder,
+            features[i],
+            default_left[i],
+            categories + category_offsets[i],
+            category_offsets[i + 1] - category_offsets[i],
+            /*category_list_right_child=*/0,
+            left_children[i],
+            right_children[i]);
+        break;
+      default:
+        return -1;
+      }
+      if (res < 0 || TreeliteModelBuilderEndNode(builder) < 0)
+      {
+        return -1;
+      }
+    }
+    return TreeliteModelBuilderEndTree(builder);
+  }
+
 } // namespace
 
 __host__ int FILLoadModel(
@@ -82,52 +263,132 @@ __host__ int FILLoadModel(
     }
   }
 
-  int num_features = 0;
+  return buildFILModel(
+      handle_p,
+      model_handle,
+      algo,
+      classification,
+      threshold,
+      storage_type,
+      blocks_per_sm,
+      threads_per_tree,
+      n_items,
+      out);
+}
+
+__host__ int FILLoadModelFromBuffer(
+    const DeviceResourceHandle handle,
+    int model_type,
+    const char *buffer,
+    size_t length,
+    int algo,
+    bool classification,
+    float threshold,
+    int storage_type,
+    int blocks_per_sm,
+    int threads_per_tree,
+    int n_items,
+    FILModelHandle *out)
+{
+  auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+  TreeliteModelHandle model_handle;
   {
-    auto res = TreeliteQueryNumFeature(model_handle, &num_features);
+    auto const res = treeliteLoadModelFromBuffer(
+        /*model_type=*/static_cast<ModelType>(model_type),
+        /*buffer=*/buffer,
+        /*length=*/length,
+        &model_handle);
     if (res < 0)
     {
-      return FIL_FAIL_TO_GET_NUM_FEATURE;
+      return FIL_FAIL_TO_LOAD_MODEL;
     }
   }
 
-  ML::fil::treelite_params_t params;
-  params.algo = static_cast<ML::fil::algo_t>(algo);
-  params.output_class = classification;
-  params.threshold = threshold;
-  params.storage_type = static_cast<ML::fil::storage_type_t>(storage_type);
-  params.blocks_per_sm = blocks_per_sm;
-  params.output_class = classification;
-  params.threads_per_tree = threads_per_tree;
-  params.n_items = n_items;
-  params.pforest_shape_str = nullptr;
-  params.precision = ML::fil::precision_t::PRECISION_FLOAT32;
-
-  ML::fil::forest_variant f;
-
-  ML::fil::from_treelite(
-      /*handle=*/*handle_p->handle,
-      /*pforest=*/&f,
-      /*model=*/model_handle,
-      /*tl_params=*/&params);
-
-  auto forest = std::make_unique<ML::fil::forest32_t>(std::move(std::get<ML::fil::forest32_t>(f)));
+  return buildFILModel(
+      handle_p,
+      model_handle,
+      algo,
+      classification,
+      threshold,
+      storage_type,
+      blocks_per_sm,
+      threads_per_tree,
+      n_items,
+      out);
+}
 
-  auto model = std::make_unique<FILModel>(
-      std::move(forest),
-      num_features);
+__host__ int FILLoadModelFromTrees(
+    const DeviceResourceHandle handle,
+    const char *metadata,
+    int num_tree,
+    const int *tree_offsets,
+    const int *kinds,
+    const int *left_children,
+    const int *right_children,
+    const int *features,
+    const double *values,
+    const bool *default_left,
+    const int *category_offsets,
+    const uint32_t *categories,
+    int algo,
+    bool classification,
+    float threshold,
+    int storage_type,
+    int blocks_per_sm,
+    int threads_per_tree,
+    int n_items,
+    FILModelHandle *out)
+{
+  auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
 
-  *out = static_cast<FILModelHandle>(model.release());
+  TreeliteModelBuilderHandle builder;
+  if (TreeliteGetModelBuilder(metadata, &builder) < 0)
+  {
+    return FIL_FAIL_TO_LOAD_MODEL;
+  }
+  for (int t = 0; t < num_tree; ++t)
+  {
+    auto const res = buildTree(
+        builder,
+        tree_offsets[t],
+        tree_offsets[t + 1],
+        kinds,
+        left_children,
+        right_children,
+        features,
+        values,
+        default_left,
+        category_offsets,
+        categories);
+    if (res < 0)
+    {
+      TreeliteDeleteModelBuilder(builder);
+      return FIL_FAIL_TO_LOAD_MODEL;
+    }
+  }
 
+  TreeliteModelHandle model_handle;
   {
-    auto res = TreeliteFreeModel(model_handle);
+    auto const res = TreeliteModelBuilderCommitModel(builder, &model_handle);
+    TreeliteDeleteModelBuilder(builder);
     if (res < 0)
     {
-      return FIL_FAIL_TO_FREE_MODEL;
+      return FIL_FAIL_TO_LOAD_MODEL;
     }
   }
 
-  return FIL_SUCCESS;
+  return buildFILModel(
+      handle_p,
+      model_handle,
+      algo,
+      classification,
+      threshold,
+      storage_type,
+      blocks_per_sm,
+      threads_per_tree,
+      n_items,
+      out);
 }
 
 __host__ int FILFreeModel(
@@ -149,40 +410,69 @@ __host__ int FILPredict(
     bool output_class_probabilities,
     float *preds)
 {
-  auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+  try
+  {
+    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
 
-  auto fil_model = static_cast<FILModel *>(model);
+    auto fil_model = static_cast<FILModel *>(model);
 
-  auto d_x = rmm::device_uvector<float>(
-      fil_model->numFeatures_ * num_row,
-      handle_p->handle->get_stream());
+    auto d_x = rmm::device_uvector<float>(
+        fil_model->numFeatures_ * num_row,
+        handle_p->handle->get_stream());
 
-  raft::update_device(d_x.data(),
-                      x,
-                      fil_model->numFeatures_ * num_row,
-                      handle_p->handle->get_stream());
+    raft::update_device(d_x.data(),
+                        x,
+                        fil_model->numFeatures_ * num_row,
+                        handle_p->handle->get_stream());
 
-  auto pred_size = output_class_probabilities
-                       ? 2 * num_row
-                       : num_row;
+    auto pred_size = output_class_probabilities
+                         ? fil_model->numOutputs() * num_row
+                         : num_row;
 
-  auto d_preds = rmm::device_uvector<float>(
-      pred_size,
-      handle_p->handle->get_stream());
+    auto d_preds = rmm::device_uvector<float>(
+        pred_size,
+        handle_p->handle->get_stream());
+
+    ML::fil::predict(/*h=*/*handle_p->handle,
+                     /*f=*/*fil_model->forest_,
+                     /*preds=*/d_preds.begin(),
+                     /*data=*/d_x.begin(),
+                     /*num_rows=*/num_row,
+                     /*predict_proba=*/output_class_probabilities);
+
+    raft::update_host(preds,
+                      d_preds.begin(),
+                      d_preds.size(),
+                      handle_p->handle->get_stream());
 
-  ML::fil::predict(/*h=*/*handle_p->handle,
-                   /*f=*/*fil_model->forest_,
-                   /*preds=*/d_preds.begin(),
-                   /*data=*/d_x.begin(),
-                   /*num_rows=*/num_row,
-                   /*predict_proba=*/output_class_probabilities);
+    handle_p->handle->sync_stream();
+  }
+  catch (const rmm::out_of_memory &)
+  {
+    return FIL_OUT_OF_MEMORY;
+  }
+  catch (const std::exception &)
+  {
+    return FIL_FAIL_TO_PREDICT;
+  }
 
-  raft::update_host(preds,
-                    d_preds.begin(),
-                    d_preds.size(),
-                    handle_p->handle->get_stream());
+  return FIL_SUCCESS;
+}
 
-  handle_p->handle->sync_stream();
+__host__ int FILGetNumFeatures(
+    FILModelHandle model,
+    size_t *out)
+{
+  auto fil_model = static_cast<FILModel const *>(model);
+  *out = fil_model->numFeatures_;
+  return FIL_SUCCESS;
+}
 
+__host__ int FILGetNumClasses(
+    FILModelHandle model,
+    size_t *out)
+{
+  auto fil_model = static_cast<FILModel const *>(model);
+  *out = fil_model->numClasses_;
   return FIL_SUCCESS;
 }
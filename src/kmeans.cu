@@ -7,6 +7,97 @@
 
 #include <memory>
 
+namespace
+{
+
+    template <typename T>
+    __host__ int kmeansFit(
+        const DeviceResourceHandle handle,
+        const T *x,
+        int num_row,
+        int num_col,
+        int k,
+        int max_iters,
+        double tol,
+        int init_method,
+        int metric,
+        int seed,
+        int verbosity,
+        int *labels,
+        T *centroids,
+        T *inertia,
+        int *n_iter)
+    {
+        auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+        auto d_x = rmm::device_uvector<T>(
+            num_col * num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_x.data(),
+                            x,
+                            num_col * num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_labels = rmm::device_uvector<int>(
+            num_row,
+            handle_p->handle->get_stream());
+
+        auto d_centroids = rmm::device_uvector<T>(
+            k * num_col,
+            handle_p->handle->get_stream());
+
+        // centroids is also the input of the Array init method
+        if (init_method == static_cast<int>(ML::kmeans::KMeansParams::InitMethod::Array))
+        {
+            raft::update_device(d_centroids.data(),
+                                centroids,
+                                k * num_col,
+                                handle_p->handle->get_stream());
+        }
+
+        ML::kmeans::KMeansParams params;
+        params.n_clusters = k;
+        params.max_iter = max_iters;
+        if (tol > 0)
+        {
+            params.tol = tol;
+            params.inertia_check = true;
+        }
+
+        params.init = static_cast<ML::kmeans::KMeansParams::InitMethod>(init_method);
+        params.verbosity = verbosity;
+        params.metric = static_cast<raft::distance::DistanceType>(metric);
+
+        ML::kmeans::fit_predict(
+            *handle_p->handle,
+            params,
+            d_x.begin(),
+            num_row,
+            num_col,
+            nullptr,
+            d_centroids.begin(),
+            d_labels.begin(),
+            *inertia,
+            *n_iter);
+
+        raft::update_host(labels,
+                          d_labels.begin(),
+                          d_labels.size(),
+                          handle_p->handle->get_stream());
+
+        raft::update_host(centroids,
+                          d_centroids.begin(),
+                          d_centroids.size(),
+                          handle_p->handle->get_stream());
+
+        handle_p->handle->sync_stream();
+
+        return 0;
+    }
+
+} // namespace
+
 __host__ int
 KmeansFit(
     const DeviceResourceHandle handle,
@@ -25,61 +116,30 @@ KmeansFit(
     float *inertia,
     int *n_iter)
 {
-    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
-
-    auto d_x = rmm::device_uvector<float>(
-        num_col * num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_x.data(),
-                        x,
-                        num_col * num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_labels = rmm::device_uvector<int>(
-        num_row,
-        handle_p->handle->get_stream());
-
-    auto d_centroids = rmm::device_uvector<float>(
-        k * num_col,
-        handle_p->handle->get_stream());
-
-    ML::kmeans::KMeansParams params;
-    params.n_clusters = k;
-    params.max_iter = max_iters;
-    if (tol > 0)
-    {
-        params.tol = tol;
-        params.inertia_check = true;
-    }
+    return kmeansFit(handle, x, num_row, num_col, k, max_iters, tol,
+                     init_method, metric, seed, verbosity,
+                     labels, centroids, inertia, n_iter);
+}
 
-    params.init = static_cast<ML::kmeans::KMeansParams::InitMethod>(init_method);
-    params.verbosity = verbosity;
-    params.metric = static_cast<raft::distance::DistanceType>(metric);
-
-    ML::kmeans::fit_predict(
-        *handle_p->handle,
-        params,
-        d_x.begin(),
-        num_row,
-        num_col,
-        nullptr,
-        d_centroids.begin(),
-        d_labels.begin(),
-        *inertia,
-        *n_iter);
-
-    raft::update_host(labels,
-                      d_labels.begin(),
-                      d_labels.size(),
-                      handle_p->handle->get_stream());
-
-    raft::update_host(centroids,
-                      d_centroids.begin(),
-                      d_centroids.size(),
-                      handle_p->handle->get_stream());
-
-    handle_p->handle->sync_stream();
-
-    return 0;
+__host__ int
+KmeansFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    int num_row,
+    int num_col,
+    int k,
+    int max_iters,
+    double tol,
+    int init_method,
+    int metric,
+    int seed,
+    int verbosity,
+    int *labels,
+    double *centroids,
+    double *inertia,
+    int *n_iter)
+{
+    return kmeansFit(handle, x, num_row, num_col, k, max_iters, tol,
+                     init_method, metric, seed, verbosity,
+                     labels, centroids, inertia, n_iter);
 }
@@ -7,6 +7,200 @@
 #include <cuml/linear_model/qn.h>
 
 #include <memory>
+#include <stdexcept>
+
+namespace
+{
+
+    template <typename T>
+    __host__ int olsFit(
+        const DeviceResourceHandle handle,
+        const T *x,
+        size_t num_row,
+        size_t num_col,
+        const T *labels,
+        bool fit_intercept,
+        bool normalize,
+        int algo,
+        T *coef,
+        T *intercept)
+    {
+        auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+        auto d_x = rmm::device_uvector<T>(
+            num_col * num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_x.data(),
+                            x,
+                            num_col * num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_labels = rmm::device_uvector<T>(
+            num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_labels.data(),
+                            labels,
+                            num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_coef = rmm::device_uvector<T>(
+            num_col,
+            handle_p->handle->get_stream());
+
+        ML::GLM::olsFit(
+            *handle_p->handle,
+            d_x.begin(),
+            int(num_row),
+            int(num_col),
+            d_labels.begin(),
+            d_coef.begin(),
+            intercept,
+            fit_intercept,
+            normalize,
+            algo,
+            nullptr);
+
+        raft::update_host(coef,
+                          d_coef.begin(),
+                          d_coef.size(),
+                          handle_p->handle->get_stream());
+
+        handle_p->handle->sync_stream();
+
+        return 0;
+    }
+
+    template <typename T>
+    __host__ int ridgeFit(
+        const DeviceResourceHandle handle,
+        const T *x,
+        size_t num_row,
+        size_t num_col,
+        const T *labels,
+        T *alpha,
+        size_t n_alpha,
+        bool fit_intercept,
+        bool normalize,
+        int algo,
+        T *coef,
+        T *intercept)
+    {
+        auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+        auto d_x = rmm::device_uvector<T>(
+            num_col * num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_x.data(),
+                            x,
+                            num_col * num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_labels = rmm::device_uvector<T>(
+            num_row,
+            handle_p->handle->get_stream());
+
+        raft::update_device(d_labels.data(),
+                            labels,
+                            num_row,
+                            handle_p->handle->get_stream());
+
+        auto d_coef = rmm::device_uvector<T>(
+            num_col,
+            handle_p->handle->get_stream());
+
+        ML::GLM::ridgeFit(
+            *handle_p->handle,
+            d_x.begin(),
+            num_row,
+            num_col,
+            d_labels.begin(),
+            alpha,
+            int(n_alpha),
+            d_coef.begin(),
+            intercept,
+            fit_intercept,
+            normalize,
+            algo,
+            nullptr);
+
+        raft::update_host(coef,
+                          d_coef.begin(),
+                          d_coef.size(),
+                          handle_p->handle->get_stream());
+
+        handle_p->handle->sync_stream();
+
+        return 0;
+    }
+
+    template <typename T>
+    __host__ int gemmPredict(
+        const DeviceResourceHandle handle,
+        const T *x,
+        size_t num_row,
+        size_t num_col,
+        const T *coef,
+        T intercept,
+        T *preds)
+    {
+        try
+        {
+            auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
+
+            auto d_x = rmm::device_uvector<T>(
+                num_col * num_row,
+                handle_p->handle->get_stream());
+
+            raft::update_device(d_x.data(),
+                                x,
+                                num_col * num_row,
+                                handle_p->handle->get_stream());
+
+            auto d_coef = rmm::device_uvector<T>(
+                num_col,
+                handle_p->handle->get_stream());
+
+            raft::update_device(d_coef.data(),
+                                coef,
+                                num_col,
+                                handle_p->handle->get_stream());
+
+            auto d_preds = rmm::device_uvector<T>(
+                num_row,
+                handle_p->handle->get_stream());
+
+            ML::GLM::gemmPredict(
+                *handle_p->handle,
+                d_x.begin(),
+                num_row,
+                num_col,
+                d_coef.begin(),
+                intercept,
+                d_preds.begin());
+
+            raft::update_host(preds,
+                              d_preds.begin(),
+                              d_preds.size(),
+                              handle_p->handle->get_stream());
+
+            handle_p->handle->sync_stream();
+        }
+        catch (const rmm::out_of_memory &)
+        {
+            return LINEAR_REGRESSION_OUT_OF_MEMORY;
+        }
+        catch (const std::exception &)
+        {
+            return LINEAR_REGRESSION_FAIL_TO_PREDICT;
+        }
+
+        return LINEAR_REGRESSION_SUCCESS;
+    }
+
+} // namespace
 
 __host__ int OlsFit(
     const DeviceResourceHandle handle,
@@ -20,51 +214,24 @@ __host__ int OlsFit(
     float *coef,
     float *intercept)
 {
-    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
-
-    auto d_x = rmm::device_uvector<float>(
-        num_col * num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_x.data(),
-                        x,
-                        num_col * num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_labels = rmm::device_uvector<float>(
-        num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_labels.data(),
-                        labels,
-                        num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_coef = rmm::device_uvector<float>(
-        num_col,
-        handle_p->handle->get_stream());
-
-    ML::GLM::olsFit(
-        *handle_p->handle,
-        d_x.begin(),
-        int(num_row),
-        int(num_col),
-        d_labels.begin(),
-        d_coef.begin(),
-        intercept,
-        fit_intercept,
-        normalize,
-        algo,
-        nullptr);
-
-    raft::update_host(coef,
-                      d_coef.begin(),
-                      d_coef.size(),
-                      handle_p->handle->get_stream());
-
-    handle_p->handle->sync_stream();
-
-    return 0;
+    return olsFit(handle, x, num_row, num_col, labels,
+                  fit_intercept, normalize, algo, coef, intercept);
+}
+
+__host__ int OlsFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *labels,
+    bool fit_intercept,
+    bool normalize,
+    int algo,
+    double *coef,
+    double *intercept)
+{
+    return olsFit(handle, x, num_row, num_col, labels,
+                  fit_intercept, normalize, algo, coef, intercept);
 }
 
 __host__ int RidgeFit(
@@ -81,53 +248,26 @@ __host__ int RidgeFit(
     float *coef,
     float *intercept)
 {
-    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
-
-    auto d_x = rmm::device_uvector<float>(
-        num_col * num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_x.data(),
-                        x,
-                        num_col * num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_labels = rmm::device_uvector<float>(
-        num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_labels.data(),
-                        labels,
-                        num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_coef = rmm::device_uvector<float>(
-        num_col,
-        handle_p->handle->get_stream());
-
-    ML::GLM::ridgeFit(
-        *handle_p->handle,
-        d_x.begin(),
-        num_row,
-        num_col,
-        d_labels.begin(),
-        alpha,
-        int(n_alpha),
-        d_coef.begin(),
-        intercept,
-        fit_intercept,
-        normalize,
-        algo,
-        nullptr);
-
-    raft::update_host(coef,
-                      d_coef.begin(),
-                      d_coef.size(),
-                      handle_p->handle->get_stream());
-
-    handle_p->handle->sync_stream();
-
-    return 0;
+    return ridgeFit(handle, x, num_row, num_col, labels, alpha, n_alpha,
+                    fit_intercept, normalize, algo, coef, intercept);
+}
+
+__host__ int RidgeFit64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *labels,
+    double *alpha,
+    size_t n_alpha,
+    bool fit_intercept,
+    bool normalize,
+    int algo,
+    double *coef,
+    double *intercept)
+{
+    return ridgeFit(handle, x, num_row, num_col, labels, alpha, n_alpha,
+                    fit_intercept, normalize, algo, coef, intercept);
 }
 
 __host__ int GemmPredict(
@@ -139,45 +279,17 @@ __host__ int GemmPredict(
     float intercept,
     float *preds)
 {
-    auto handle_p = static_cast<cuml4c::DeviceResource *>(handle);
-
-    auto d_x = rmm::device_uvector<float>(
-        num_col * num_row,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_x.data(),
-                        x,
-                        num_col * num_row,
-                        handle_p->handle->get_stream());
-
-    auto d_coef = rmm::device_uvector<float>(
-        num_col,
-        handle_p->handle->get_stream());
-
-    raft::update_device(d_coef.data(),
-                        coef,
-                        num_col,
-                        handle_p->handle->get_stream());
-
-    auto d_preds = rmm::device_uvector<float>(
-        num_row,
-        handle_p->handle->get_stream());
-
-    ML::GLM::gemmPredict(
-        *handle_p->handle,
-        d_x.begin(),
-        num_row,
-        num_col,
-        d_coef.begin(),
-        intercept,
-        d_preds.begin());
-
-    raft::update_host(preds,
-                      d_preds.begin(),
-                      d_preds.size(),
-                      handle_p->handle->get_stream());
-
-    handle_p->handle->sync_stream();
-
-    return 0;
+    return gemmPredict(handle, x, num_row, num_col, coef, intercept, preds);
+}
+
+__host__ int GemmPredict64(
+    const DeviceResourceHandle handle,
+    const double *x,
+    size_t num_row,
+    size_t num_col,
+    const double *coef,
+    double intercept,
+    double *preds)
+{
+    return gemmPredict(handle, x, num_row, num_col, coef, intercept, preds);
 }
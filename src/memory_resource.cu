@@ -4,23 +4,58 @@
 #include <rmm/mr/device/pool_memory_resource.hpp>
 #include <rmm/mr/device/binning_memory_resource.hpp>
 #include <rmm/mr/device/arena_memory_resource.hpp>
+#include <rmm/mr/device/statistics_resource_adaptor.hpp>
 
 #include <memory>
 #include <optional>
 
+namespace
+{
+
+    using statistics_adaptor = rmm::mr::statistics_resource_adaptor<rmm::mr::device_memory_resource>;
+
+    // MemoryResource owns a memory resource installed as the current device resource.
+    // allocations are counted by a statistics adaptor on top of it,
+    // and the resource which was current before is restored on reset.
+    struct MemoryResource
+    {
+        __host__ MemoryResource(std::unique_ptr<rmm::mr::device_memory_resource> mr,
+                                rmm::mr::device_memory_resource *previous)
+            : mr(std::move(mr)),
+              statistics(std::make_unique<statistics_adaptor>(this->mr.get())),
+              previous(previous) {}
+
+        std::unique_ptr<rmm::mr::device_memory_resource> mr;
+        std::unique_ptr<statistics_adaptor> statistics;
+        rmm::mr::device_memory_resource *previous;
+    };
+
+    __host__ void use(std::unique_ptr<rmm::mr::device_memory_resource> mr,
+                      rmm::mr::device_memory_resource *previous,
+                      DeviceMemoryResource *resource)
+    {
+        auto p = std::make_unique<MemoryResource>(std::move(mr), previous);
+
+        rmm::mr::set_current_device_resource(p->statistics.get());
+
+        *resource = static_cast<DeviceMemoryResource>(p.release());
+    }
+
+} // namespace
+
 __host__ int UsePoolMemoryResource(
     size_t initial_pool_size,
     size_t maximum_pool_size,
     DeviceMemoryResource *resource)
 {
+    auto previous = rmm::mr::get_current_device_resource();
+
     auto mr = std::make_unique<rmm::mr::pool_memory_resource<rmm::mr::device_memory_resource>>(
-        rmm::mr::get_current_device_resource(),
+        previous,
         initial_pool_size,
         std::optional<size_t>(maximum_pool_size));
 
-    rmm::mr::set_current_device_resource(mr.get());
-
-    *resource = mr.release();
+    use(std::move(mr), previous, resource);
 
     return 0;
 }
@@ -30,14 +65,14 @@ __host__ int UseBinningMemoryResource(
     int8_t max_size_exponent,
     DeviceMemoryResource *resource)
 {
+    auto previous = rmm::mr::get_current_device_resource();
+
     auto mr = std::make_unique<rmm::mr::binning_memory_resource<rmm::mr::device_memory_resource>>(
-        rmm::mr::get_current_device_resource(),
+        previous,
         min_size_exponent,
         max_size_exponent);
 
-    rmm::mr::set_current_device_resource(mr.get());
-
-    *resource = mr.release();
+    use(std::move(mr), previous, resource);
 
     return 0;
 }
@@ -46,14 +81,15 @@ __host__ int UseArenaMemoryResource(
     DeviceMemoryResource *resource,
     size_t arena_size)
 {
+    auto previous = rmm::mr::get_current_device_resource();
+
     auto mr = std::make_unique<rmm::mr::arena_memory_resource<rmm::mr::device_memory_resource>>(
-        rmm::mr::get_current_device_resource(),
+        previous,
         std::optional<size_t>(arena_size),
         false);
 
-    rmm::mr::set_current_device_resource(mr.get());
+    use(std::move(mr), previous, resource);
 
-    *resource = mr.release();
     return 0;
 }
 
@@ -61,21 +97,36 @@ __host__ int ResetMemoryResource(
     DeviceMemoryResource resource,
     int resource_type)
 {
-    switch (resource_type)
+    if (resource_type < 0 || resource_type > 2)
     {
-    case 0:
-        delete static_cast<rmm::mr::pool_memory_resource<rmm::mr::device_memory_resource> *>(resource);
-        break;
-    case 1:
-        delete static_cast<rmm::mr::binning_memory_resource<rmm::mr::device_memory_resource> *>(resource);
-        break;
-    case 2:
-        delete static_cast<rmm::mr::arena_memory_resource<rmm::mr::device_memory_resource> *>(resource);
-        break;
-    default:
         return 1;
     }
 
-    rmm::mr::set_current_device_resource(rmm::mr::detail::initial_resource());
+    auto p = static_cast<MemoryResource *>(resource);
+
+    rmm::mr::set_current_device_resource(p->previous);
+
+    delete p;
+
+    return 0;
+}
+
+__host__ int GetMemoryResourceStatistics(
+    DeviceMemoryResource resource,
+    size_t *allocated_bytes,
+    size_t *peak_bytes,
+    size_t *pool_size,
+    bool *has_pool_size)
+{
+    auto p = static_cast<MemoryResource *>(resource);
+
+    auto counter = p->statistics->get_bytes_counter();
+    *allocated_bytes = counter.value;
+    *peak_bytes = counter.peak;
+
+    auto pool = dynamic_cast<rmm::mr::pool_memory_resource<rmm::mr::device_memory_resource> *>(p->mr.get());
+    *has_pool_size = pool != nullptr;
+    *pool_size = pool != nullptr ? pool->pool_size() : 0;
+
     return 0;
-}
\ No newline at end of file
+}
@@ -6,7 +6,8 @@ import pandas as pd
 import treelite
 import tl2cgen
 import xgboost as xgb
-from sklearn import datasets, model_selection
+from skl2onnx import to_onnx
+from sklearn import datasets, ensemble, model_selection
 
 if sys.platform == "win32" or sys.platform == "cygwin":
     shared_library_extension = "dll"
@@ -45,6 +46,8 @@ booster = xgb.train(
 )
 
 booster.save_model("xgboost.json")
+# the default format of xgboost 2, which is ubjson
+booster.save_model("xgboost.model")
 
 test_x.to_csv("feature.csv", index=False, header=False, float_format="%.8f")
 test_y.to_csv("label.csv", index=False, header=False, float_format="%.8f")
@@ -57,6 +60,22 @@ with open("score-xgboost.csv", "w") as f:
     for x in xgboost_scores:
         print(x, file=f)
 
+# [batch_size, num_feature + 1]
+# the features are read back from feature.csv, which is what the go tests predict
+xgboost_contribs = booster.predict(xgb.DMatrix(np.loadtxt("feature.csv", delimiter=",")), pred_contribs=True)
+np.savetxt("contrib-xgboost.csv", xgboost_contribs, delimiter=",", fmt="%.8g")
+
+# a model converted by skl2onnx, whose graph has the nodes converters add around the ensemble
+gbdt = ensemble.GradientBoostingClassifier(n_estimators=20, max_depth=3, random_state=seed)
+gbdt.fit(train_x.to_numpy(np.float32), train_y)
+gbdt_onnx = to_onnx(gbdt, train_x.to_numpy(np.float32)[:1], target_opset={"": 15, "ai.onnx.ml": 3})
+with open("sklearn-gbdt.onnx", "wb") as f:
+    f.write(gbdt_onnx.SerializeToString())
+
+# [batch_size], the probabilities of class 1
+gbdt_scores = gbdt.predict_proba(np.loadtxt("feature.csv", delimiter=",", dtype=np.float32))[:, 1]
+np.savetxt("score-sklearn-gbdt.csv", gbdt_scores, fmt="%.8g")
+
 dvalid = tl2cgen.DMatrix(test_x)
 
 model = treelite.Model.from_xgboost(booster)
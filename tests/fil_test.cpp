@@ -1,4 +1,6 @@
 #include <fstream>
+#include <iterator>
+#include <string>
 
 #include <treelite/c_api.h>
 #include <gtest/gtest.h>
@@ -76,3 +78,26 @@ TEST(FILTest, TestFIL)
 
     FreeDeviceResourceHandle(device_resource_handle);
 }
+
+TEST(FILTest, TestFILFromBuffer)
+{
+    DeviceResourceHandle device_resource_handle;
+    CreateDeviceResourceHandle(&device_resource_handle);
+
+    std::ifstream ifs("testdata/xgboost.json", std::ios::binary);
+    std::string buffer((std::istreambuf_iterator<char>(ifs)), std::istreambuf_iterator<char>());
+
+    FILModelHandle handle;
+    auto res = FILLoadModelFromBuffer(device_resource_handle, 1, buffer.data(), buffer.size(), 0, true, 0.5, 0, 0, 1, 0, &handle);
+    EXPECT_EQ(res, 0);
+
+    size_t num_features = 0;
+    res = FILGetNumFeatures(handle, &num_features);
+    EXPECT_EQ(res, 0);
+    EXPECT_EQ(num_features, 30);
+
+    res = FILFreeModel(device_resource_handle, handle);
+    EXPECT_EQ(res, 0);
+
+    FreeDeviceResourceHandle(device_resource_handle);
+}
@@ -144,3 +144,73 @@ TEST(GLMTest, TestRidgeRegression)
 
     FreeDeviceResourceHandle(device_resource_handle);
 }
+
+TEST(GLMTest, TestLinearRegression64)
+{
+    DeviceResourceHandle device_resource_handle;
+    CreateDeviceResourceHandle(&device_resource_handle);
+
+    std::vector<double> feature;
+    size_t num_col = 30;
+    size_t num_row = 0;
+
+    {
+        std::ifstream ifs_csv_file("testdata/feature.csv");
+        std::string line;
+        while (std::getline(ifs_csv_file, line))
+        {
+            std::stringstream ss(line);
+            std::string val;
+            num_row++;
+            while (std::getline(ss, val, ','))
+            {
+                feature.push_back(std::stod(val));
+            }
+        }
+    }
+
+    std::vector<double> labels;
+    {
+        std::ifstream ifs_csv_file("testdata/label.csv");
+        std::string line;
+        while (std::getline(ifs_csv_file, line))
+        {
+            labels.push_back(std::stod(line));
+        }
+    }
+
+    std::vector<double> coef(num_col);
+    std::vector<double> intercept(1);
+
+    {
+        auto res = OlsFit64(
+            device_resource_handle,
+            feature.data(),
+            num_row,
+            num_col,
+            labels.data(),
+            true,
+            true,
+            0,
+            coef.data(),
+            intercept.data());
+
+        EXPECT_EQ(res, 0);
+    }
+
+    std::vector<double> preds(num_row);
+    {
+        auto res = GemmPredict64(
+            device_resource_handle,
+            feature.data(),
+            num_row,
+            num_col,
+            coef.data(),
+            intercept[0],
+            preds.data());
+
+        EXPECT_EQ(res, 0);
+    }
+
+    FreeDeviceResourceHandle(device_resource_handle);
+}
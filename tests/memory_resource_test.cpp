@@ -40,4 +40,26 @@ TEST(MemoryResourceTest, TestUseArenaMemoryResource)
     ResetMemoryResource(mr, 2);
 
     FreeDeviceResourceHandle(device_resource_handle);
-}
\ No newline at end of file
+}
+TEST(MemoryResourceTest, TestGetMemoryResourceStatistics)
+{
+    DeviceResourceHandle device_resource_handle;
+    CreateDeviceResourceHandle(&device_resource_handle);
+
+    DeviceMemoryResource mr;
+    UsePoolMemoryResource(1024 * 1024, 8 * 1024 * 1024, &mr);
+
+    size_t allocated_bytes = 0;
+    size_t peak_bytes = 0;
+    size_t pool_size = 0;
+    bool has_pool_size = false;
+    auto res = GetMemoryResourceStatistics(mr, &allocated_bytes, &peak_bytes, &pool_size, &has_pool_size);
+    EXPECT_EQ(res, 0);
+    EXPECT_EQ(allocated_bytes, 0);
+    EXPECT_TRUE(has_pool_size);
+    EXPECT_GE(pool_size, 1024 * 1024);
+
+    ResetMemoryResource(mr, 0);
+
+    FreeDeviceResourceHandle(device_resource_handle);
+}